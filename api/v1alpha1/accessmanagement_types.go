@@ -15,6 +15,8 @@
 package v1alpha1
 
 import (
+	"slices"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -22,6 +24,10 @@ const (
 	AccessManagementKind = "AccessManagement"
 
 	AccessManagementName = "hmc"
+
+	// DefaultClusterAccessRole is the only ClusterRole the ClusterAccesses may grant
+	// if the AccessManagement does not list any.
+	DefaultClusterAccessRole = "view"
 )
 
 // AccessManagementSpec defines the desired state of AccessManagement
@@ -29,6 +35,9 @@ type AccessManagementSpec struct {
 	// AccessRules is the list of access rules. Each AccessRule enforces
 	// objects distribution to the TargetNamespaces.
 	AccessRules []AccessRule `json:"accessRules,omitempty"`
+	// ClusterAccessRoles is the list of the ClusterRoles of the workload clusters
+	// the ClusterAccesses are allowed to grant. Only the "view" ClusterRole may be granted if unset.
+	ClusterAccessRoles []string `json:"clusterAccessRoles,omitempty"`
 }
 
 // AccessManagementStatus defines the observed state of AccessManagement
@@ -95,6 +104,16 @@ type AccessManagementList struct {
 	Items           []AccessManagement `json:"items"`
 }
 
+// ClusterAccessRoleAllowed reports whether the ClusterAccesses may grant the given ClusterRole.
+// A nil AccessManagement allows only the default ClusterRole.
+func (in *AccessManagement) ClusterAccessRoleAllowed(role string) bool {
+	if in == nil || len(in.Spec.ClusterAccessRoles) == 0 {
+		return role == DefaultClusterAccessRole
+	}
+
+	return slices.Contains(in.Spec.ClusterAccessRoles, role)
+}
+
 func init() {
 	SchemeBuilder.Register(&AccessManagement{}, &AccessManagementList{})
 }
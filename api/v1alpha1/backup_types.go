@@ -20,13 +20,48 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const (
+	// BackupKind is the string representation of a Backup.
+	BackupKind = "Backup"
+
+	// BackupScopeLabel is set on the Velero Backups to indicate which objects have been backed up.
+	BackupScopeLabel = "hmc.mirantis.com/backup-scope"
+	// BackupScopeClusterDeployments indicates the Velero Backup contains
+	// only the selected ClusterDeployments and the objects they depend on.
+	BackupScopeClusterDeployments = "ClusterDeployments"
+
+	// ClusterDeploymentBackupLabelPrefix followed by the name of a ClusterDeployment forms the label
+	// marking the objects required to restore the ClusterDeployment, the label value is the namespace
+	// of the ClusterDeployment. Objects shared between several ClusterDeployments carry several labels.
+	ClusterDeploymentBackupLabelPrefix = "backup.hmc.mirantis.com/"
+)
+
 // BackupSpec defines the desired state of Backup
 type BackupSpec struct {
+	// ClusterDeployments narrows the Backup down to the selected ClusterDeployments
+	// and the objects they depend on, namely their HelmReleases, CAPI objects,
+	// kubeconfig Secrets, Sveltos Profiles, Credentials and cluster identities.
+	// The whole management cluster is backed up if not set.
+	ClusterDeployments *ClusterDeploymentsSelector `json:"clusterDeployments,omitempty"`
 	// Oneshot indicates whether the Backup should not be scheduled
 	// and rather created immediately and only once.
 	Oneshot bool `json:"oneshot,omitempty"`
 }
 
+// ClusterDeploymentsSelector selects ClusterDeployments within a single namespace.
+// +kubebuilder:validation:XValidation:rule="has(self.names) || has(self.labelSelector)",message="either names or labelSelector must be set"
+type ClusterDeploymentsSelector struct {
+	// LabelSelector selects the ClusterDeployments by their labels.
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+
+	// +kubebuilder:validation:MinLength=1
+
+	// Namespace of the ClusterDeployments.
+	Namespace string `json:"namespace"`
+	// Names of the ClusterDeployments.
+	Names []string `json:"names,omitempty"`
+}
+
 // BackupStatus defines the observed state of Backup
 type BackupStatus struct {
 	// Reference to the underlying Velero object being managed.
@@ -40,6 +75,22 @@ type BackupStatus struct {
 	NextAttempt *metav1.Time `json:"nextAttempt,omitempty"`
 	// Last Velero Backup that has been created.
 	LastBackup *velerov1.BackupStatus `json:"lastBackup,omitempty"`
+	// ClusterDeployments holds the names of the ClusterDeployments selected by the Backup.
+	// Always absent for the Backups of the whole management cluster.
+	ClusterDeployments []string `json:"clusterDeployments,omitempty"`
+	// Pruning holds the results of the enforcement of the retention policy
+	// defined in the Management. Only applicable to the scheduled Backups.
+	Pruning *BackupPruningStatus `json:"pruning,omitempty"`
+}
+
+// BackupPruningStatus holds the results of the retention policy enforcement.
+type BackupPruningStatus struct {
+	// LastPruneTime is the last time any of the Velero Backups has been pruned.
+	LastPruneTime *metav1.Time `json:"lastPruneTime,omitempty"`
+	// LastPruned holds the names of the Velero Backups pruned the last time.
+	LastPruned []string `json:"lastPruned,omitempty"`
+	// Retained is the number of the Velero Backups kept according to the retention policy.
+	Retained int `json:"retained"`
 }
 
 // +kubebuilder:object:root=true
@@ -0,0 +1,109 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ClusterAccessKind is the string representation of a ClusterAccess.
+	ClusterAccessKind = "ClusterAccess"
+	// ClusterAccessFinalizer is the finalizer revoking the access issued by the ClusterAccess.
+	ClusterAccessFinalizer = "hmc.mirantis.com/cluster-access"
+
+	// ClusterAccessNamespace is the namespace in the workload cluster the ServiceAccounts
+	// of the ClusterAccesses are created in.
+	ClusterAccessNamespace = "hmc-cluster-access"
+
+	// ExpiredReason indicates the access issued by the ClusterAccess has expired and has been revoked.
+	ExpiredReason = "Expired"
+)
+
+// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="spec is immutable"
+
+// ClusterAccessSpec defines the desired state of ClusterAccess
+type ClusterAccessSpec struct {
+	// +kubebuilder:validation:MinLength=1
+
+	// ClusterDeployment is the name of the ClusterDeployment in the same namespace
+	// the kubeconfig is issued for.
+	ClusterDeployment string `json:"clusterDeployment"`
+
+	// +kubebuilder:validation:MinLength=1
+
+	// ClusterRole is the name of the ClusterRole in the workload cluster granted to the issued kubeconfig.
+	ClusterRole string `json:"clusterRole"`
+	// Namespaces restricts the ClusterRole to the given namespaces of the workload cluster.
+	// The ClusterRole is granted cluster-wide if empty.
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// +kubebuilder:default:="8h"
+	// +kubebuilder:validation:XValidation:rule="duration(self) >= duration('10m')",message="ttl must be at least 10m"
+
+	// TTL is the lifetime of the issued kubeconfig. Once it has passed,
+	// the access is revoked and the kubeconfig Secret is deleted.
+	TTL metav1.Duration `json:"ttl,omitempty"`
+}
+
+// ClusterAccessStatus defines the observed state of ClusterAccess
+type ClusterAccessStatus struct {
+	// ExpirationTime is the time the issued kubeconfig expires at.
+	ExpirationTime *metav1.Time `json:"expirationTime,omitempty"`
+	// KubeconfigSecret is the name of the Secret in the namespace of the ClusterAccess
+	// holding the issued kubeconfig under the "value" key.
+	KubeconfigSecret string `json:"kubeconfigSecret,omitempty"`
+	// Conditions contains details for the current state of the ClusterAccess.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// ObservedGeneration is the last observed generation.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+func (in *ClusterAccess) GetConditions() *[]metav1.Condition {
+	return &in.Status.Conditions
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Cluster",type=string,JSONPath=`.spec.clusterDeployment`
+// +kubebuilder:printcolumn:name="ClusterRole",type=string,JSONPath=`.spec.clusterRole`
+// +kubebuilder:printcolumn:name="Secret",type=string,JSONPath=`.status.kubeconfigSecret`
+// +kubebuilder:printcolumn:name="Expires",type=date,JSONPath=`.status.expirationTime`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+
+// ClusterAccess is the Schema for the clusteraccesses API.
+// It issues a short-lived kubeconfig of the workload cluster of a ClusterDeployment
+// authenticated with a ServiceAccount token and limited to the given ClusterRole,
+// so that the admin kubeconfig of the cluster does not have to be handed out.
+type ClusterAccess struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterAccessSpec   `json:"spec,omitempty"`
+	Status ClusterAccessStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterAccessList contains a list of ClusterAccess
+type ClusterAccessList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterAccess `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterAccess{}, &ClusterAccessList{})
+}
@@ -15,6 +15,11 @@
 package v1alpha1
 
 import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -32,6 +37,15 @@ const (
 	HMCManagedLabelValue = "true"
 
 	ClusterNameLabelKey = "cluster.x-k8s.io/cluster-name"
+
+	// ClusterDeploymentPausedAnnotation marks the CAPI Clusters paused by the ClusterDeployment.
+	ClusterDeploymentPausedAnnotation = "hmc.mirantis.com/cluster-deployment-paused"
+	// ClusterDeploymentRollbackAnnotation requests the rollback of the ClusterDeployment
+	// to the template and config of the revision with the given number from its history.
+	ClusterDeploymentRollbackAnnotation = "hmc.mirantis.com/rollback-to-revision"
+
+	// ClusterDeploymentMaxHistory is the maximum number of revisions kept in the history of the ClusterDeployment.
+	ClusterDeploymentMaxHistory = 10
 )
 
 const (
@@ -43,10 +57,77 @@ const (
 	HelmChartReadyCondition = "HelmChartReady"
 	// HelmReleaseReadyCondition indicates the corresponding HelmRelease is ready and fully reconciled.
 	HelmReleaseReadyCondition = "HelmReleaseReady"
+	// ClusterAdoptedCondition indicates the existing CAPI Cluster has been taken over by the ClusterDeployment.
+	ClusterAdoptedCondition = "ClusterAdopted"
+	// PausedCondition indicates the ClusterDeployment is paused along with
+	// the HelmRelease, the CAPI Cluster and the Sveltos Profile.
+	PausedCondition = "Paused"
+	// DriftedCondition indicates the live objects rendered by the template have been changed
+	// outside of HMC and differ from the deployed Helm release.
+	DriftedCondition = "Drifted"
+	// AutoscalingReadyCondition indicates the cluster-autoscaler of the cluster is installed and ready.
+	AutoscalingReadyCondition = "AutoscalingReady"
+	// RolledBackCondition reports the outcome of the rollback requested with the
+	// ClusterDeploymentRollbackAnnotation until the spec of the ClusterDeployment changes.
+	// The failed rollback is reported until the annotation is removed.
+	RolledBackCondition = "RolledBack"
 	// ReadyCondition indicates the ClusterDeployment is ready and fully reconciled.
 	ReadyCondition string = "Ready"
 )
 
+// DeletionPolicy defines what happens to the cluster when the ClusterDeployment is deleted.
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete deletes the cluster along with the ClusterDeployment.
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	// DeletionPolicyOrphan detaches the CAPI objects and the services deployed
+	// on the cluster from HMC and leaves the cluster running.
+	DeletionPolicyOrphan DeletionPolicy = "Orphan"
+)
+
+// UpgradeCheck is a check of the cluster health performed after the cluster is upgraded to another template.
+type UpgradeCheck string
+
+const (
+	// UpgradeCheckCluster requires the health checks of the ClusterTemplate, e.g. the CAPI conditions, to pass.
+	UpgradeCheckCluster UpgradeCheck = "Cluster"
+	// UpgradeCheckNodes requires the Nodes of all of the Machines to be healthy.
+	UpgradeCheckNodes UpgradeCheck = "Nodes"
+	// UpgradeCheckServices requires all of the services to be deployed.
+	UpgradeCheckServices UpgradeCheck = "Services"
+)
+
+// UpgradePhase is the phase of the upgrade of the cluster to another template.
+type UpgradePhase string
+
+const (
+	// UpgradePhaseProgressing indicates the cluster is being rolled out with the new template.
+	UpgradePhaseProgressing UpgradePhase = "Progressing"
+	// UpgradePhaseSucceeded indicates the cluster has been rolled out and passed the health checks.
+	UpgradePhaseSucceeded UpgradePhase = "Succeeded"
+	// UpgradePhaseRolledBack indicates the cluster has been rolled back to the previous template.
+	UpgradePhaseRolledBack UpgradePhase = "RolledBack"
+	// UpgradePhaseFailed indicates the cluster has not become healthy in time and the rollback is disabled.
+	UpgradePhaseFailed UpgradePhase = "Failed"
+)
+
+// RevisionOutcome is the outcome of the deployment of a revision of the cluster.
+type RevisionOutcome string
+
+const (
+	// RevisionOutcomePending indicates the revision is being deployed.
+	RevisionOutcomePending RevisionOutcome = "Pending"
+	// RevisionOutcomeDeployed indicates the revision has been deployed and the cluster is ready.
+	RevisionOutcomeDeployed RevisionOutcome = "Deployed"
+	// RevisionOutcomeFailed indicates the deployment of the revision has failed.
+	RevisionOutcomeFailed RevisionOutcome = "Failed"
+	// RevisionOutcomeRolledBack indicates the revision has been rolled back by the staged upgrade.
+	RevisionOutcomeRolledBack RevisionOutcome = "RolledBack"
+	// RevisionOutcomeSuperseded indicates the revision has been replaced with another one before it was deployed.
+	RevisionOutcomeSuperseded RevisionOutcome = "Superseded"
+)
+
 // ClusterDeploymentSpec defines the desired state of ClusterDeployment
 type ClusterDeploymentSpec struct {
 	// Config allows to provide parameters for template customization.
@@ -88,6 +169,140 @@ type ClusterDeploymentSpec struct {
 	// By default the remaining services will be deployed even if conflict is detected.
 	// If set to true, the deployment will stop after encountering the first conflict.
 	StopOnConflict bool `json:"stopOnConflict,omitempty"`
+
+	// Paused suspends the reconciliation of the cluster: the HelmRelease is suspended,
+	// the CAPI Cluster and the Sveltos Profile are paused.
+	// Allows to perform manual maintenance on the cluster without HMC interfering.
+	Paused bool `json:"paused,omitempty"`
+
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="adopt is immutable"
+
+	// Adopt indicates that the CAPI Cluster with the name of the ClusterDeployment already exists
+	// in its namespace and should be taken over instead of being provisioned.
+	// The template values missing in the Config are computed from the existing CAPI objects,
+	// then the objects rendered by the template are given the Helm ownership metadata.
+	// The names of the existing objects must match the ones rendered by the template.
+	Adopt bool `json:"adopt,omitempty"`
+
+	// +kubebuilder:default:=Delete
+	// +kubebuilder:validation:Enum=Delete;Orphan
+
+	// DeletionPolicy defines what happens to the cluster when the ClusterDeployment is deleted.
+	// Delete deletes the CAPI objects and therefore the cloud resources of the cluster.
+	// Orphan detaches the CAPI objects from HMC dropping the owner references and the Flux labels,
+	// and leaves both them and the services deployed on the cluster in place.
+	// Allows to migrate the cluster to another management cluster or decommission HMC
+	// without destroying the workloads. Should be set before the ClusterDeployment is deleted.
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// Upgrade enables the staged upgrades of the cluster: once the Template is changed the cluster is
+	// considered upgraded only after it has been rolled out and passed the health checks,
+	// otherwise it is rolled back to the previous template.
+	// If not set, the new template is applied immediately.
+	Upgrade *UpgradeSpec `json:"upgrade,omitempty"`
+
+	// MaintenanceWindow is the name of the MaintenanceWindow the template upgrades,
+	// config and services changes are postponed to. The initial deployment is not postponed.
+	MaintenanceWindow string `json:"maintenanceWindow,omitempty"`
+
+	// DriftDetection enables the periodic comparison of the objects rendered by the template
+	// with the live ones. The changes made directly to the live objects, e.g. with kubectl edit,
+	// are reported with the Drifted condition. If not set, the drift is not detected.
+	DriftDetection *DriftDetectionSpec `json:"driftDetection,omitempty"`
+
+	// Autoscaling enables the cluster-autoscaler of the worker machines of the cluster.
+	// The cluster-autoscaler is installed in the management cluster in the namespace of the ClusterDeployment
+	// and scales the MachineDeployments and MachinePools of the cluster within the given bounds.
+	// The bounds are passed to the template in the autoscaling value, the templates supporting it
+	// leave the number of the worker machines to the cluster-autoscaler, otherwise the workers number
+	// of the template is applied again on the template or config changes.
+	Autoscaling *AutoscalingSpec `json:"autoscaling,omitempty"`
+
+	// +listType=map
+	// +listMapKey=name
+
+	// WorkerPools are the groups of the worker machines of the cluster in addition to the ones configured in Config.
+	// The worker pools are translated into the values of the template with its worker pools mapping
+	// and take precedence over the same values given in Config. Requires the template to declare the mapping.
+	WorkerPools []WorkerPool `json:"workerPools,omitempty"`
+}
+
+// WorkerPool is a group of the worker machines of the cluster sharing the same configuration.
+type WorkerPool struct {
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=32
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+
+	// Name is the name of the worker pool unique within the cluster,
+	// it is used in the names of the objects of the worker pool.
+	Name string `json:"name"`
+	// InstanceType is the provider specific type of the machines, e.g. t3.small.
+	// The default of the template is used if not set.
+	InstanceType string `json:"instanceType,omitempty"`
+
+	// +kubebuilder:validation:Minimum=0
+
+	// Replicas is the number of the machines in the worker pool.
+	Replicas int32 `json:"replicas"`
+	// Labels are the labels of the nodes of the worker pool.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Taints are the taints of the nodes of the worker pool.
+	Taints []corev1.Taint `json:"taints,omitempty"`
+	// FailureDomain is the failure domain, e.g. the availability zone, the machines of the worker pool are placed in.
+	FailureDomain string `json:"failureDomain,omitempty"`
+}
+
+// +kubebuilder:validation:XValidation:rule="self.minWorkers <= self.maxWorkers",message="minWorkers must not be greater than maxWorkers"
+
+// AutoscalingSpec configures the cluster-autoscaler of the cluster.
+type AutoscalingSpec struct {
+	// +kubebuilder:validation:MinLength=1
+
+	// Template is the name of the ServiceTemplate in the same namespace
+	// of the cluster-autoscaler chart, e.g. cluster-autoscaler-9-43-2.
+	Template string `json:"template"`
+
+	// +kubebuilder:validation:Minimum=0
+
+	// MinWorkers is the minimum number of the worker machines of each of the MachineDeployments and MachinePools.
+	// Scaling from zero requires the infrastructure provider to report the capacity of the machines.
+	MinWorkers int32 `json:"minWorkers"`
+
+	// +kubebuilder:validation:Minimum=1
+
+	// MaxWorkers is the maximum number of the worker machines of each of the MachineDeployments and MachinePools.
+	MaxWorkers int32 `json:"maxWorkers"`
+}
+
+// DriftDetectionSpec configures the drift detection of the objects rendered by the template.
+type DriftDetectionSpec struct {
+	// +kubebuilder:default:="10m"
+
+	// Interval is the interval between the drift checks.
+	Interval metav1.Duration `json:"interval,omitempty"`
+
+	// Correct reverts the drifted fields of the live objects to the rendered values.
+	// The drift is corrected only within the maintenance window if one is set.
+	Correct bool `json:"correct,omitempty"`
+}
+
+// UpgradeSpec configures the staged upgrades of the cluster.
+type UpgradeSpec struct {
+	// +kubebuilder:default:="30m"
+
+	// Timeout is the maximum duration for the upgraded cluster to roll out
+	// and pass the health checks before it is rolled back.
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+
+	// +kubebuilder:default:={Cluster,Nodes,Services}
+	// +listType=set
+
+	// Checks is the list of the health checks the upgraded cluster must pass
+	// in addition to the rollout of its control plane and worker machines.
+	Checks []UpgradeCheck `json:"checks,omitempty"`
+
+	// DisableRollback keeps the new template if the upgrade fails.
+	DisableRollback bool `json:"disableRollback,omitempty"`
 }
 
 // ClusterDeploymentStatus defines the observed state of ClusterDeployment
@@ -104,16 +319,145 @@ type ClusterDeploymentStatus struct {
 	// this cluster can be upgraded. It can be an empty array, which means no upgrades are
 	// available.
 	AvailableUpgrades []string `json:"availableUpgrades,omitempty"`
+	// Machines summarizes the CAPI machines of the cluster.
+	Machines *MachinesStatus `json:"machines,omitempty"`
+	// Template is the name of the ClusterTemplate the cluster has been successfully deployed
+	// or upgraded with, the staged upgrades are rolled back to it.
+	Template string `json:"template,omitempty"`
+	// Upgrade is the state of the latest upgrade of the cluster to another template.
+	Upgrade *UpgradeStatus `json:"upgrade,omitempty"`
+
+	// +kubebuilder:validation:MaxItems=10
+
+	// History is the list of the latest revisions of the cluster, from the oldest to the newest.
+	// A revision is recorded each time either the template or the config is changed.
+	History []ClusterRevision `json:"history,omitempty"`
+	// DryRunResult is the name of the ConfigMap in the namespace of the ClusterDeployment
+	// holding the manifest rendered by the template and the list of the objects to be created
+	// while DryRun is enabled.
+	DryRunResult string `json:"dryRunResult,omitempty"`
+	// Autoscaling is the activity of the cluster-autoscaler of the cluster.
+	Autoscaling *AutoscalingStatus `json:"autoscaling,omitempty"`
 	// ObservedGeneration is the last observed generation.
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 }
 
+// AutoscalingStatus is the activity of the cluster-autoscaler as reported in its status ConfigMap.
+type AutoscalingStatus struct {
+	// LastProbeTime is the time the cluster-autoscaler has last reported its status at.
+	LastProbeTime *metav1.Time `json:"lastProbeTime,omitempty"`
+	// Health is the health of the cluster as seen by the cluster-autoscaler, e.g. Healthy or Unhealthy.
+	Health string `json:"health,omitempty"`
+	// ScaleUp is the state of the scale up, e.g. NoActivity or InProgress.
+	ScaleUp string `json:"scaleUp,omitempty"`
+	// ScaleDown is the state of the scale down, e.g. NoCandidates or CandidatesPresent.
+	ScaleDown string `json:"scaleDown,omitempty"`
+}
+
+// ClusterRevision is a revision of the cluster, i.e. the template and the config it has been deployed with.
+type ClusterRevision struct {
+	// StartTime is the time the revision has been applied at.
+	StartTime metav1.Time `json:"startTime"`
+	// CompletionTime is the time the outcome of the revision has been observed at.
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// Config is the config the cluster has been deployed with, restored on the rollback to the revision.
+	Config *apiextensionsv1.JSON `json:"config,omitempty"`
+	// Template is the name of the ClusterTemplate.
+	Template string `json:"template"`
+	// ChartVersion is the version of the Helm chart of the ClusterTemplate.
+	ChartVersion string `json:"chartVersion,omitempty"`
+	// ValuesHash is the hash of the config.
+	ValuesHash string `json:"valuesHash"`
+	// Outcome of the deployment of the revision.
+	Outcome RevisionOutcome `json:"outcome"`
+	// Message describes the outcome.
+	Message string `json:"message,omitempty"`
+	// Revision is the sequence number of the revision.
+	Revision int64 `json:"revision"`
+}
+
+// UpgradeStatus is the state of the upgrade of the cluster to another template.
+type UpgradeStatus struct {
+	// StartTime is the time the upgrade started at.
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// CompletionTime is the time the upgrade succeeded, failed or was rolled back at.
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// FromTemplate is the name of the ClusterTemplate the cluster is upgraded from.
+	FromTemplate string `json:"fromTemplate"`
+	// ToTemplate is the name of the ClusterTemplate the cluster is upgraded to.
+	ToTemplate string `json:"toTemplate"`
+	// Phase of the upgrade.
+	Phase UpgradePhase `json:"phase"`
+	// Message describes the pending health check or the outcome of the upgrade.
+	Message string `json:"message,omitempty"`
+}
+
+// MachinesStatus is the inventory of the CAPI machines of the cluster.
+type MachinesStatus struct {
+	// ControlPlane holds the replicas of the control plane.
+	// Not set for the control planes without replicas, e.g. the managed ones.
+	ControlPlane *ReplicasStatus `json:"controlPlane,omitempty"`
+	// MachineDeployments holds the replicas of each of the MachineDeployments.
+	MachineDeployments []ReplicasStatus `json:"machineDeployments,omitempty"`
+	// MachinePools holds the replicas of each of the MachinePools.
+	MachinePools []ReplicasStatus `json:"machinePools,omitempty"`
+	// FailedMachines is the list of the Machines that have failed along with the failure reasons.
+	FailedMachines []FailedMachine `json:"failedMachines,omitempty"`
+	// ProviderIDs is the list of the provider IDs of the Machines and MachinePools.
+	ProviderIDs []string `json:"providerIDs,omitempty"`
+	// Summary is the total number of ready replicas out of the desired ones
+	// across the control plane, MachineDeployments and MachinePools, e.g. 3/5.
+	Summary string `json:"summary,omitempty"`
+	// Ready is the total number of ready replicas.
+	Ready int32 `json:"ready"`
+	// Desired is the total number of desired replicas.
+	Desired int32 `json:"desired"`
+	// Failed is the number of the failed Machines.
+	Failed int32 `json:"failed"`
+}
+
+// ReplicasStatus holds the replicas of a CAPI object managing a set of machines.
+type ReplicasStatus struct {
+	// Kind of the object.
+	Kind string `json:"kind"`
+	// Name of the object.
+	Name string `json:"name"`
+	// Summary is the number of ready replicas out of the desired ones, e.g. 2/3.
+	Summary string `json:"summary,omitempty"`
+	// Desired is the number of desired replicas.
+	Desired int32 `json:"desired"`
+	// Ready is the number of ready replicas.
+	Ready int32 `json:"ready"`
+}
+
+// FailedMachine describes a failed CAPI Machine.
+type FailedMachine struct {
+	// Name of the Machine.
+	Name string `json:"name"`
+	// ProviderID of the Machine.
+	ProviderID string `json:"providerID,omitempty"`
+	// NodeName is the name of the Node of the Machine.
+	NodeName string `json:"nodeName,omitempty"`
+	// Phase of the Machine.
+	Phase string `json:"phase,omitempty"`
+	// FailureReason is a short reason of the Machine failure.
+	FailureReason string `json:"failureReason,omitempty"`
+	// FailureMessage is a detailed description of the Machine failure.
+	FailureMessage string `json:"failureMessage,omitempty"`
+}
+
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:shortName=clusterd;cld
 // +kubebuilder:printcolumn:name="ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description="Ready",priority=0
 // +kubebuilder:printcolumn:name="status",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].message",description="Status",priority=0
+// +kubebuilder:printcolumn:name="machines",type="string",JSONPath=".status.machines.summary",description="Ready machines",priority=0
+// +kubebuilder:printcolumn:name="controlPlane",type="string",JSONPath=".status.machines.controlPlane.summary",description="Ready control plane machines",priority=1
+// +kubebuilder:printcolumn:name="failed",type="integer",JSONPath=".status.machines.failed",description="Failed machines",priority=1
 // +kubebuilder:printcolumn:name="dryRun",type="string",JSONPath=".spec.dryRun",description="Dry Run",priority=1
+// +kubebuilder:printcolumn:name="paused",type="string",JSONPath=".spec.paused",description="Paused",priority=1
+// +kubebuilder:printcolumn:name="upgrade",type="string",JSONPath=".status.upgrade.phase",description="Upgrade phase",priority=1
+// +kubebuilder:printcolumn:name="deletionPolicy",type="string",JSONPath=".spec.deletionPolicy",description="Deletion Policy",priority=1
 
 // ClusterDeployment is the Schema for the ClusterDeployments API
 type ClusterDeployment struct {
@@ -131,6 +475,33 @@ func (in *ClusterDeployment) HelmValues() (values map[string]any, err error) {
 	return values, err
 }
 
+// Values returns the fields set in the WorkerPool keyed by their JSON names,
+// e.g. instanceType, in the form of the Helm values.
+func (in *WorkerPool) Values() (values map[string]any, err error) {
+	raw, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(raw, &values)
+	return values, err
+}
+
+// GetRevision returns the revision with the given number from the history.
+func (in *ClusterDeployment) GetRevision(revision string) (*ClusterRevision, error) {
+	number, err := strconv.ParseInt(revision, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid revision %q: %w", revision, err)
+	}
+
+	for i := range in.Status.History {
+		if in.Status.History[i].Revision == number {
+			return &in.Status.History[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("revision %d is not found in the history", number)
+}
+
 func (in *ClusterDeployment) GetConditions() *[]metav1.Condition {
 	return &in.Status.Conditions
 }
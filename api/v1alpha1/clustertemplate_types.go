@@ -15,7 +15,11 @@
 package v1alpha1
 
 import (
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
 
 	"github.com/Masterminds/semver/v3"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -26,8 +30,63 @@ const (
 	ClusterTemplateKind = "ClusterTemplate"
 	// ChartAnnotationKubernetesVersion is an annotation containing the Kubernetes exact version in the SemVer format associated with a ClusterTemplate.
 	ChartAnnotationKubernetesVersion = "hmc.mirantis.com/k8s-version"
+	// ChartAnnotationHealthChecks is an annotation containing the comma-separated list of the objects
+	// defining the health of the clusters deployed from a ClusterTemplate, e.g.
+	// "clusters.cluster.x-k8s.io/v1beta1=ControlPlaneReady;InfrastructureReady,k0scontrolplanes.controlplane.cluster.x-k8s.io/v1beta1".
+	ChartAnnotationHealthChecks = "hmc.mirantis.com/health-checks"
+	// ChartAnnotationWorkerPools is an annotation containing the mapping of the worker pools of the clusters
+	// deployed from a ClusterTemplate into the values of its chart, e.g.
+	// "workerPools:name=name,instanceType=machine.instanceType,replicas=replicas".
+	ChartAnnotationWorkerPools = "hmc.mirantis.com/worker-pools"
 )
 
+// WorkerPoolFields is the list of the fields of a WorkerPool which can be mapped into the values of a template.
+var WorkerPoolFields = []string{"name", "instanceType", "replicas", "labels", "taints", "failureDomain"}
+
+// HealthCheck declares the objects of the given resource
+// whose conditions define the health of a cluster.
+type HealthCheck struct {
+	// Group of the resource.
+	Group string `json:"group,omitempty"`
+	// +kubebuilder:validation:MinLength=1
+
+	// Version of the resource.
+	Version string `json:"version"`
+	// +kubebuilder:validation:MinLength=1
+
+	// Resource is the plural name of the resource, e.g. machinepools.
+	Resource string `json:"resource"`
+	// Conditions is the list of the condition types of the objects that should be reported.
+	// If empty, the boolean status.ready field of the objects is checked instead.
+	Conditions []string `json:"conditions,omitempty"`
+}
+
+// WorkerPoolsMapping declares how the worker pools of a ClusterDeployment are translated into the values of a template.
+type WorkerPoolsMapping struct {
+	// +kubebuilder:validation:MinLength=1
+
+	// Path is the dot-separated path of the list of the worker pools in the values, e.g. workerPools.
+	Path string `json:"path"`
+	// +kubebuilder:validation:MinProperties=1
+
+	// Fields maps the fields of a worker pool, i.e. name, instanceType, replicas, labels, taints and failureDomain,
+	// to the dot-separated paths in an item of the list. The fields absent in the mapping are not supported by the template.
+	Fields map[string]string `json:"fields"`
+}
+
+// DefaultHealthChecks returns the health checks used for the ClusterTemplates
+// that declare none neither in the spec nor in the Helm chart metadata.
+func DefaultHealthChecks() []HealthCheck {
+	return []HealthCheck{
+		{Group: "cluster.x-k8s.io", Version: "v1beta1", Resource: "clusters", Conditions: []string{"ControlPlaneInitialized", "ControlPlaneReady", "InfrastructureReady"}},
+		{Group: "cluster.x-k8s.io", Version: "v1beta1", Resource: "machinedeployments", Conditions: []string{"Available"}},
+		{Group: "cluster.x-k8s.io", Version: "v1beta1", Resource: "machinepools", Conditions: []string{"Ready"}},
+		{Group: "controlplane.cluster.x-k8s.io", Version: "v1beta1", Resource: "kubeadmcontrolplanes", Conditions: []string{"Available"}},
+		// k0smotron does not use the metav1.Condition type for status.conditions
+		{Group: "controlplane.cluster.x-k8s.io", Version: "v1beta1", Resource: "k0scontrolplanes"},
+	}
+}
+
 // ClusterTemplateSpec defines the desired state of ClusterTemplate
 type ClusterTemplateSpec struct {
 	Helm HelmSpec `json:"helm"`
@@ -43,6 +102,15 @@ type ClusterTemplateSpec struct {
 	// Providers represent required CAPI providers.
 	// Should be set if not present in the Helm chart metadata.
 	Providers Providers `json:"providers,omitempty"`
+	// HealthChecks declare the objects defining the health of the clusters deployed from the ClusterTemplate.
+	// Take precedence over the ones from the Helm chart metadata.
+	// If neither is set, the defaults covering the Cluster, MachineDeployment, MachinePool,
+	// KubeadmControlPlane and K0sControlPlane objects are used.
+	HealthChecks []HealthCheck `json:"healthChecks,omitempty"`
+	// WorkerPools declares the mapping of the worker pools of the ClusterDeployments into the values of the template.
+	// Takes precedence over the one from the Helm chart metadata.
+	// If neither is set, the worker pools are not supported by the template.
+	WorkerPools *WorkerPoolsMapping `json:"workerPools,omitempty"`
 }
 
 // ClusterTemplateStatus defines the observed state of ClusterTemplate
@@ -58,6 +126,10 @@ type ClusterTemplateStatus struct {
 	KubernetesVersion string `json:"k8sVersion,omitempty"`
 	// Providers represent required CAPI providers.
 	Providers Providers `json:"providers,omitempty"`
+	// HealthChecks declare the objects defining the health of the clusters deployed from the ClusterTemplate.
+	HealthChecks []HealthCheck `json:"healthChecks,omitempty"`
+	// WorkerPools is the mapping of the worker pools of the ClusterDeployments into the values of the template.
+	WorkerPools *WorkerPoolsMapping `json:"workerPools,omitempty"`
 
 	TemplateStatusCommon `json:",inline"`
 }
@@ -74,6 +146,25 @@ func (t *ClusterTemplate) FillStatusWithProviders(annotations map[string]string)
 
 	t.Status.ProviderContracts = contractsStatus
 
+	healthChecks, err := getHealthChecks(t.Spec.HealthChecks, annotations)
+	if err != nil {
+		return fmt.Errorf("failed to get health checks for ClusterTemplate %s/%s: %w", t.GetNamespace(), t.GetName(), err)
+	}
+
+	t.Status.HealthChecks = healthChecks
+
+	t.Status.WorkerPools = t.Spec.WorkerPools.DeepCopy()
+	if mappingFromAnno := annotations[ChartAnnotationWorkerPools]; t.Status.WorkerPools == nil && strings.TrimSpace(mappingFromAnno) != "" {
+		if t.Status.WorkerPools, err = parseWorkerPoolsMapping(mappingFromAnno); err != nil {
+			return fmt.Errorf("failed to get worker pools mapping for ClusterTemplate %s/%s: %w", t.GetNamespace(), t.GetName(), err)
+		}
+	}
+	if t.Status.WorkerPools != nil {
+		if err := validateWorkerPoolFields(t.Status.WorkerPools.Fields); err != nil {
+			return fmt.Errorf("invalid worker pools mapping for ClusterTemplate %s/%s: %w", t.GetNamespace(), t.GetName(), err)
+		}
+	}
+
 	kversion := annotations[ChartAnnotationKubernetesVersion]
 	if t.Spec.KubernetesVersion != "" {
 		kversion = t.Spec.KubernetesVersion
@@ -91,6 +182,95 @@ func (t *ClusterTemplate) FillStatusWithProviders(annotations map[string]string)
 	return nil
 }
 
+func getHealthChecks(healthChecks []HealthCheck, annotations map[string]string) ([]HealthCheck, error) {
+	const (
+		multiCheckSeparator     = ","
+		multiConditionSeparator = ";"
+	)
+
+	if len(healthChecks) > 0 {
+		return slices.Clone(healthChecks), nil
+	}
+
+	checksFromAnno := annotations[ChartAnnotationHealthChecks]
+	if strings.TrimSpace(checksFromAnno) == "" {
+		return DefaultHealthChecks(), nil
+	}
+
+	var result []HealthCheck
+	for _, v := range strings.Split(checksFromAnno, multiCheckSeparator) {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		gvr, conditions, _ := strings.Cut(v, "=")
+		groupResource, version, ok := strings.Cut(gvr, "/")
+		if !ok || version == "" || groupResource == "" {
+			return nil, fmt.Errorf("incorrect health check %s given for the %s annotation, expected <resource>.<group>/<version>[=<condition>;...]", v, ChartAnnotationHealthChecks)
+		}
+
+		resource, group, _ := strings.Cut(groupResource, ".")
+		check := HealthCheck{Group: group, Version: version, Resource: resource}
+		for _, c := range strings.Split(conditions, multiConditionSeparator) {
+			if c = strings.TrimSpace(c); c != "" {
+				check.Conditions = append(check.Conditions, c)
+			}
+		}
+
+		result = append(result, check)
+	}
+
+	return result, nil
+}
+
+// parseWorkerPoolsMapping parses the worker pools mapping given in the format of the ChartAnnotationWorkerPools annotation.
+func parseWorkerPoolsMapping(mapping string) (*WorkerPoolsMapping, error) {
+	const (
+		pathSeparator  = ":"
+		fieldSeparator = ","
+	)
+
+	path, fields, ok := strings.Cut(mapping, pathSeparator)
+	if path = strings.TrimSpace(path); !ok || path == "" {
+		return nil, fmt.Errorf("incorrect worker pools mapping %s given for the %s annotation, expected <path>:<field>=<path>[,...]", mapping, ChartAnnotationWorkerPools)
+	}
+
+	result := &WorkerPoolsMapping{Path: path, Fields: make(map[string]string)}
+	for _, v := range strings.Split(fields, fieldSeparator) {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+
+		field, valuePath, _ := strings.Cut(v, "=")
+		field, valuePath = strings.TrimSpace(field), strings.TrimSpace(valuePath)
+		if field == "" || valuePath == "" {
+			return nil, fmt.Errorf("incorrect worker pool field mapping %s given for the %s annotation, expected <field>=<path>", v, ChartAnnotationWorkerPools)
+		}
+
+		result.Fields[field] = valuePath
+	}
+
+	return result, nil
+}
+
+func validateWorkerPoolFields(fields map[string]string) error {
+	if len(fields) == 0 {
+		return errors.New("no worker pool fields are mapped")
+	}
+	if _, ok := fields["name"]; !ok {
+		return errors.New("the name of the worker pools must be mapped")
+	}
+
+	for _, field := range slices.Sorted(maps.Keys(fields)) {
+		if !slices.Contains(WorkerPoolFields, field) {
+			return fmt.Errorf("unknown worker pool field %s, expected one of %s", field, strings.Join(WorkerPoolFields, ", "))
+		}
+	}
+
+	return nil
+}
+
 // GetSpecProviders returns .spec.providers of the Template.
 func (t *ClusterTemplate) GetSpecProviders() Providers {
 	return t.Spec.Providers
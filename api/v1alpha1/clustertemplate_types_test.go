@@ -0,0 +1,117 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_getHealthChecks(t *testing.T) {
+	machinePools := HealthCheck{Group: "cluster.x-k8s.io", Version: "v1beta1", Resource: "machinepools", Conditions: []string{"Ready"}}
+
+	tests := []struct {
+		name         string
+		healthChecks []HealthCheck
+		annotations  map[string]string
+		expected     []HealthCheck
+		isErr        bool
+	}{
+		{
+			name:     "defaults",
+			expected: DefaultHealthChecks(),
+		},
+		{
+			name:         "spec precedes annotations",
+			healthChecks: []HealthCheck{machinePools},
+			annotations:  map[string]string{ChartAnnotationHealthChecks: "clusters.cluster.x-k8s.io/v1beta1=Ready"},
+			expected:     []HealthCheck{machinePools},
+		},
+		{
+			name: "annotations",
+			annotations: map[string]string{
+				ChartAnnotationHealthChecks: " machinepools.cluster.x-k8s.io/v1beta1=Ready, azureasomanagedcontrolplanes.infrastructure.cluster.x-k8s.io/v1alpha1,",
+			},
+			expected: []HealthCheck{
+				machinePools,
+				{Group: "infrastructure.cluster.x-k8s.io", Version: "v1alpha1", Resource: "azureasomanagedcontrolplanes"},
+			},
+		},
+		{
+			name:        "multiple conditions",
+			annotations: map[string]string{ChartAnnotationHealthChecks: "clusters.cluster.x-k8s.io/v1beta1=ControlPlaneReady;InfrastructureReady"},
+			expected: []HealthCheck{
+				{Group: "cluster.x-k8s.io", Version: "v1beta1", Resource: "clusters", Conditions: []string{"ControlPlaneReady", "InfrastructureReady"}},
+			},
+		},
+		{
+			name:        "missing version",
+			annotations: map[string]string{ChartAnnotationHealthChecks: "machinepools.cluster.x-k8s.io=Ready"},
+			isErr:       true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := getHealthChecks(test.healthChecks, test.annotations)
+			if (err != nil) != test.isErr {
+				t.Fatalf("getHealthChecks() error = %v, want error %v", err, test.isErr)
+			}
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("getHealthChecks() = %+v, want %+v", result, test.expected)
+			}
+		})
+	}
+}
+
+func Test_parseWorkerPoolsMapping(t *testing.T) {
+	tests := []struct {
+		name     string
+		mapping  string
+		expected *WorkerPoolsMapping
+		isErr    bool
+	}{
+		{
+			name:    "mapping",
+			mapping: " workerPools: name=name, instanceType=machine.instanceType,replicas=replicas,",
+			expected: &WorkerPoolsMapping{
+				Path:   "workerPools",
+				Fields: map[string]string{"name": "name", "instanceType": "machine.instanceType", "replicas": "replicas"},
+			},
+		},
+		{
+			name:    "missing path",
+			mapping: "name=name,replicas=replicas",
+			isErr:   true,
+		},
+		{
+			name:    "missing field path",
+			mapping: "workerPools:name=name,replicas",
+			isErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := parseWorkerPoolsMapping(test.mapping)
+			if (err != nil) != test.isErr {
+				t.Fatalf("parseWorkerPoolsMapping() error = %v, want error %v", err, test.isErr)
+			}
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("parseWorkerPoolsMapping() = %+v, want %+v", result, test.expected)
+			}
+		})
+	}
+}
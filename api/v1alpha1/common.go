@@ -54,4 +54,6 @@ const (
 	ProviderK0smotronName = "k0smotron"
 	// Provider Sveltos
 	ProviderSveltosName = "projectsveltos"
+	// ProviderSveltosTargetNamespace is the namespace Sveltos is installed to.
+	ProviderSveltosTargetNamespace = "projectsveltos"
 )
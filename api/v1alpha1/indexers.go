@@ -28,6 +28,7 @@ func SetupIndexers(ctx context.Context, mgr ctrl.Manager) error {
 		setupClusterDeploymentIndexer,
 		setupClusterDeploymentServicesIndexer,
 		setupClusterDeploymentCredentialIndexer,
+		setupClusterDeploymentMaintenanceWindowIndexer,
 		setupReleaseVersionIndexer,
 		setupReleaseTemplatesIndexer,
 		setupClusterTemplateChainIndexer,
@@ -103,6 +104,24 @@ func extractCredentialNameFromClusterDeployment(rawObj client.Object) []string {
 	return []string{cluster.Spec.Credential}
 }
 
+// ClusterDeploymentMaintenanceWindowIndexKey indexer field name to extract MaintenanceWindow name reference from a ClusterDeployment object.
+const ClusterDeploymentMaintenanceWindowIndexKey = ".spec.maintenanceWindow"
+
+func setupClusterDeploymentMaintenanceWindowIndexer(ctx context.Context, mgr ctrl.Manager) error {
+	return mgr.GetFieldIndexer().IndexField(ctx, &ClusterDeployment{}, ClusterDeploymentMaintenanceWindowIndexKey, extractMaintenanceWindowNameFromClusterDeployment)
+}
+
+// extractMaintenanceWindowNameFromClusterDeployment returns referenced MaintenanceWindow name
+// declared in a ClusterDeployment object.
+func extractMaintenanceWindowNameFromClusterDeployment(rawObj client.Object) []string {
+	cluster, ok := rawObj.(*ClusterDeployment)
+	if !ok || cluster.Spec.MaintenanceWindow == "" {
+		return nil
+	}
+
+	return []string{cluster.Spec.MaintenanceWindow}
+}
+
 // release
 
 // ReleaseVersionIndexKey indexer field name to extract release version from a Release object.
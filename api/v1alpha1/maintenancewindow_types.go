@@ -0,0 +1,69 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// MaintenanceWindowKind is the string representation of a MaintenanceWindow.
+	MaintenanceWindowKind = "MaintenanceWindow"
+
+	// PendingMaintenanceCondition indicates the changes are postponed until the next maintenance window.
+	PendingMaintenanceCondition = "PendingMaintenance"
+)
+
+// MaintenanceWindowSpec defines the desired state of MaintenanceWindow
+type MaintenanceWindowSpec struct {
+	// +kubebuilder:validation:MinLength=1
+
+	// Schedule is the cron expression the windows start at, e.g. "0 22 * * sat".
+	Schedule string `json:"schedule"`
+	// Duration of each of the windows.
+	Duration metav1.Duration `json:"duration"`
+	// Timezone is the name of the IANA time zone the schedule is evaluated in, e.g. "Europe/Berlin".
+	// Defaults to UTC.
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Schedule",type=string,JSONPath=`.spec.schedule`
+// +kubebuilder:printcolumn:name="Duration",type=string,JSONPath=`.spec.duration`
+// +kubebuilder:printcolumn:name="Timezone",type=string,JSONPath=`.spec.timezone`
+
+// MaintenanceWindow is the Schema for the maintenancewindows API.
+// The template upgrades, config and services changes of the ClusterDeployments and MultiClusterServices
+// referencing the MaintenanceWindow are applied only inside its windows.
+type MaintenanceWindow struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec MaintenanceWindowSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// MaintenanceWindowList contains a list of MaintenanceWindow
+type MaintenanceWindowList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MaintenanceWindow `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MaintenanceWindow{}, &MaintenanceWindowList{})
+}
@@ -28,6 +28,16 @@ const (
 	ManagementKind      = "Management"
 	ManagementName      = "hmc"
 	ManagementFinalizer = "hmc.mirantis.com/management"
+
+	// PreUpgradeBackupCondition indicates whether the Backup required before upgrading
+	// the Management to the new Release has succeeded.
+	PreUpgradeBackupCondition = "PreUpgradeBackupSucceeded"
+	// BackupStorageAvailableCondition indicates whether all of the Velero
+	// BackupStorageLocations defined in the Management are available.
+	BackupStorageAvailableCondition = "BackupStorageAvailable"
+	// RolloutInProgressCondition indicates whether the components of the Management
+	// are being rolled out one by one.
+	RolloutInProgressCondition = "RolloutInProgress"
 )
 
 // ManagementSpec defines the desired state of Management
@@ -45,6 +55,26 @@ type ManagementSpec struct {
 	Providers []Provider `json:"providers,omitempty"`
 
 	Backup ManagementBackup `json:"backup,omitempty"`
+
+	// Rollout enables the ordered rollout of the components. If set, a component
+	// is updated only once all of the preceding ones are healthy, and a component
+	// not becoming healthy within the timeout is reverted to its previous template.
+	// If not set, all of the components are updated at once.
+	Rollout *ManagementRollout `json:"rollout,omitempty"`
+}
+
+// ManagementRollout defines the ordered rollout of the Management components.
+// The components are rolled out in the order: hmc, capi and the providers as listed,
+// except that a provider always follows the providers it depends on.
+type ManagementRollout struct {
+	// +kubebuilder:default="15m"
+
+	// Timeout is the time a component is given to become healthy after its template
+	// has been changed, once exceeded the component is reverted to its previous template.
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+
+	// ComponentTimeouts overrides the Timeout for the components by their names.
+	ComponentTimeouts map[string]metav1.Duration `json:"componentTimeouts,omitempty"`
 }
 
 // Core represents a structure describing core Management components.
@@ -71,6 +101,111 @@ type ManagementBackup struct {
 	//
 	// [Velero]: https://velero.io
 	Enabled bool `json:"enabled"`
+
+	// PreUpgrade indicates whether a oneshot Backup has to be successfully taken
+	// before the components of a new Release are rolled out.
+	// The upgrade is blocked while the Backup is in progress or if it has failed.
+	// Has no effect if the backup feature is disabled.
+	PreUpgrade bool `json:"preUpgrade,omitempty"`
+
+	// TTL is the amount of time before the Velero Backups are eligible for garbage collection.
+	// Defaults to 30 days.
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+
+	// Retention defines how many of the Velero Backups created by the scheduled Backups
+	// are kept, the rest of them are pruned. Nothing is pruned if not set,
+	// the Velero Backups are kept until their TTL expires.
+	Retention *BackupRetention `json:"retention,omitempty"`
+
+	// StorageLocations are the Velero BackupStorageLocations to store the Backups in.
+	// The locations not listed anymore are removed.
+	StorageLocations []BackupStorageLocation `json:"storageLocations,omitempty"`
+
+	// SnapshotLocations are the Velero VolumeSnapshotLocations to store the volume snapshots in.
+	// The locations not listed anymore are removed.
+	SnapshotLocations []VolumeSnapshotLocation `json:"snapshotLocations,omitempty"`
+}
+
+// BackupStorageLocation defines the Velero BackupStorageLocation reconciled by the Backup controller.
+type BackupStorageLocation struct {
+	// Config holds the provider-specific configuration, e.g. region or s3Url.
+	Config map[string]string `json:"config,omitempty"`
+
+	// +kubebuilder:validation:MinLength=1
+
+	// Name of the BackupStorageLocation.
+	Name string `json:"name"`
+
+	// +kubebuilder:validation:MinLength=1
+
+	// Provider is the name of the Velero object storage plugin, e.g. aws.
+	Provider string `json:"provider"`
+
+	// +kubebuilder:validation:MinLength=1
+
+	// Bucket is the object storage bucket to store the Backups in.
+	Bucket string `json:"bucket"`
+	// Prefix is the path inside the bucket to store the Backups under.
+	Prefix string `json:"prefix,omitempty"`
+
+	BackupLocationCredential `json:",inline"`
+
+	// Default indicates whether this location is the default one
+	// for the Backups which do not specify any location.
+	Default bool `json:"default,omitempty"`
+}
+
+// VolumeSnapshotLocation defines the Velero VolumeSnapshotLocation reconciled by the Backup controller.
+type VolumeSnapshotLocation struct {
+	// Config holds the provider-specific configuration, e.g. region.
+	Config map[string]string `json:"config,omitempty"`
+
+	// +kubebuilder:validation:MinLength=1
+
+	// Name of the VolumeSnapshotLocation.
+	Name string `json:"name"`
+
+	// +kubebuilder:validation:MinLength=1
+
+	// Provider is the name of the Velero volume snapshotter plugin, e.g. aws.
+	Provider string `json:"provider"`
+
+	BackupLocationCredential `json:",inline"`
+}
+
+// BackupLocationCredential references the Credential holding the credentials of a Velero location.
+type BackupLocationCredential struct {
+	// Credential is the name of the Credential in the system namespace.
+	// The identity of the Credential must be a Secret in the system namespace
+	// containing the credentials file in the format expected by the Velero plugin.
+	// The credentials configured in Velero itself are used if not set.
+	Credential string `json:"credential,omitempty"`
+
+	// +kubebuilder:default=cloud
+
+	// CredentialKey is the key of the Secret holding the credentials file.
+	CredentialKey string `json:"credentialKey,omitempty"`
+}
+
+// BackupRetention defines the retention policy of the Velero Backups created by the scheduled Backups.
+// A Velero Backup is retained if any of the rules selects it. Only completed Backups count towards the rules,
+// failed Backups are retained until a more recent Backup completes. Backups in progress are never pruned.
+// +kubebuilder:validation:XValidation:rule="has(self.keepLast) || has(self.keepDaily) || has(self.keepWeekly)",message="at least one of keepLast, keepDaily or keepWeekly must be set"
+type BackupRetention struct {
+	// +kubebuilder:validation:Minimum=0
+
+	// KeepLast is the number of the most recent Velero Backups to keep.
+	KeepLast int `json:"keepLast,omitempty"`
+
+	// +kubebuilder:validation:Minimum=0
+
+	// KeepDaily is the number of the most recent days to keep the latest Velero Backup for.
+	KeepDaily int `json:"keepDaily,omitempty"`
+
+	// +kubebuilder:validation:Minimum=0
+
+	// KeepWeekly is the number of the most recent weeks to keep the latest Velero Backup for.
+	KeepWeekly int `json:"keepWeekly,omitempty"`
 }
 
 // Component represents HMC management component
@@ -88,6 +223,14 @@ type Provider struct {
 	Component `json:",inline"`
 	// Name of the provider.
 	Name string `json:"name"`
+	// TargetNamespace is the namespace the provider is installed to.
+	// Defaults to the system namespace.
+	TargetNamespace string `json:"targetNamespace,omitempty"`
+	// DependsOn is the list of the names of the other providers to be installed
+	// before this one. The provider is always installed after the core components.
+	DependsOn []string `json:"dependsOn,omitempty"`
+	// CreateNamespace indicates whether the TargetNamespace has to be created if it does not exist.
+	CreateNamespace bool `json:"createNamespace,omitempty"`
 }
 
 func (in *Component) HelmValues() (values map[string]any, err error) {
@@ -104,7 +247,7 @@ func GetDefaultProviders() []Provider {
 		{Name: ProviderAzureName},
 		{Name: ProviderVSphereName},
 		{Name: ProviderOpenStackName},
-		{Name: ProviderSveltosName},
+		{Name: ProviderSveltosName, TargetNamespace: ProviderSveltosTargetNamespace, CreateNamespace: true},
 	}
 }
 
@@ -140,20 +283,49 @@ type ManagementStatus struct {
 	Components map[string]ComponentStatus `json:"components,omitempty"`
 	// BackupName is a name of the management cluster scheduled backup.
 	BackupName string `json:"backupName,omitempty"`
+	// PreUpgradeBackupName is a name of the oneshot backup taken before the latest Release upgrade.
+	PreUpgradeBackupName string `json:"preUpgradeBackupName,omitempty"`
 	// Release indicates the current Release object.
 	Release string `json:"release,omitempty"`
 	// AvailableProviders holds all available CAPI providers.
 	AvailableProviders Providers `json:"availableProviders,omitempty"`
+	// InfrastructureProviders describe the integration of the available infrastructure providers with HMC
+	// as declared by their ProviderTemplates.
+	InfrastructureProviders map[string]InfrastructureProvider `json:"infrastructureProviders,omitempty"`
+	// Conditions contains details for the current state of the Management.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 	// ObservedGeneration is the last observed generation.
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 }
 
+// InfrastructureProvider returns the description of the integration of the given infrastructure provider with HMC
+// declared by its ProviderTemplate, falling back to the builtin one for the older charts of the providers shipped with HMC.
+func (in *Management) InfrastructureProvider(name string) (InfrastructureProvider, bool) {
+	if infrastructure, ok := in.Status.InfrastructureProviders[name]; ok {
+		return *infrastructure.DeepCopy(), true
+	}
+
+	return BuiltinInfrastructureProvider(name)
+}
+
+func (in *Management) GetConditions() *[]metav1.Condition {
+	return &in.Status.Conditions
+}
+
 // ComponentStatus is the status of Management component installation
 type ComponentStatus struct {
 	// Template is the name of the Template associated with this component.
 	Template string `json:"template,omitempty"`
 	// Error stores as error message in case of failed installation
 	Error string `json:"error,omitempty"`
+	// PreviousTemplate is the name of the Template the component has been healthy with
+	// before the rollout of the current Template. Empty if no rollout is in progress.
+	PreviousTemplate string `json:"previousTemplate,omitempty"`
+	// RevertedTemplate is the name of the Template the component has been reverted from
+	// since it has not become healthy within the rollout timeout.
+	RevertedTemplate string `json:"revertedTemplate,omitempty"`
+	// RolloutStartTime is the time the rollout of the current Template has been started at.
+	RolloutStartTime *metav1.Time `json:"rolloutStartTime,omitempty"`
 	// Success represents if a component installation was successful
 	Success bool `json:"success,omitempty"`
 }
@@ -86,6 +86,10 @@ type MultiClusterServiceSpec struct {
 	// By default the remaining services will be deployed even if conflict is detected.
 	// If set to true, the deployment will stop after encountering the first conflict.
 	StopOnConflict bool `json:"stopOnConflict,omitempty"`
+
+	// MaintenanceWindow is the name of the MaintenanceWindow the services changes are postponed to.
+	// The initial deployment is not postponed.
+	MaintenanceWindow string `json:"maintenanceWindow,omitempty"`
 }
 
 // ServiceStatus contains details for the state of services.
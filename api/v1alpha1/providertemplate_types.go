@@ -16,12 +16,83 @@ package v1alpha1
 
 import (
 	"fmt"
+	"strings"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
-// ProviderTemplateKind denotes the providertemplate resource Kind.
-const ProviderTemplateKind = "ProviderTemplate"
+const (
+	// ProviderTemplateKind denotes the providertemplate resource Kind.
+	ProviderTemplateKind = "ProviderTemplate"
+
+	// ChartAnnotationInfrastructureCluster is the annotation of the Helm chart of a ProviderTemplate declaring
+	// the apiVersion and kind of the infrastructure cluster objects of the provider,
+	// e.g. infrastructure.cluster.x-k8s.io/v1beta1/DockerCluster.
+	ChartAnnotationInfrastructureCluster = "hmc.mirantis.com/infrastructure-cluster"
+	// ChartAnnotationClusterIdentityKinds is the annotation of the Helm chart of a ProviderTemplate declaring
+	// the comma-separated kinds of the ClusterIdentities the Credentials for the provider may reference.
+	ChartAnnotationClusterIdentityKinds = "hmc.mirantis.com/cluster-identity-kinds"
+	// ChartAnnotationCredentialsPropagation is the annotation of the Helm chart of a ProviderTemplate declaring
+	// the strategy the credentials are propagated into the workload clusters with.
+	ChartAnnotationCredentialsPropagation = "hmc.mirantis.com/credentials-propagation"
+	// ChartAnnotationInfrastructureHealthConditions is the annotation of the Helm chart of a ProviderTemplate declaring
+	// the comma-separated condition types of the infrastructure cluster objects defining the health of the clusters.
+	ChartAnnotationInfrastructureHealthConditions = "hmc.mirantis.com/infrastructure-health-conditions"
+)
+
+// CredentialsPropagation is the strategy the credentials are propagated into the workload clusters with.
+type CredentialsPropagation string
+
+const (
+	// CredentialsPropagationNone does not propagate any credentials.
+	CredentialsPropagationNone CredentialsPropagation = "None"
+	// CredentialsPropagationAzure propagates the cloud config of the Azure cloud controller manager.
+	CredentialsPropagationAzure CredentialsPropagation = "Azure"
+	// CredentialsPropagationVSphere propagates the configuration of the vSphere cloud controller manager and CSI driver.
+	CredentialsPropagationVSphere CredentialsPropagation = "VSphere"
+	// CredentialsPropagationOpenStack propagates the cloud config of the OpenStack cloud controller manager.
+	CredentialsPropagationOpenStack CredentialsPropagation = "OpenStack"
+)
+
+// builtinInfrastructureProviders describe the infrastructure providers shipped with HMC for the ProviderTemplates
+// of the charts released before the metadata was declared with the chart annotations.
+// The chart annotations are the source of truth, the new providers must not be added here.
+var builtinInfrastructureProviders = map[string]InfrastructureProvider{
+	"infrastructure-aws": {
+		ClusterIdentityKinds:   []string{"AWSClusterStaticIdentity", "AWSClusterRoleIdentity", "AWSClusterControllerIdentity"},
+		CredentialsPropagation: CredentialsPropagationNone,
+	},
+	"infrastructure-azure": {
+		ClusterIdentityKinds:   []string{"AzureClusterIdentity", "Secret"},
+		CredentialsPropagation: CredentialsPropagationAzure,
+	},
+	"infrastructure-vsphere": {
+		ClusterIdentityKinds:   []string{"VSphereClusterIdentity"},
+		CredentialsPropagation: CredentialsPropagationVSphere,
+	},
+	"infrastructure-openstack": {
+		ClusterIdentityKinds:   []string{"Secret"},
+		CredentialsPropagation: CredentialsPropagationOpenStack,
+	},
+}
+
+// InfrastructureProvider describes how HMC integrates with an infrastructure provider.
+type InfrastructureProvider struct {
+	// Cluster is the group, version and kind of the infrastructure cluster objects of the provider.
+	Cluster *metav1.GroupVersionKind `json:"cluster,omitempty"`
+
+	// CredentialsPropagation is the strategy the credentials are propagated into the workload clusters with,
+	// e.g. for the cloud controller manager. Nothing is propagated if not set. HMC implements the None, Azure,
+	// VSphere and OpenStack strategies, the clusters of the providers with other strategies report them as unsupported.
+	CredentialsPropagation CredentialsPropagation `json:"credentialsPropagation,omitempty"`
+	// ClusterIdentityKinds are the kinds of the ClusterIdentities the Credentials for the provider may reference.
+	// Any kind is allowed if not set.
+	ClusterIdentityKinds []string `json:"clusterIdentityKinds,omitempty"`
+	// HealthConditions are the condition types of the infrastructure cluster objects reported
+	// in the status of the ClusterDeployments and defining their health. Requires the Cluster to be set.
+	HealthConditions []string `json:"healthConditions,omitempty"`
+}
 
 // ProviderTemplateSpec defines the desired state of ProviderTemplate
 type ProviderTemplateSpec struct {
@@ -30,6 +101,9 @@ type ProviderTemplateSpec struct {
 	// Providers represent exposed CAPI providers.
 	// Should be set if not present in the Helm chart metadata.
 	Providers Providers `json:"providers,omitempty"`
+	// Infrastructure describes the integration of the infrastructure providers of the template with HMC.
+	// Takes precedence over the one from the Helm chart metadata.
+	Infrastructure *InfrastructureProvider `json:"infrastructure,omitempty"`
 }
 
 // ProviderTemplateStatus defines the observed state of ProviderTemplate
@@ -37,6 +111,8 @@ type ProviderTemplateStatus struct {
 	CAPIContracts CompatibilityContracts `json:"capiContracts,omitempty"`
 	// Providers represent exposed CAPI providers.
 	Providers Providers `json:"providers,omitempty"`
+	// Infrastructure describes the integration of the infrastructure providers of the template with HMC.
+	Infrastructure *InfrastructureProvider `json:"infrastructure,omitempty"`
 
 	TemplateStatusCommon `json:",inline"`
 }
@@ -53,9 +129,71 @@ func (t *ProviderTemplate) FillStatusWithProviders(annotations map[string]string
 
 	t.Status.CAPIContracts = contractsStatus
 
+	infrastructure, err := getInfrastructureProvider(t.Spec.Infrastructure, annotations)
+	if err != nil {
+		return fmt.Errorf("failed to get infrastructure provider metadata for ProviderTemplate %s: %w", t.GetName(), err)
+	}
+
+	t.Status.Infrastructure = infrastructure
+
 	return nil
 }
 
+// getInfrastructureProvider returns the infrastructure provider metadata either from the spec
+// or from the given annotations, nil if neither declares it.
+func getInfrastructureProvider(spec *InfrastructureProvider, annotations map[string]string) (*InfrastructureProvider, error) {
+	if spec != nil {
+		return spec.DeepCopy(), nil
+	}
+
+	splitList := func(v string) []string {
+		var list []string
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		return list
+	}
+
+	infrastructure := &InfrastructureProvider{
+		CredentialsPropagation: CredentialsPropagation(strings.TrimSpace(annotations[ChartAnnotationCredentialsPropagation])),
+		ClusterIdentityKinds:   splitList(annotations[ChartAnnotationClusterIdentityKinds]),
+		HealthConditions:       splitList(annotations[ChartAnnotationInfrastructureHealthConditions]),
+	}
+
+	if cluster := strings.TrimSpace(annotations[ChartAnnotationInfrastructureCluster]); cluster != "" {
+		idx := strings.LastIndex(cluster, "/")
+		if idx < 0 {
+			return nil, fmt.Errorf("invalid infrastructure cluster %q, expected apiVersion/kind", cluster)
+		}
+
+		gv, err := schema.ParseGroupVersion(cluster[:idx])
+		if err != nil {
+			return nil, fmt.Errorf("invalid apiVersion of the infrastructure cluster %q: %w", cluster, err)
+		}
+		infrastructure.Cluster = &metav1.GroupVersionKind{Group: gv.Group, Version: gv.Version, Kind: cluster[idx+1:]}
+	}
+
+	if infrastructure.Cluster == nil && infrastructure.CredentialsPropagation == "" &&
+		len(infrastructure.ClusterIdentityKinds) == 0 && len(infrastructure.HealthConditions) == 0 {
+		return nil, nil //nolint:nilnil // the metadata is optional
+	}
+
+	return infrastructure, nil
+}
+
+// BuiltinInfrastructureProvider returns the metadata of the given infrastructure provider shipped with HMC
+// for the ProviderTemplates not declaring it.
+func BuiltinInfrastructureProvider(name string) (InfrastructureProvider, bool) {
+	infrastructure, ok := builtinInfrastructureProviders[name]
+	if !ok {
+		return InfrastructureProvider{}, false
+	}
+
+	return *infrastructure.DeepCopy(), true
+}
+
 // GetHelmSpec returns .spec.helm of the Template.
 func (t *ProviderTemplate) GetHelmSpec() *HelmSpec {
 	return &t.Spec.Helm
@@ -0,0 +1,81 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_getInfrastructureProvider(t *testing.T) {
+	secrets := &InfrastructureProvider{ClusterIdentityKinds: []string{"Secret"}}
+
+	tests := []struct {
+		name        string
+		spec        *InfrastructureProvider
+		annotations map[string]string
+		expected    *InfrastructureProvider
+		isErr       bool
+	}{
+		{
+			name: "not declared",
+		},
+		{
+			name:        "spec precedes annotations",
+			spec:        secrets,
+			annotations: map[string]string{ChartAnnotationClusterIdentityKinds: "DockerClusterIdentity"},
+			expected:    secrets,
+		},
+		{
+			name: "annotations",
+			annotations: map[string]string{
+				ChartAnnotationInfrastructureCluster:          "infrastructure.cluster.x-k8s.io/v1beta1/DockerCluster",
+				ChartAnnotationClusterIdentityKinds:           " Secret, DockerClusterIdentity,",
+				ChartAnnotationCredentialsPropagation:         "None",
+				ChartAnnotationInfrastructureHealthConditions: "Ready,LoadBalancerAvailable",
+			},
+			expected: &InfrastructureProvider{
+				Cluster:                &metav1.GroupVersionKind{Group: "infrastructure.cluster.x-k8s.io", Version: "v1beta1", Kind: "DockerCluster"},
+				ClusterIdentityKinds:   []string{"Secret", "DockerClusterIdentity"},
+				CredentialsPropagation: CredentialsPropagationNone,
+				HealthConditions:       []string{"Ready", "LoadBalancerAvailable"},
+			},
+		},
+		{
+			name:        "missing kind",
+			annotations: map[string]string{ChartAnnotationInfrastructureCluster: "DockerCluster"},
+			isErr:       true,
+		},
+		{
+			name:        "credentials propagation unknown to HMC",
+			annotations: map[string]string{ChartAnnotationCredentialsPropagation: "Docker"},
+			expected:    &InfrastructureProvider{CredentialsPropagation: "Docker"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := getInfrastructureProvider(test.spec, test.annotations)
+			if (err != nil) != test.isErr {
+				t.Fatalf("getInfrastructureProvider() error = %v, want error %v", err, test.isErr)
+			}
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("getInfrastructureProvider() = %+v, want %+v", result, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ReleaseUpgradePlanKind is the string representation of a ReleaseUpgradePlan.
+const ReleaseUpgradePlanKind = "ReleaseUpgradePlan"
+
+// ReleaseUpgradePlanSpec defines the desired state of ReleaseUpgradePlan
+type ReleaseUpgradePlanSpec struct {
+	// +kubebuilder:validation:MinLength=1
+
+	// Release is the name of the Release the upgrade of the Management is planned to.
+	Release string `json:"release"`
+}
+
+// ProviderTemplateChange is a change of the ProviderTemplate of a Management component.
+type ProviderTemplateChange struct {
+	// Name is the name of the component.
+	Name string `json:"name"`
+	// From is the ProviderTemplate the component is currently installed from,
+	// empty if the component is added by the Release.
+	From string `json:"from,omitempty"`
+	// To is the ProviderTemplate the component is to be installed from,
+	// empty if the component is removed by the Release.
+	To string `json:"to,omitempty"`
+}
+
+// IncompatibleTemplate is a template which is to become incompatible after the upgrade.
+type IncompatibleTemplate struct {
+	// Namespace is the namespace of the template.
+	Namespace string `json:"namespace"`
+	// Name is the name of the template.
+	Name string `json:"name"`
+	// Reason is the human-readable explanation of the incompatibility.
+	Reason string `json:"reason"`
+}
+
+// AffectedClusterDeployment is a ClusterDeployment whose template is to become incompatible after the upgrade.
+type AffectedClusterDeployment struct {
+	// Namespace is the namespace of the ClusterDeployment.
+	Namespace string `json:"namespace"`
+	// Name is the name of the ClusterDeployment.
+	Name string `json:"name"`
+	// Template is the current template of the ClusterDeployment.
+	Template string `json:"template"`
+	// UpgradeTo is the first of the available upgrades of the ClusterDeployment
+	// compatible with the Release, empty if there is none.
+	UpgradeTo string `json:"upgradeTo,omitempty"`
+}
+
+// ReleaseUpgradePlanStatus defines the observed state of ReleaseUpgradePlan
+type ReleaseUpgradePlanStatus struct {
+	// CurrentRelease is the Release of the Management at the time the plan has been computed.
+	CurrentRelease string `json:"currentRelease,omitempty"`
+	// ProviderTemplates are the changes of the ProviderTemplates of the Management components.
+	ProviderTemplates []ProviderTemplateChange `json:"providerTemplates,omitempty"`
+	// IncompatibleClusterTemplates are the currently valid ClusterTemplates
+	// which are incompatible with the providers of the Release.
+	IncompatibleClusterTemplates []IncompatibleTemplate `json:"incompatibleClusterTemplates,omitempty"`
+	// AffectedClusterDeployments are the ClusterDeployments of the IncompatibleClusterTemplates.
+	AffectedClusterDeployments []AffectedClusterDeployment `json:"affectedClusterDeployments,omitempty"`
+	// IncompatibleServiceTemplates are the ServiceTemplates of the AffectedClusterDeployments whose
+	// Kubernetes constraint is not satisfied by the template the ClusterDeployment is to be upgraded to.
+	IncompatibleServiceTemplates []IncompatibleTemplate `json:"incompatibleServiceTemplates,omitempty"`
+	// Conditions contains details for the current state of the ReleaseUpgradePlan.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// ObservedGeneration is the last observed generation.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Upgradable indicates the Management can be switched to the Release
+	// without leaving any ClusterDeployment on an incompatible template.
+	Upgradable bool `json:"upgradable,omitempty"`
+}
+
+func (in *ReleaseUpgradePlan) GetConditions() *[]metav1.Condition {
+	return &in.Status.Conditions
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Release",type=string,JSONPath=`.spec.release`
+// +kubebuilder:printcolumn:name="Current",type=string,JSONPath=`.status.currentRelease`
+// +kubebuilder:printcolumn:name="Upgradable",type=boolean,JSONPath=`.status.upgradable`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ReleaseUpgradePlan is the Schema for the releaseupgradeplans API.
+// It previews the effect of switching the Management to another Release: the ProviderTemplates
+// to be changed, the ClusterTemplates to become incompatible with the providers of the Release,
+// the ClusterDeployments affected by that and the ServiceTemplates breaking their Kubernetes constraints.
+type ReleaseUpgradePlan struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ReleaseUpgradePlanSpec   `json:"spec,omitempty"`
+	Status ReleaseUpgradePlanStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ReleaseUpgradePlanList contains a list of ReleaseUpgradePlan
+type ReleaseUpgradePlanList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ReleaseUpgradePlan `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ReleaseUpgradePlan{}, &ReleaseUpgradePlanList{})
+}
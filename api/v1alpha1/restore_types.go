@@ -0,0 +1,122 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// RestoreKind is the string representation of a Restore.
+	RestoreKind = "Restore"
+
+	// RestorePausedAnnotation marks CAPI objects paused by the Restore with the given name.
+	RestorePausedAnnotation = "hmc.mirantis.com/restore-paused"
+)
+
+// RestoreStage is a stage of the management cluster restoration.
+type RestoreStage string
+
+const (
+	// RestoreStageReleases restores the HMC Releases along with the HMC CRDs.
+	RestoreStageReleases RestoreStage = "Releases"
+	// RestoreStageManagement restores the ProviderTemplates and the Management
+	// and waits for all of the Management components to be installed.
+	RestoreStageManagement RestoreStage = "Management"
+	// RestoreStageCredentials restores the Cluster and Service Templates, Credentials
+	// and the corresponding cluster identities and waits for them to be ready.
+	RestoreStageCredentials RestoreStage = "Credentials"
+	// RestoreStageClusters restores the rest of the objects, including ClusterDeployments
+	// and CAPI objects, the latter are restored paused.
+	RestoreStageClusters RestoreStage = "Clusters"
+	// RestoreStageClusterDeployments restores the ClusterDeployments from the ClusterDeployment scoped
+	// backup along with the objects they depend on, the CAPI objects are restored paused.
+	RestoreStageClusterDeployments RestoreStage = "ClusterDeployments"
+	// RestoreStageCompleted indicates the restoration is finished
+	// and the CAPI objects have been unpaused.
+	RestoreStageCompleted RestoreStage = "Completed"
+)
+
+// RestoreSpec defines the desired state of Restore
+type RestoreSpec struct {
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="backupName is immutable"
+
+	// BackupName is the name of the Velero Backup to restore the management cluster from.
+	BackupName string `json:"backupName"`
+	// NamespaceMapping maps the namespaces of the backed up ClusterDeployments to the namespaces
+	// to restore them into. Only applicable to the ClusterDeployment scoped backups.
+	NamespaceMapping map[string]string `json:"namespaceMapping,omitempty"`
+}
+
+// RestoreStatus defines the observed state of Restore
+type RestoreStatus struct {
+	// Stage is the current stage of the restoration.
+	Stage RestoreStage `json:"stage,omitempty"`
+	// Stages holds the statuses of the Velero Restores created for each of the stages.
+	Stages []RestoreStageStatus `json:"stages,omitempty"`
+	// Conditions contains details for the current state of the Restore.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// ObservedGeneration is the last observed generation.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// RestoreStageStatus is the status of the Velero Restore of a single stage.
+type RestoreStageStatus struct {
+	// Stage is the restoration stage.
+	Stage RestoreStage `json:"stage"`
+	// Name of the Velero Restore object.
+	Name string `json:"name"`
+	// Phase of the Velero Restore object.
+	Phase velerov1.RestorePhase `json:"phase,omitempty"`
+	// Warnings is a count of all warning messages generated during the Velero Restore.
+	Warnings int `json:"warnings,omitempty"`
+	// Errors is a count of all error messages generated during the Velero Restore.
+	Errors int `json:"errors,omitempty"`
+}
+
+func (in *Restore) GetConditions() *[]metav1.Condition {
+	return &in.Status.Conditions
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Backup",type=string,JSONPath=`.spec.backupName`
+// +kubebuilder:printcolumn:name="Stage",type=string,JSONPath=`.status.stage`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+
+// Restore is the Schema for the restores API
+type Restore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RestoreSpec   `json:"spec,omitempty"`
+	Status RestoreStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// RestoreList contains a list of Restore
+type RestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Restore `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Restore{}, &RestoreList{})
+}
@@ -22,6 +22,7 @@ import (
 	hcv2 "github.com/fluxcd/helm-controller/api/v2"
 	sourcev1 "github.com/fluxcd/source-controller/api/v1"
 	sveltosv1beta1 "github.com/projectsveltos/addon-controller/api/v1beta1"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/dynamic"
@@ -59,6 +60,7 @@ func init() {
 	utilruntime.Must(capz.AddToScheme(scheme))
 	utilruntime.Must(capv.AddToScheme(scheme))
 	utilruntime.Must(capo.AddToScheme(scheme))
+	utilruntime.Must(velerov1.AddToScheme(scheme))
 	// +kubebuilder:scaffold:scheme
 }
 
@@ -231,6 +233,11 @@ func main() {
 		DynamicClient:          dc,
 		SystemNamespace:        currentNamespace,
 		CreateAccessManagement: createAccessManagement,
+		// the Backup and Restore controllers are set up once Velero is installed
+		VeleroControllers: &controller.VeleroControllers{
+			Manager:         mgr,
+			SystemNamespace: currentNamespace,
+		},
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Management")
 		os.Exit(1)
@@ -297,6 +304,19 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err = (&controller.ClusterAccessReconciler{
+		Client: mgr.GetClient(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterAccess")
+		os.Exit(1)
+	}
+	if err = (&controller.ReleaseUpgradePlanReconciler{
+		Client: mgr.GetClient(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ReleaseUpgradePlan")
+		os.Exit(1)
+	}
+
 	if err = (&controller.MultiClusterServiceReconciler{
 		Client:          mgr.GetClient(),
 		SystemNamespace: currentNamespace,
@@ -304,14 +324,6 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "MultiClusterService")
 		os.Exit(1)
 	}
-	// TODO (zerospiel): disabled until the #605
-	// if err = (&controller.BackupReconciler{
-	// 	Client: mgr.GetClient(),
-	// 	Scheme: mgr.GetScheme(),
-	// }).SetupWithManager(mgr); err != nil {
-	// 	setupLog.Error(err, "unable to create controller", "controller", "Backup")
-	// 	os.Exit(1)
-	// }
 	// +kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -354,6 +366,10 @@ func setupWebhooks(mgr ctrl.Manager, currentNamespace string) error {
 		setupLog.Error(err, "unable to create webhook", "webhook", "AccessManagement")
 		return err
 	}
+	if err := (&hmcwebhook.ClusterAccessValidator{}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "ClusterAccess")
+		return err
+	}
 	if err := (&hmcwebhook.ClusterTemplateChainValidator{}).SetupWebhookWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create webhook", "webhook", "ClusterTemplateChain")
 		return err
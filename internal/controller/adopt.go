@@ -0,0 +1,227 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
+)
+
+const (
+	// metadata Helm requires to adopt existing objects into a release
+	helmReleaseNameAnnotation      = "meta.helm.sh/release-name"
+	helmReleaseNamespaceAnnotation = "meta.helm.sh/release-namespace"
+	helmManagedByLabel             = "app.kubernetes.io/managed-by"
+)
+
+// setAdoptedClusterValues computes the template values from the existing CAPI objects of the adopted cluster
+// and sets the ones missing in the Config of the given ClusterDeployment. Returns true if the ClusterDeployment has been updated.
+func (r *ClusterDeploymentReconciler) setAdoptedClusterValues(ctx context.Context, clusterDeployment *hmc.ClusterDeployment) (updated bool, _ error) {
+	cluster := new(unstructured.Unstructured)
+	cluster.SetGroupVersionKind(schema.GroupVersionKind{Group: "cluster.x-k8s.io", Version: "v1beta1", Kind: "Cluster"})
+	if err := r.Get(ctx, client.ObjectKeyFromObject(clusterDeployment), cluster); err != nil {
+		return false, fmt.Errorf("failed to get CAPI Cluster %s to adopt: %w", client.ObjectKeyFromObject(clusterDeployment), err)
+	}
+
+	computedValues, err := r.computeAdoptedClusterValues(ctx, cluster)
+	if err != nil {
+		return false, err
+	}
+
+	values, err := clusterDeployment.HelmValues()
+	if err != nil {
+		return false, fmt.Errorf("failed to parse config: %w", err)
+	}
+	if values == nil {
+		values = make(map[string]any)
+	}
+
+	valuesRaw, err := json.Marshal(values)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	// the values explicitly provided in the config take precedence
+	mergedRaw, err := json.Marshal(chartutil.CoalesceTables(values, computedValues))
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	if clusterDeployment.Spec.Config != nil && string(valuesRaw) == string(mergedRaw) {
+		return false, nil
+	}
+
+	clusterDeployment.Spec.Config = &apiextensionsv1.JSON{Raw: mergedRaw}
+	if err := r.Update(ctx, clusterDeployment); err != nil {
+		return false, fmt.Errorf("failed to update clusterDeployment %s/%s: %w", clusterDeployment.Namespace, clusterDeployment.Name, err)
+	}
+
+	ctrl.LoggerFrom(ctx).Info("Computed the template values of the adopted cluster")
+
+	return true, nil
+}
+
+// computeAdoptedClusterValues computes the values common to the cluster templates from the given CAPI Cluster,
+// its control plane and MachineDeployments. The provider specific values are expected to be set in the config.
+func (r *ClusterDeploymentReconciler) computeAdoptedClusterValues(ctx context.Context, cluster *unstructured.Unstructured) (map[string]any, error) {
+	values := make(map[string]any)
+
+	if clusterNetwork, found, _ := unstructured.NestedMap(cluster.Object, "spec", "clusterNetwork"); found {
+		values["clusterNetwork"] = clusterNetwork
+	}
+
+	controlPlane, found, err := r.getControlPlane(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		if replicas, found, _ := unstructured.NestedInt64(controlPlane.Object, "spec", "replicas"); found {
+			values["controlPlaneNumber"] = replicas
+		}
+
+		if version, found, _ := unstructured.NestedString(controlPlane.Object, "spec", "version"); found && strings.HasPrefix(controlPlane.GetKind(), "K0s") {
+			// k0smotron does not allow "+" in the version
+			values["k0s"] = map[string]any{"version": strings.Replace(version, "-k0s.", "+k0s.", 1)}
+		}
+	}
+
+	machineDeployments, err := r.listCAPIObjects(ctx, cluster.GetNamespace(), "MachineDeploymentList",
+		client.MatchingLabels{hmc.ClusterNameLabelKey: cluster.GetName()})
+	if err != nil {
+		return nil, err
+	}
+	if len(machineDeployments) > 0 {
+		var workers int64
+		for _, md := range machineDeployments {
+			replicas, _, _ := unstructured.NestedInt64(md.Object, "spec", "replicas")
+			workers += replicas
+		}
+		values["workersNumber"] = workers
+	}
+
+	return values, nil
+}
+
+// adoptClusterObjects gives the Helm ownership metadata to the existing objects rendered by the template
+// so that the Helm release takes them over instead of failing on conflicts.
+// Fails if any of the existing objects defining the cluster is not rendered by the template
+// since the cluster would be partially provisioned anew otherwise.
+func (r *ClusterDeploymentReconciler) adoptClusterObjects(ctx context.Context, actionConfig *action.Configuration, clusterDeployment *hmc.ClusterDeployment, hcChart *chart.Chart, values *apiextensionsv1.JSON) error {
+	release, err := renderTemplate(ctx, actionConfig, clusterDeployment, hcChart, values)
+	if err != nil {
+		return fmt.Errorf("failed to render the template: %w", err)
+	}
+
+	objects, err := decodeManifests(release.Manifest)
+	if err != nil {
+		return fmt.Errorf("failed to decode the rendered template: %w", err)
+	}
+
+	rendered := make(map[string]struct{}, len(objects))
+	for _, obj := range objects {
+		rendered[obj.GetKind()+"/"+obj.GetName()] = struct{}{}
+	}
+
+	existing, err := r.getAdoptedClusterObjects(ctx, clusterDeployment)
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+	for _, obj := range existing {
+		if _, ok := rendered[obj]; !ok {
+			missing = append(missing, obj)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("existing objects are not rendered by the template, check the names and the config: %s", strings.Join(missing, ", "))
+	}
+
+	for _, obj := range objects {
+		found, err := r.getReleaseObject(ctx, obj, clusterDeployment.Namespace)
+		if err != nil {
+			return err
+		}
+		if !found {
+			continue
+		}
+
+		original := obj.DeepCopy()
+
+		annotations := obj.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[helmReleaseNameAnnotation] = clusterDeployment.Name
+		annotations[helmReleaseNamespaceAnnotation] = clusterDeployment.Namespace
+		obj.SetAnnotations(annotations)
+
+		objLabels := obj.GetLabels()
+		if objLabels == nil {
+			objLabels = make(map[string]string)
+		}
+		objLabels[helmManagedByLabel] = "Helm"
+		obj.SetLabels(objLabels)
+
+		if err := r.Patch(ctx, obj, client.MergeFrom(original)); err != nil {
+			return fmt.Errorf("failed to adopt %s %s: %w", obj.GetKind(), client.ObjectKeyFromObject(obj), err)
+		}
+	}
+
+	return nil
+}
+
+// getAdoptedClusterObjects returns the kinds and names of the existing CAPI Cluster with the name of the given
+// ClusterDeployment and the objects defining its infrastructure, control plane and worker machines.
+func (r *ClusterDeploymentReconciler) getAdoptedClusterObjects(ctx context.Context, clusterDeployment *hmc.ClusterDeployment) ([]string, error) {
+	cluster := new(unstructured.Unstructured)
+	cluster.SetGroupVersionKind(schema.GroupVersionKind{Group: "cluster.x-k8s.io", Version: "v1beta1", Kind: "Cluster"})
+	if err := r.Get(ctx, client.ObjectKeyFromObject(clusterDeployment), cluster); err != nil {
+		return nil, fmt.Errorf("failed to get CAPI Cluster %s to adopt: %w", client.ObjectKeyFromObject(clusterDeployment), err)
+	}
+
+	objects := []string{cluster.GetKind() + "/" + cluster.GetName()}
+	for _, refField := range []string{"controlPlaneRef", "infrastructureRef"} {
+		ref, found, _ := unstructured.NestedStringMap(cluster.Object, "spec", refField)
+		if found && ref["kind"] != "" && ref["name"] != "" {
+			objects = append(objects, ref["kind"]+"/"+ref["name"])
+		}
+	}
+
+	for _, listKind := range []string{"MachineDeploymentList", "MachinePoolList"} {
+		items, err := r.listCAPIObjects(ctx, cluster.GetNamespace(), listKind, client.MatchingLabels{hmc.ClusterNameLabelKey: cluster.GetName()})
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			objects = append(objects, item.GetKind()+"/"+item.GetName())
+		}
+	}
+
+	return objects, nil
+}
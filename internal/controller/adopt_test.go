@@ -0,0 +1,142 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
+)
+
+var _ = Describe("ClusterDeployment adoption", func() {
+	It("should compute the template values from the CAPI Cluster", func() {
+		controllerReconciler := &ClusterDeploymentReconciler{Client: k8sClient}
+
+		cluster := &unstructured.Unstructured{Object: map[string]any{
+			"spec": map[string]any{
+				"clusterNetwork": map[string]any{
+					"pods": map[string]any{"cidrBlocks": []any{"10.244.0.0/16"}},
+				},
+			},
+		}}
+		cluster.SetAPIVersion("cluster.x-k8s.io/v1beta1")
+		cluster.SetKind("Cluster")
+		cluster.SetName("test-adopted-cluster")
+		cluster.SetNamespace(metav1.NamespaceDefault)
+
+		values, err := controllerReconciler.computeAdoptedClusterValues(context.Background(), cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(values).To(Equal(map[string]any{
+			"clusterNetwork": map[string]any{
+				"pods": map[string]any{"cidrBlocks": []any{"10.244.0.0/16"}},
+			},
+		}))
+	})
+
+	Context("When adopting the existing objects", func() {
+		const clusterName = "test-adopted-cluster"
+
+		ctx := context.Background()
+
+		// the ClusterDeployment itself is not created since only the adoption is exercised
+		clusterDeployment := &hmc.ClusterDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      clusterName,
+				Namespace: metav1.NamespaceDefault,
+			},
+			Spec: hmc.ClusterDeploymentSpec{
+				Template: "test-template",
+				Adopt:    true,
+			},
+		}
+
+		hcChart := &chart.Chart{
+			Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "test-template", Version: "0.1.0"},
+			Templates: []*chart.File{{
+				Name: "templates/cluster.yaml",
+				Data: []byte(`apiVersion: cluster.x-k8s.io/v1beta1
+kind: Cluster
+metadata:
+  name: {{ .Release.Name }}
+  namespace: {{ .Release.Namespace }}
+spec:
+  infrastructureRef:
+    apiVersion: infrastructure.cluster.x-k8s.io/v1beta1
+    kind: DockerCluster
+    name: {{ .Values.infrastructureName }}
+`),
+			}},
+		}
+
+		cluster := &unstructured.Unstructured{}
+
+		BeforeEach(func() {
+			By("creating the CAPI Cluster to adopt")
+			cluster = &unstructured.Unstructured{Object: map[string]any{
+				"spec": map[string]any{
+					"infrastructureRef": map[string]any{
+						"apiVersion": "infrastructure.cluster.x-k8s.io/v1beta1",
+						"kind":       "DockerCluster",
+						"name":       clusterName,
+					},
+				},
+			}}
+			cluster.SetAPIVersion("cluster.x-k8s.io/v1beta1")
+			cluster.SetKind("Cluster")
+			cluster.SetName(clusterName)
+			cluster.SetNamespace(metav1.NamespaceDefault)
+			Expect(k8sClient.Create(ctx, cluster)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			By("Cleanup the CAPI Cluster")
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, cluster))).To(Succeed())
+		})
+
+		It("should give the Helm ownership to the objects rendered by the template", func() {
+			controllerReconciler := &ClusterDeploymentReconciler{Client: k8sClient}
+
+			values := &apiextensionsv1.JSON{Raw: []byte(`{"infrastructureName":"` + clusterName + `"}`)}
+			Expect(controllerReconciler.adoptClusterObjects(ctx, &action.Configuration{Log: GinkgoWriter.Printf}, clusterDeployment, hcChart, values)).To(Succeed())
+
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(cluster), cluster)).To(Succeed())
+			Expect(cluster.GetAnnotations()).To(HaveKeyWithValue(helmReleaseNameAnnotation, clusterName))
+			Expect(cluster.GetAnnotations()).To(HaveKeyWithValue(helmReleaseNamespaceAnnotation, metav1.NamespaceDefault))
+			Expect(cluster.GetLabels()).To(HaveKeyWithValue(helmManagedByLabel, "Helm"))
+		})
+
+		It("should reject the existing objects not rendered by the template", func() {
+			controllerReconciler := &ClusterDeploymentReconciler{Client: k8sClient}
+
+			values := &apiextensionsv1.JSON{Raw: []byte(`{"infrastructureName":"another-cluster"}`)}
+			err := controllerReconciler.adoptClusterObjects(ctx, &action.Configuration{Log: GinkgoWriter.Printf}, clusterDeployment, hcChart, values)
+			Expect(err).To(MatchError(ContainSubstring("existing objects are not rendered by the template")))
+			Expect(err).To(MatchError(ContainSubstring("DockerCluster/" + clusterName)))
+
+			By("Checking the Cluster has been left intact")
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(cluster), cluster)).To(Succeed())
+			Expect(cluster.GetAnnotations()).NotTo(HaveKey(helmReleaseNameAnnotation))
+		})
+	})
+})
@@ -0,0 +1,297 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	fluxconditions "github.com/fluxcd/pkg/runtime/conditions"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
+	"github.com/K0rdent/kcm/internal/helm"
+)
+
+const (
+	// autoscalerMinSizeAnnotation and autoscalerMaxSizeAnnotation are the annotations of the MachineDeployments
+	// and MachinePools the cluster-autoscaler discovers the node groups and their bounds with.
+	autoscalerMinSizeAnnotation = "cluster.x-k8s.io/cluster-api-autoscaler-node-group-min-size"
+	autoscalerMaxSizeAnnotation = "cluster.x-k8s.io/cluster-api-autoscaler-node-group-max-size"
+
+	// autoscalerReleaseSuffix is the suffix of the name of the cluster-autoscaler HelmRelease of a ClusterDeployment.
+	autoscalerReleaseSuffix = "-autoscaler"
+	// autoscalerStatusConfigMap is the ConfigMap in the workload cluster the cluster-autoscaler reports its status with.
+	autoscalerStatusConfigMap = "cluster-autoscaler-status"
+	// autoscalerStatusTimeLayout is the layout of the time in the status of the cluster-autoscaler.
+	autoscalerStatusTimeLayout = "2006-01-02 15:04:05.999999999 -0700 MST"
+)
+
+// reconcileAutoscaling annotates the MachineDeployments and MachinePools of the given ClusterDeployment
+// with the autoscaling bounds and installs the cluster-autoscaler managing them in the management cluster.
+// The annotations and the cluster-autoscaler are removed once the autoscaling is disabled.
+func (r *ClusterDeploymentReconciler) reconcileAutoscaling(ctx context.Context, clusterDeployment *hmc.ClusterDeployment) error {
+	autoscaling := clusterDeployment.Spec.Autoscaling
+	if autoscaling == nil {
+		if clusterDeployment.Status.Autoscaling == nil &&
+			apimeta.FindStatusCondition(clusterDeployment.Status.Conditions, hmc.AutoscalingReadyCondition) == nil {
+			return nil
+		}
+
+		if err := helm.DeleteHelmRelease(ctx, r.Client, clusterDeployment.Name+autoscalerReleaseSuffix, clusterDeployment.Namespace); err != nil {
+			return fmt.Errorf("failed to delete the cluster-autoscaler HelmRelease: %w", err)
+		}
+		if err := r.annotateNodeGroups(ctx, clusterDeployment, nil); err != nil {
+			return err
+		}
+
+		clusterDeployment.Status.Autoscaling = nil
+		apimeta.RemoveStatusCondition(clusterDeployment.GetConditions(), hmc.AutoscalingReadyCondition)
+		return nil
+	}
+
+	if err := r.annotateNodeGroups(ctx, clusterDeployment, autoscaling); err != nil {
+		return err
+	}
+
+	template := new(hmc.ServiceTemplate)
+	key := client.ObjectKey{Name: autoscaling.Template, Namespace: clusterDeployment.Namespace}
+	if err := r.Get(ctx, key, template); err != nil {
+		return fmt.Errorf("failed to get ServiceTemplate %s: %w", key, err)
+	}
+	if !template.Status.Valid || template.Status.ChartRef == nil {
+		return fmt.Errorf("ServiceTemplate %s is not marked as valid", key)
+	}
+
+	values, err := autoscalerValues(clusterDeployment)
+	if err != nil {
+		return err
+	}
+
+	hr, _, err := helm.ReconcileHelmRelease(ctx, r.Client, clusterDeployment.Name+autoscalerReleaseSuffix, clusterDeployment.Namespace, helm.ReconcileHelmReleaseOpts{
+		Values: values,
+		OwnerReference: &metav1.OwnerReference{
+			APIVersion: hmc.GroupVersion.String(),
+			Kind:       hmc.ClusterDeploymentKind,
+			Name:       clusterDeployment.Name,
+			UID:        clusterDeployment.UID,
+		},
+		ChartRef: template.Status.ChartRef,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reconcile the cluster-autoscaler HelmRelease: %w", err)
+	}
+
+	if !fluxconditions.IsReady(hr) {
+		apimeta.SetStatusCondition(clusterDeployment.GetConditions(), metav1.Condition{
+			Type:    hmc.AutoscalingReadyCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  hmc.ProgressingReason,
+			Message: "Waiting for the cluster-autoscaler to be installed",
+		})
+		return nil
+	}
+
+	apimeta.SetStatusCondition(clusterDeployment.GetConditions(), metav1.Condition{
+		Type:    hmc.AutoscalingReadyCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  hmc.SucceededReason,
+		Message: fmt.Sprintf("Worker machines are autoscaled between %d and %d", autoscaling.MinWorkers, autoscaling.MaxWorkers),
+	})
+
+	if err := r.reconcileAutoscalingStatus(ctx, clusterDeployment); err != nil {
+		// the activity is informational only, hence do not report the autoscaling as failed
+		ctrl.LoggerFrom(ctx).Error(err, "failed to collect the cluster-autoscaler status")
+	}
+
+	return nil
+}
+
+// annotateNodeGroups sets the autoscaling bounds annotations of the MachineDeployments and MachinePools
+// of the given ClusterDeployment, the annotations are removed if autoscaling is nil.
+func (r *ClusterDeploymentReconciler) annotateNodeGroups(ctx context.Context, clusterDeployment *hmc.ClusterDeployment, autoscaling *hmc.AutoscalingSpec) error {
+	selector := client.MatchingLabels{hmc.ClusterNameLabelKey: clusterDeployment.Name}
+	for _, listKind := range []string{"MachineDeploymentList", "MachinePoolList"} {
+		nodeGroups, err := r.listCAPIObjects(ctx, clusterDeployment.Namespace, listKind, selector)
+		if err != nil {
+			return err
+		}
+
+		for _, nodeGroup := range nodeGroups {
+			if err := r.annotateNodeGroup(ctx, &nodeGroup, autoscaling); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// annotateNodeGroup patches the autoscaling bounds annotations of the given MachineDeployment or MachinePool if they differ.
+func (r *ClusterDeploymentReconciler) annotateNodeGroup(ctx context.Context, nodeGroup *unstructured.Unstructured, autoscaling *hmc.AutoscalingSpec) error {
+	annotations := nodeGroup.GetAnnotations()
+	desired := map[string]string{}
+	if autoscaling != nil {
+		desired[autoscalerMinSizeAnnotation] = strconv.Itoa(int(autoscaling.MinWorkers))
+		desired[autoscalerMaxSizeAnnotation] = strconv.Itoa(int(autoscaling.MaxWorkers))
+	}
+
+	changed := false
+	for _, key := range []string{autoscalerMinSizeAnnotation, autoscalerMaxSizeAnnotation} {
+		value, set := desired[key]
+		current, exists := annotations[key]
+		switch {
+		case set && (!exists || current != value):
+			if annotations == nil {
+				annotations = make(map[string]string)
+			}
+			annotations[key] = value
+			changed = true
+		case !set && exists:
+			delete(annotations, key)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	original := nodeGroup.DeepCopy()
+	nodeGroup.SetAnnotations(annotations)
+	if err := r.Patch(ctx, nodeGroup, client.MergeFrom(original)); err != nil {
+		return fmt.Errorf("failed to set autoscaling annotations of %s %s: %w", nodeGroup.GetKind(), client.ObjectKeyFromObject(nodeGroup), err)
+	}
+
+	return nil
+}
+
+// setAutoscalingHelmValues sets the autoscaling bounds into the values of the cluster template,
+// so the templates supporting it render the autoscaler annotations and leave the replicas
+// of the MachineDeployments and MachinePools to the cluster-autoscaler.
+func setAutoscalingHelmValues(values *apiextensionsv1.JSON, autoscaling *hmc.AutoscalingSpec) (*apiextensionsv1.JSON, error) {
+	if autoscaling == nil {
+		return values, nil
+	}
+
+	valuesJSON := make(map[string]any)
+	if err := json.Unmarshal(values.Raw, &valuesJSON); err != nil {
+		return nil, fmt.Errorf("error unmarshalling values: %w", err)
+	}
+
+	valuesJSON["autoscaling"] = map[string]any{
+		"minWorkers": autoscaling.MinWorkers,
+		"maxWorkers": autoscaling.MaxWorkers,
+	}
+	valuesRaw, err := json.Marshal(valuesJSON)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling values: %w", err)
+	}
+
+	return &apiextensionsv1.JSON{Raw: valuesRaw}, nil
+}
+
+// autoscalerValues returns the values of the cluster-autoscaler chart running in the management cluster
+// and managing the workload cluster of the given ClusterDeployment.
+func autoscalerValues(clusterDeployment *hmc.ClusterDeployment) (*apiextensionsv1.JSON, error) {
+	values := map[string]any{
+		"cluster-autoscaler": map[string]any{
+			"cloudProvider": "clusterapi",
+			// the workload cluster is accessed with the kubeconfig of the cluster
+			// while the CAPI objects are managed in the management cluster
+			"clusterAPIMode":             "kubeconfig-incluster",
+			"clusterAPIKubeconfigSecret": clusterDeployment.Name + "-kubeconfig",
+			"autoDiscovery": map[string]any{
+				"clusterName": clusterDeployment.Name,
+				"namespace":   clusterDeployment.Namespace,
+			},
+		},
+	}
+
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the cluster-autoscaler values: %w", err)
+	}
+
+	return &apiextensionsv1.JSON{Raw: raw}, nil
+}
+
+// reconcileAutoscalingStatus collects the activity of the cluster-autoscaler from its status ConfigMap
+// in the workload cluster of the given ClusterDeployment.
+func (r *ClusterDeploymentReconciler) reconcileAutoscalingStatus(ctx context.Context, clusterDeployment *hmc.ClusterDeployment) error {
+	clusterClient, _, found, err := getClusterClient(ctx, r.Client, clusterDeployment.Namespace, clusterDeployment.Name)
+	if err != nil || !found {
+		return err
+	}
+
+	// the cluster-autoscaler writes its status to the workload cluster in the namespace it is installed
+	// in the management cluster with, there is no status yet if either the namespace or the ConfigMap is missing
+	configMap := new(corev1.ConfigMap)
+	if err := clusterClient.Get(ctx, client.ObjectKey{Name: autoscalerStatusConfigMap, Namespace: clusterDeployment.Namespace}, configMap); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+
+		return fmt.Errorf("failed to get the cluster-autoscaler status in the cluster: %w", err)
+	}
+
+	status, err := parseAutoscalerStatus(configMap.Data["status"])
+	if err != nil {
+		return err
+	}
+	clusterDeployment.Status.Autoscaling = status
+
+	return nil
+}
+
+// parseAutoscalerStatus parses the YAML status reported by the cluster-autoscaler.
+func parseAutoscalerStatus(data string) (*hmc.AutoscalingStatus, error) {
+	type statusField struct {
+		Status string `json:"status"`
+	}
+
+	var reported struct {
+		Time        string `json:"time"`
+		ClusterWide struct {
+			Health    statusField `json:"health"`
+			ScaleUp   statusField `json:"scaleUp"`
+			ScaleDown statusField `json:"scaleDown"`
+		} `json:"clusterWide"`
+	}
+	if err := yaml.Unmarshal([]byte(data), &reported); err != nil {
+		return nil, fmt.Errorf("failed to parse the cluster-autoscaler status: %w", err)
+	}
+
+	status := &hmc.AutoscalingStatus{
+		Health:    reported.ClusterWide.Health.Status,
+		ScaleUp:   reported.ClusterWide.ScaleUp.Status,
+		ScaleDown: reported.ClusterWide.ScaleDown.Status,
+	}
+	if t, err := time.Parse(autoscalerStatusTimeLayout, reported.Time); err == nil {
+		status.LastProbeTime = &metav1.Time{Time: t}
+	}
+
+	return status, nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
+)
+
+var _ = Describe("Cluster autoscaling", func() {
+	It("should configure the cluster-autoscaler for the cluster", func() {
+		clusterDeployment := &hmc.ClusterDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "test-ns"},
+		}
+
+		values, err := autoscalerValues(clusterDeployment)
+		Expect(err).NotTo(HaveOccurred())
+
+		var decoded map[string]map[string]any
+		Expect(json.Unmarshal(values.Raw, &decoded)).To(Succeed())
+		Expect(decoded["cluster-autoscaler"]).To(HaveKeyWithValue("cloudProvider", "clusterapi"))
+		Expect(decoded["cluster-autoscaler"]).To(HaveKeyWithValue("clusterAPIKubeconfigSecret", "test-cluster-kubeconfig"))
+		Expect(decoded["cluster-autoscaler"]).To(HaveKeyWithValue("autoDiscovery", map[string]any{
+			"clusterName": "test-cluster",
+			"namespace":   "test-ns",
+		}))
+	})
+
+	It("should pass the autoscaling bounds to the cluster template", func() {
+		values, err := setAutoscalingHelmValues(&apiextensionsv1.JSON{Raw: []byte(`{"workersNumber":2}`)}, &hmc.AutoscalingSpec{
+			MinWorkers: 1,
+			MaxWorkers: 5,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(values.Raw).To(MatchJSON(`{"workersNumber":2,"autoscaling":{"minWorkers":1,"maxWorkers":5}}`))
+
+		unchanged := &apiextensionsv1.JSON{Raw: []byte(`{"workersNumber":2}`)}
+		values, err = setAutoscalingHelmValues(unchanged, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(values).To(BeIdenticalTo(unchanged))
+	})
+
+	It("should parse the status of the cluster-autoscaler", func() {
+		status, err := parseAutoscalerStatus(`
+time: 2024-11-21 10:15:30.123456789 +0000 UTC
+autoscalerStatus: Running
+clusterWide:
+  health:
+    status: Healthy
+    nodeCounts:
+      registered:
+        total: 3
+  scaleUp:
+    status: InProgress
+  scaleDown:
+    status: NoCandidates
+`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Health).To(Equal("Healthy"))
+		Expect(status.ScaleUp).To(Equal("InProgress"))
+		Expect(status.ScaleDown).To(Equal("NoCandidates"))
+		Expect(status.LastProbeTime).NotTo(BeNil())
+		Expect(status.LastProbeTime.Time).To(BeTemporally("==", time.Date(2024, 11, 21, 10, 15, 30, 123456789, time.UTC)))
+	})
+})
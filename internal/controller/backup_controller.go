@@ -16,45 +16,663 @@ package controller
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
 
+	hcv2 "github.com/fluxcd/helm-controller/api/v2"
+	sveltosv1beta1 "github.com/projectsveltos/addon-controller/api/v1beta1"
+	"github.com/robfig/cron/v3"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	velerolabel "github.com/vmware-tanzu/velero/pkg/label"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 
-	hmcmirantiscomv1alpha1 "github.com/K0rdent/kcm/api/v1alpha1"
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
 )
 
 // BackupReconciler reconciles a Backup object
 type BackupReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme          *runtime.Scheme
+	SystemNamespace string
 }
 
-// +kubebuilder:rbac:groups=hmc.mirantis.com.hmc.mirantis.com,resources=Backups,verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups=hmc.mirantis.com.hmc.mirantis.com,resources=Backups/status,verbs=get;update;patch
-// +kubebuilder:rbac:groups=hmc.mirantis.com.hmc.mirantis.com,resources=Backups/finalizers,verbs=update
+func (r *BackupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	l := ctrl.LoggerFrom(ctx)
+	l.Info("Reconciling Backup")
 
-// Reconcile is part of the main kubernetes reconciliation loop which aims to
-// move the current state of the cluster closer to the desired state.
-// TODO(user): Modify the Reconcile function to compare the state specified by
-// the Backup object against the actual cluster state, and then
-// perform operations to make the cluster state reflect the state specified by
-// the user.
-//
-// For more details, check Reconcile and its Result here:
-// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.19.0/pkg/reconcile
-func (*BackupReconciler) Reconcile(ctx context.Context, _ ctrl.Request) (ctrl.Result, error) {
-	_ = log.FromContext(ctx)
+	backup := new(hmc.Backup)
+	if err := r.Get(ctx, req.NamespacedName, backup); err != nil {
+		if apierrors.IsNotFound(err) {
+			l.Info("Backup not found, ignoring since object must be deleted")
+			return ctrl.Result{}, nil
+		}
+
+		l.Error(err, "Failed to get Backup")
+		return ctrl.Result{}, err
+	}
+
+	mgmt := new(hmc.Management)
+	if err := r.Get(ctx, client.ObjectKey{Name: hmc.ManagementName}, mgmt); err != nil {
+		if apierrors.IsNotFound(err) {
+			l.Info("Management not found, skipping Backup reconciliation")
+			return ctrl.Result{}, nil
+		}
+
+		return ctrl.Result{}, fmt.Errorf("failed to get Management: %w", err)
+	}
+
+	if !mgmt.Spec.Backup.Enabled {
+		l.Info("Backup feature is disabled in the Management, skipping Backup reconciliation")
+		return ctrl.Result{}, nil
+	}
+
+	var (
+		templateSpec *velerov1.BackupSpec
+		err          error
+	)
+	if backup.Spec.ClusterDeployments != nil {
+		templateSpec, err = r.getClusterDeploymentsBackupTemplateSpec(ctx, backup)
+	} else {
+		templateSpec, err = r.getBackupTemplateSpec(ctx)
+	}
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to construct velero backup spec: %w", err)
+	}
+	if mgmt.Spec.Backup.TTL != nil {
+		templateSpec.TTL = *mgmt.Spec.Backup.TTL
+	}
+
+	if backup.Spec.Oneshot {
+		return ctrl.Result{}, r.reconcileOneshot(ctx, backup, templateSpec)
+	}
+
+	return r.reconcileScheduled(ctx, backup, &mgmt.Spec.Backup, templateSpec)
+}
+
+// reconcileOneshot creates the single Velero Backup for the given Backup
+// and mirrors its status.
+func (r *BackupReconciler) reconcileOneshot(ctx context.Context, backup *hmc.Backup, templateSpec *velerov1.BackupSpec) error {
+	l := ctrl.LoggerFrom(ctx)
+
+	veleroBackup := &velerov1.Backup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backup.Name,
+			Namespace: r.SystemNamespace,
+		},
+	}
+
+	// velero backups are not supposed to be changed after the creation
+	err := r.Get(ctx, client.ObjectKeyFromObject(veleroBackup), veleroBackup)
+	if apierrors.IsNotFound(err) {
+		veleroBackup.Spec = *templateSpec
+		// the metadata is only applied by velero to the backups created by schedules
+		veleroBackup.Labels = templateSpec.Metadata.Labels
+		if err := controllerutil.SetControllerReference(backup, veleroBackup, r.Scheme); err != nil {
+			return fmt.Errorf("failed to set controller reference to velero Backup %s: %w", client.ObjectKeyFromObject(veleroBackup), err)
+		}
+
+		if err := r.Create(ctx, veleroBackup); err != nil {
+			return fmt.Errorf("failed to create velero Backup %s: %w", client.ObjectKeyFromObject(veleroBackup), err)
+		}
+
+		l.Info("Created velero Backup", "velero_backup", client.ObjectKeyFromObject(veleroBackup))
+	} else if err != nil {
+		return fmt.Errorf("failed to get velero Backup %s: %w", client.ObjectKeyFromObject(veleroBackup), err)
+	}
+
+	backup.Status.Reference = veleroObjectReference(veleroBackup, "Backup")
+	backup.Status.LastBackup = veleroBackup.Status.DeepCopy()
+	backup.Status.Schedule = nil
+	backup.Status.NextAttempt = nil
+
+	return r.updateStatus(ctx, backup)
+}
+
+// reconcileScheduled ensures the Velero Schedule for the given Backup exists and is up-to-date,
+// and mirrors statuses of both the Schedule and the most recent Velero Backup created by it.
+func (r *BackupReconciler) reconcileScheduled(ctx context.Context, backup *hmc.Backup, backupConfig *hmc.ManagementBackup, templateSpec *velerov1.BackupSpec) (ctrl.Result, error) {
+	l := ctrl.LoggerFrom(ctx)
+
+	cronSpec := backupConfig.Schedule
+	schedule, err := cron.ParseStandard(cronSpec)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to parse backup schedule %q: %w", cronSpec, err)
+	}
+
+	veleroSchedule := &velerov1.Schedule{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backup.Name,
+			Namespace: r.SystemNamespace,
+		},
+	}
+
+	operation, err := ctrl.CreateOrUpdate(ctx, r.Client, veleroSchedule, func() error {
+		veleroSchedule.Spec.Schedule = cronSpec
+		veleroSchedule.Spec.Template = *templateSpec
+		return controllerutil.SetControllerReference(backup, veleroSchedule, r.Scheme)
+	})
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to reconcile velero Schedule %s: %w", client.ObjectKeyFromObject(veleroSchedule), err)
+	}
+	if operation != controllerutil.OperationResultNone {
+		l.Info("Reconciled velero Schedule", "velero_schedule", client.ObjectKeyFromObject(veleroSchedule), "operation", operation)
+	}
+
+	scheduledBackups, err := r.listScheduledBackups(ctx, veleroSchedule.Name)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var lastBackup *velerov1.Backup
+	if len(scheduledBackups) > 0 {
+		last := slices.MaxFunc(scheduledBackups, func(a, b velerov1.Backup) int {
+			return a.CreationTimestamp.Compare(b.CreationTimestamp.Time)
+		})
+		lastBackup = &last
+	}
+
+	if err := r.pruneScheduledBackups(ctx, backup, scheduledBackups, backupConfig.Retention); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// velero evaluates schedules in UTC
+	nextAttempt := schedule.Next(time.Now().UTC())
+
+	backup.Status.Reference = veleroObjectReference(veleroSchedule, "Schedule")
+	backup.Status.Schedule = veleroSchedule.Status.DeepCopy()
+	backup.Status.NextAttempt = &metav1.Time{Time: nextAttempt}
+	backup.Status.LastBackup = nil
+	if lastBackup != nil {
+		backup.Status.LastBackup = lastBackup.Status.DeepCopy()
+	}
+
+	if err := r.updateStatus(ctx, backup); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// refresh the next attempt even if nothing has been changed in between
+	return ctrl.Result{RequeueAfter: time.Until(nextAttempt)}, nil
+}
+
+func (r *BackupReconciler) listScheduledBackups(ctx context.Context, scheduleName string) ([]velerov1.Backup, error) {
+	backups := new(velerov1.BackupList)
+	if err := r.List(ctx, backups,
+		client.InNamespace(r.SystemNamespace),
+		client.MatchingLabels{velerov1.ScheduleNameLabel: scheduleName},
+	); err != nil {
+		return nil, fmt.Errorf("failed to list velero Backups created by the Schedule %s: %w", scheduleName, err)
+	}
+
+	return backups.Items, nil
+}
+
+// pruneScheduledBackups requests Velero to delete the given scheduled Backups
+// which are not retained according to the retention policy, and reflects the results in the Backup status.
+func (r *BackupReconciler) pruneScheduledBackups(ctx context.Context, backup *hmc.Backup, scheduledBackups []velerov1.Backup, retention *hmc.BackupRetention) error {
+	if retention == nil {
+		backup.Status.Pruning = nil
+		return nil
+	}
+
+	l := ctrl.LoggerFrom(ctx)
+
+	retained, pruned := applyBackupRetention(scheduledBackups, retention)
+
+	var deleted []string
+	for _, veleroBackup := range pruned {
+		created, err := r.ensureDeleteBackupRequest(ctx, &veleroBackup)
+		if err != nil {
+			return err
+		}
+		if created {
+			l.Info("Requested deletion of the velero Backup pruned by the retention policy", "velero_backup", client.ObjectKeyFromObject(&veleroBackup))
+			deleted = append(deleted, veleroBackup.Name)
+		}
+	}
+
+	if backup.Status.Pruning == nil {
+		backup.Status.Pruning = new(hmc.BackupPruningStatus)
+	}
+	backup.Status.Pruning.Retained = len(retained)
+	if len(deleted) > 0 {
+		backup.Status.Pruning.LastPruneTime = &metav1.Time{Time: time.Now()}
+		backup.Status.Pruning.LastPruned = deleted
+	}
+
+	return nil
+}
+
+// applyBackupRetention splits the given Velero Backups into the retained and the pruned ones.
+// Only completed Backups count towards the rules. Failed Backups are retained for troubleshooting
+// until a more recent Backup completes, Backups in progress are always retained.
+// Backups already being deleted are neither retained nor pruned.
+func applyBackupRetention(backups []velerov1.Backup, retention *hmc.BackupRetention) (retained, pruned []velerov1.Backup) {
+	var completed, failed []velerov1.Backup
+	for _, b := range backups {
+		switch {
+		case !b.DeletionTimestamp.IsZero() || b.Status.Phase == velerov1.BackupPhaseDeleting:
+			continue
+		case b.Status.Phase == velerov1.BackupPhaseCompleted:
+			completed = append(completed, b)
+		case isBackupFailed(b.Status.Phase):
+			failed = append(failed, b)
+		default:
+			retained = append(retained, b)
+		}
+	}
+
+	// the most recent first
+	slices.SortFunc(completed, func(a, b velerov1.Backup) int {
+		return b.CreationTimestamp.Compare(a.CreationTimestamp.Time)
+	})
+
+	var (
+		days  = make(map[string]struct{})
+		weeks = make(map[string]struct{})
+	)
+	for i, b := range completed {
+		keep := i < retention.KeepLast
+
+		// velero evaluates schedules in UTC
+		created := b.CreationTimestamp.UTC()
+		if day := created.Format(time.DateOnly); len(days) < retention.KeepDaily {
+			if _, ok := days[day]; !ok {
+				days[day] = struct{}{}
+				keep = true
+			}
+		}
+		if year, week := created.ISOWeek(); len(weeks) < retention.KeepWeekly {
+			key := fmt.Sprintf("%d-%d", year, week)
+			if _, ok := weeks[key]; !ok {
+				weeks[key] = struct{}{}
+				keep = true
+			}
+		}
+
+		if keep {
+			retained = append(retained, b)
+		} else {
+			pruned = append(pruned, b)
+		}
+	}
+
+	for _, b := range failed {
+		if len(completed) > 0 && b.CreationTimestamp.Before(&completed[0].CreationTimestamp) {
+			pruned = append(pruned, b)
+		} else {
+			retained = append(retained, b)
+		}
+	}
+
+	return retained, pruned
+}
+
+func isBackupFailed(phase velerov1.BackupPhase) bool {
+	switch phase {
+	case velerov1.BackupPhasePartiallyFailed,
+		velerov1.BackupPhaseFailed,
+		velerov1.BackupPhaseFailedValidation:
+		return true
+	default:
+		return false
+	}
+}
+
+// ensureDeleteBackupRequest creates the Velero DeleteBackupRequest for the given Backup if it does not exist yet.
+// Deleting the Backup object is not enough since Velero would sync it back from the object storage.
+// Returns true if the request has been created.
+func (r *BackupReconciler) ensureDeleteBackupRequest(ctx context.Context, veleroBackup *velerov1.Backup) (bool, error) {
+	labels := map[string]string{
+		velerov1.BackupNameLabel: velerolabel.GetValidName(veleroBackup.Name),
+		velerov1.BackupUIDLabel:  string(veleroBackup.UID),
+	}
+
+	requests := new(velerov1.DeleteBackupRequestList)
+	if err := r.List(ctx, requests, client.InNamespace(veleroBackup.Namespace), client.MatchingLabels(labels)); err != nil {
+		return false, fmt.Errorf("failed to list velero DeleteBackupRequests for the Backup %s: %w", client.ObjectKeyFromObject(veleroBackup), err)
+	}
+	if len(requests.Items) > 0 {
+		return false, nil
+	}
+
+	request := &velerov1.DeleteBackupRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: veleroBackup.Name + "-",
+			Namespace:    veleroBackup.Namespace,
+			Labels:       labels,
+		},
+		Spec: velerov1.DeleteBackupRequestSpec{
+			BackupName: veleroBackup.Name,
+		},
+	}
+	if err := r.Create(ctx, request); err != nil {
+		return false, fmt.Errorf("failed to create velero DeleteBackupRequest for the Backup %s: %w", client.ObjectKeyFromObject(veleroBackup), err)
+	}
+
+	return true, nil
+}
+
+// backupGroupSuffixes are the API groups whose objects are required to rebuild the management cluster.
+var backupGroupSuffixes = []string{
+	hmc.GroupVersion.Group,
+	"cluster.x-k8s.io", // CAPI core, providers and their identities
+}
+
+// getBackupTemplateSpec constructs the Velero Backup spec including every HMC and CAPI object
+// along with the Secrets they are referencing.
+// Velero cannot filter the Secrets by references, hence all of them are included.
+func (r *BackupReconciler) getBackupTemplateSpec(ctx context.Context) (*velerov1.BackupSpec, error) {
+	crds := new(metav1.PartialObjectMetadataList)
+	crds.SetGroupVersionKind(apiextensionsv1.SchemeGroupVersion.WithKind("CustomResourceDefinitionList"))
+	if err := r.List(ctx, crds); err != nil {
+		return nil, fmt.Errorf("failed to list CustomResourceDefinitions: %w", err)
+	}
+
+	resources := []string{"secrets"}
+	for _, crd := range crds.Items {
+		// CRD names are in the <plural>.<group> format which is exactly what velero expects
+		_, group, ok := strings.Cut(crd.Name, ".")
+		if !ok {
+			continue
+		}
+
+		if slices.ContainsFunc(backupGroupSuffixes, func(suffix string) bool { return group == suffix || strings.HasSuffix(group, "."+suffix) }) {
+			resources = append(resources, crd.Name)
+		}
+	}
+	slices.Sort(resources)
+
+	return &velerov1.BackupSpec{
+		IncludedNamespaces:      []string{"*"},
+		IncludedResources:       resources,
+		IncludeClusterResources: ptr.To(true),
+		TTL:                     metav1.Duration{Duration: 30 * 24 * time.Hour}, // velero's default, set it for the sake of UX
+	}, nil
+}
+
+// getClusterDeploymentsBackupTemplateSpec constructs the Velero Backup spec including only the ClusterDeployments
+// selected by the given Backup and the objects they depend on. The objects rendered from the ClusterDeployment
+// charts and the ones created by CAPI are selected by their well-known labels, the rest of the dependencies
+// are labeled explicitly.
+func (r *BackupReconciler) getClusterDeploymentsBackupTemplateSpec(ctx context.Context, backup *hmc.Backup) (*velerov1.BackupSpec, error) {
+	selector := backup.Spec.ClusterDeployments
+
+	clusterDeployments, err := r.getSelectedClusterDeployments(ctx, selector)
+	if err != nil {
+		return nil, err
+	}
+	if len(clusterDeployments) == 0 {
+		return nil, fmt.Errorf("no ClusterDeployments in the %s namespace match the Backup selector", selector.Namespace)
+	}
+
+	var (
+		namespaces     = []string{selector.Namespace}
+		names          = make([]string, 0, len(clusterDeployments))
+		labelSelectors = make([]*metav1.LabelSelector, 0, len(clusterDeployments)+3)
+	)
+	for _, cd := range clusterDeployments {
+		names = append(names, cd.Name)
+
+		dependencies, err := r.getClusterDeploymentDependencies(ctx, &cd)
+		if err != nil {
+			return nil, err
+		}
+
+		labelKey := hmc.ClusterDeploymentBackupLabelPrefix + cd.Name
+		for _, obj := range dependencies {
+			if err := r.ensureBackupLabel(ctx, obj, labelKey, cd.Namespace); err != nil {
+				return nil, err
+			}
+
+			if ns := obj.GetNamespace(); ns != "" && !slices.Contains(namespaces, ns) {
+				namespaces = append(namespaces, ns)
+			}
+		}
+
+		labelSelectors = append(labelSelectors, &metav1.LabelSelector{
+			MatchLabels: map[string]string{labelKey: cd.Namespace},
+		})
+	}
+	slices.Sort(names)
+
+	labelSelectors = append(labelSelectors,
+		// objects rendered from the ClusterDeployment charts
+		&metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "helm.toolkit.fluxcd.io/name", Operator: metav1.LabelSelectorOpIn, Values: names},
+			{Key: "helm.toolkit.fluxcd.io/namespace", Operator: metav1.LabelSelectorOpIn, Values: []string{selector.Namespace}},
+		}},
+		// objects created by CAPI, e.g. Machines and kubeconfig Secrets
+		&metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: clusterv1.ClusterNameLabel, Operator: metav1.LabelSelectorOpIn, Values: names},
+		}},
+		// helm storage Secrets, required to upgrade the restored releases in place
+		&metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "owner", Operator: metav1.LabelSelectorOpIn, Values: []string{"helm"}},
+			{Key: "name", Operator: metav1.LabelSelectorOpIn, Values: names},
+		}},
+	)
 
-	// TODO(user): your logic here
+	backup.Status.ClusterDeployments = names
 
-	return ctrl.Result{}, nil
+	return &velerov1.BackupSpec{
+		IncludedNamespaces:      namespaces,
+		IncludeClusterResources: ptr.To(true), // cluster identities might be cluster-scoped
+		OrLabelSelectors:        labelSelectors,
+		Metadata: velerov1.Metadata{
+			Labels: map[string]string{hmc.BackupScopeLabel: hmc.BackupScopeClusterDeployments},
+		},
+		TTL: metav1.Duration{Duration: 30 * 24 * time.Hour}, // velero's default, set it for the sake of UX
+	}, nil
+}
+
+func (r *BackupReconciler) getSelectedClusterDeployments(ctx context.Context, selector *hmc.ClusterDeploymentsSelector) ([]hmc.ClusterDeployment, error) {
+	opts := []client.ListOption{client.InNamespace(selector.Namespace)}
+	if selector.LabelSelector != nil {
+		s, err := metav1.LabelSelectorAsSelector(selector.LabelSelector)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ClusterDeployments label selector: %w", err)
+		}
+		opts = append(opts, client.MatchingLabelsSelector{Selector: s})
+	}
+
+	clusterDeployments := new(hmc.ClusterDeploymentList)
+	if err := r.List(ctx, clusterDeployments, opts...); err != nil {
+		return nil, fmt.Errorf("failed to list ClusterDeployments in the %s namespace: %w", selector.Namespace, err)
+	}
+
+	if len(selector.Names) == 0 {
+		return clusterDeployments.Items, nil
+	}
+
+	return slices.DeleteFunc(clusterDeployments.Items, func(cd hmc.ClusterDeployment) bool {
+		return !slices.Contains(selector.Names, cd.Name)
+	}), nil
+}
+
+// getClusterDeploymentDependencies returns the metadata of the existing objects the given ClusterDeployment
+// depends on which do not carry any of the well-known labels, including the ClusterDeployment itself.
+func (r *BackupReconciler) getClusterDeploymentDependencies(ctx context.Context, cd *hmc.ClusterDeployment) ([]*metav1.PartialObjectMetadata, error) {
+	refs := []*corev1.ObjectReference{
+		{APIVersion: hmc.GroupVersion.String(), Kind: hmc.ClusterDeploymentKind, Namespace: cd.Namespace, Name: cd.Name},
+		{APIVersion: hcv2.GroupVersion.String(), Kind: hcv2.HelmReleaseKind, Namespace: cd.Namespace, Name: cd.Name},
+		{APIVersion: sveltosv1beta1.GroupVersion.String(), Kind: sveltosv1beta1.ProfileKind, Namespace: cd.Namespace, Name: cd.Name},
+	}
+
+	if cd.Spec.Credential != "" {
+		cred := new(hmc.Credential)
+		if err := r.Get(ctx, client.ObjectKey{Namespace: cd.Namespace, Name: cd.Spec.Credential}, cred); client.IgnoreNotFound(err) != nil {
+			return nil, fmt.Errorf("failed to get Credential %s/%s: %w", cd.Namespace, cd.Spec.Credential, err)
+		} else if err == nil {
+			refs = append(refs, &corev1.ObjectReference{APIVersion: hmc.GroupVersion.String(), Kind: hmc.CredentialKind, Namespace: cred.Namespace, Name: cred.Name})
+
+			identityRefs, err := r.getIdentityReferences(ctx, cred)
+			if err != nil {
+				return nil, err
+			}
+			refs = append(refs, identityRefs...)
+		}
+	}
+
+	dependencies := make([]*metav1.PartialObjectMetadata, 0, len(refs))
+	for _, ref := range refs {
+		obj := new(metav1.PartialObjectMetadata)
+		obj.SetGroupVersionKind(ref.GroupVersionKind())
+		if err := r.Get(ctx, client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}, obj); err != nil {
+			if apierrors.IsNotFound(err) || apimeta.IsNoMatchError(err) {
+				continue
+			}
+
+			return nil, fmt.Errorf("failed to get %s %s/%s: %w", ref.Kind, ref.Namespace, ref.Name, err)
+		}
+
+		dependencies = append(dependencies, obj)
+	}
+
+	return dependencies, nil
+}
+
+// identitySecretNameFields are the fields of the cluster identities of the supported
+// providers referencing the Secrets with the actual credentials.
+var identitySecretNameFields = [][]string{
+	{"spec", "secretRef"},            // AWS
+	{"spec", "secretName"},           // vSphere
+	{"spec", "clientSecret", "name"}, // Azure
+}
+
+// getIdentityReferences returns the references to the cluster identity of the given Credential
+// and to the Secrets referenced by the identity. The Secrets of the cluster-scoped identities
+// are expected to be in the system namespace unless the namespace is set explicitly.
+func (r *BackupReconciler) getIdentityReferences(ctx context.Context, cred *hmc.Credential) ([]*corev1.ObjectReference, error) {
+	if cred.Spec.IdentityRef == nil {
+		return nil, nil
+	}
+
+	refs := []*corev1.ObjectReference{cred.Spec.IdentityRef}
+	if cred.Spec.IdentityRef.Kind == "Secret" { // e.g. OpenStack
+		return refs, nil
+	}
+
+	identity := new(unstructured.Unstructured)
+	identity.SetAPIVersion(cred.Spec.IdentityRef.APIVersion)
+	identity.SetKind(cred.Spec.IdentityRef.Kind)
+	if err := r.Get(ctx, client.ObjectKey{Namespace: cred.Spec.IdentityRef.Namespace, Name: cred.Spec.IdentityRef.Name}, identity); err != nil {
+		if apierrors.IsNotFound(err) || apimeta.IsNoMatchError(err) {
+			return refs, nil
+		}
+
+		return nil, fmt.Errorf("failed to get cluster identity %s %s: %w", cred.Spec.IdentityRef.Kind, cred.Spec.IdentityRef.Name, err)
+	}
+
+	secretNamespace := identity.GetNamespace()
+	if ns, _, _ := unstructured.NestedString(identity.Object, "spec", "clientSecret", "namespace"); ns != "" {
+		secretNamespace = ns
+	}
+	if secretNamespace == "" {
+		secretNamespace = r.SystemNamespace
+	}
+
+	for _, field := range identitySecretNameFields {
+		if name, _, _ := unstructured.NestedString(identity.Object, field...); name != "" {
+			refs = append(refs, &corev1.ObjectReference{APIVersion: "v1", Kind: "Secret", Namespace: secretNamespace, Name: name})
+		}
+	}
+
+	return refs, nil
+}
+
+// ensureBackupLabel sets the given label on the object if it is not set yet.
+func (r *BackupReconciler) ensureBackupLabel(ctx context.Context, obj *metav1.PartialObjectMetadata, key, value string) error {
+	if obj.GetLabels()[key] == value {
+		return nil
+	}
+
+	original := obj.DeepCopy()
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[key] = value
+	obj.SetLabels(labels)
+
+	if err := r.Patch(ctx, obj, client.MergeFrom(original)); err != nil {
+		return fmt.Errorf("failed to set backup label on %s %s: %w", obj.Kind, client.ObjectKeyFromObject(obj), err)
+	}
+
+	return nil
+}
+
+func (r *BackupReconciler) updateStatus(ctx context.Context, backup *hmc.Backup) error {
+	if err := r.Status().Update(ctx, backup); err != nil {
+		return fmt.Errorf("failed to update status for Backup %s: %w", backup.Name, err)
+	}
+
+	return nil
+}
+
+func setManagedLabel(obj client.Object) {
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[hmc.HMCManagedLabelKey] = hmc.HMCManagedLabelValue
+	obj.SetLabels(labels)
+}
+
+func veleroObjectReference(obj client.Object, kind string) *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		APIVersion: velerov1.SchemeGroupVersion.String(),
+		Kind:       kind,
+		Namespace:  obj.GetNamespace(),
+		Name:       obj.GetName(),
+		UID:        obj.GetUID(),
+	}
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *BackupReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&hmcmirantiscomv1alpha1.Backup{}).
+		For(&hmc.Backup{}).
+		Owns(&velerov1.Schedule{}).
+		Watches(&velerov1.Backup{}, handler.EnqueueRequestsFromMapFunc(func(_ context.Context, o client.Object) []ctrl.Request {
+			// scheduled backups are not owned by the Backup but labeled with the Schedule name
+			if name, ok := o.GetLabels()[velerov1.ScheduleNameLabel]; ok {
+				return []ctrl.Request{{NamespacedName: client.ObjectKey{Name: name}}}
+			}
+
+			if ref := metav1.GetControllerOf(o); ref != nil && ref.Kind == hmc.BackupKind && ref.APIVersion == hmc.GroupVersion.String() {
+				return []ctrl.Request{{NamespacedName: client.ObjectKey{Name: ref.Name}}}
+			}
+
+			return nil
+		})).
+		Watches(&hmc.Management{}, handler.EnqueueRequestsFromMapFunc(r.enqueueAllBackups)).
 		Complete(r)
 }
+
+func (r *BackupReconciler) enqueueAllBackups(ctx context.Context, _ client.Object) []ctrl.Request {
+	backups := new(hmc.BackupList)
+	if err := r.List(ctx, backups); err != nil {
+		return nil
+	}
+
+	req := make([]ctrl.Request, 0, len(backups.Items))
+	for _, backup := range backups.Items {
+		req = append(req, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&backup)})
+	}
+
+	return req
+}
@@ -16,66 +16,339 @@ package controller
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
-	"k8s.io/apimachinery/pkg/api/errors"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	hmcmirantiscomv1alpha1 "github.com/K0rdent/kcm/api/v1alpha1"
+	"github.com/K0rdent/kcm/internal/utils"
 )
 
 var _ = Describe("Backup Controller", func() {
 	Context("When reconciling a resource", func() {
-		const resourceName = "test-resource"
+		const (
+			scheduledBackupName = "test-scheduled-backup"
+			oneshotBackupName   = "test-oneshot-backup"
+			scopedBackupName    = "test-scoped-backup"
+			backupSchedule      = "0 */6 * * *"
+
+			clusterDeploymentName      = "test-backup-cluster-deployment"
+			clusterDeploymentNamespace = "test-backup"
+			credentialName             = "test-backup-credential"
+		)
 
 		ctx := context.Background()
 
-		typeNamespacedName := types.NamespacedName{
-			Name:      resourceName,
-			Namespace: "default", // TODO(user):Modify as needed
-		}
-		backup := &hmcmirantiscomv1alpha1.Backup{}
+		management := &hmcmirantiscomv1alpha1.Management{}
 
 		BeforeEach(func() {
-			By("creating the custom resource for the Kind Backup")
-			err := k8sClient.Get(ctx, typeNamespacedName, backup)
-			if err != nil && errors.IsNotFound(err) {
-				resource := &hmcmirantiscomv1alpha1.Backup{
+			By("creating the system namespace")
+			Expect(client.IgnoreAlreadyExists(k8sClient.Create(ctx, &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.DefaultSystemNamespace,
+				},
+			}))).To(Succeed())
+
+			By("creating the Management with the enabled backup")
+			err := k8sClient.Get(ctx, client.ObjectKey{Name: hmcmirantiscomv1alpha1.ManagementName}, management)
+			if err != nil && apierrors.IsNotFound(err) {
+				management = &hmcmirantiscomv1alpha1.Management{
 					ObjectMeta: metav1.ObjectMeta{
-						Name:      resourceName,
-						Namespace: "default",
+						Name: hmcmirantiscomv1alpha1.ManagementName,
+					},
+					Spec: hmcmirantiscomv1alpha1.ManagementSpec{
+						Release: "test-release",
+						Backup: hmcmirantiscomv1alpha1.ManagementBackup{
+							Enabled:  true,
+							Schedule: backupSchedule,
+						},
 					},
-					// TODO(user): Specify other spec details if needed.
 				}
-				Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+				Expect(k8sClient.Create(ctx, management)).To(Succeed())
 			}
 		})
 
 		AfterEach(func() {
-			// TODO(user): Cleanup logic after each test, like removing the resource instance.
-			resource := &hmcmirantiscomv1alpha1.Backup{}
-			err := k8sClient.Get(ctx, typeNamespacedName, resource)
+			By("Cleanup the velero objects")
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &velerov1.Schedule{
+				ObjectMeta: metav1.ObjectMeta{Name: scheduledBackupName, Namespace: utils.DefaultSystemNamespace},
+			}))).To(Succeed())
+			for _, name := range []string{oneshotBackupName, scopedBackupName} {
+				Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &velerov1.Backup{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: utils.DefaultSystemNamespace},
+				}))).To(Succeed())
+			}
+
+			By("Cleanup the Backups and the Management")
+			for _, name := range []string{scheduledBackupName, oneshotBackupName, scopedBackupName} {
+				Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &hmcmirantiscomv1alpha1.Backup{
+					ObjectMeta: metav1.ObjectMeta{Name: name},
+				}))).To(Succeed())
+			}
+			Expect(k8sClient.Delete(ctx, management)).To(Succeed())
+		})
+
+		It("should create velero Schedule for the scheduled Backup", func() {
+			backup := &hmcmirantiscomv1alpha1.Backup{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: scheduledBackupName,
+				},
+			}
+			Expect(k8sClient.Create(ctx, backup)).To(Succeed())
+
+			By("Reconciling the created resource")
+			controllerReconciler := &BackupReconciler{
+				Client:          k8sClient,
+				Scheme:          k8sClient.Scheme(),
+				SystemNamespace: utils.DefaultSystemNamespace,
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: client.ObjectKeyFromObject(backup),
+			})
 			Expect(err).NotTo(HaveOccurred())
 
-			By("Cleanup the specific resource instance Backup")
-			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+			By("Checking the velero Schedule has been created")
+			schedule := &velerov1.Schedule{}
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: scheduledBackupName, Namespace: utils.DefaultSystemNamespace}, schedule)).To(Succeed())
+			Expect(schedule.Spec.Schedule).To(Equal(backupSchedule))
+			Expect(schedule.Spec.Template.IncludedResources).To(ContainElement("secrets"))
+			Expect(schedule.Spec.Template.IncludedResources).To(ContainElement("backups.hmc.mirantis.com"))
+			Expect(metav1.IsControlledBy(schedule, backup)).To(BeTrue())
+
+			By("Checking the Backup status")
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(backup), backup)).To(Succeed())
+			Expect(backup.Status.Reference).NotTo(BeNil())
+			Expect(backup.Status.Reference.Kind).To(Equal("Schedule"))
+			Expect(backup.Status.Reference.Name).To(Equal(schedule.Name))
+			Expect(backup.Status.NextAttempt).NotTo(BeNil())
+			Expect(backup.Status.LastBackup).To(BeNil())
 		})
-		It("should successfully reconcile the resource", func() {
+
+		It("should create velero Backup for the oneshot Backup", func() {
+			backup := &hmcmirantiscomv1alpha1.Backup{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: oneshotBackupName,
+				},
+				Spec: hmcmirantiscomv1alpha1.BackupSpec{
+					Oneshot: true,
+				},
+			}
+			Expect(k8sClient.Create(ctx, backup)).To(Succeed())
+
 			By("Reconciling the created resource")
 			controllerReconciler := &BackupReconciler{
-				Client: k8sClient,
-				Scheme: k8sClient.Scheme(),
+				Client:          k8sClient,
+				Scheme:          k8sClient.Scheme(),
+				SystemNamespace: utils.DefaultSystemNamespace,
 			}
 
 			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
-				NamespacedName: typeNamespacedName,
+				NamespacedName: client.ObjectKeyFromObject(backup),
 			})
 			Expect(err).NotTo(HaveOccurred())
-			// TODO(user): Add more specific assertions depending on your controller's reconciliation logic.
-			// Example: If you expect a certain status condition after reconciliation, verify it here.
+
+			By("Checking the velero Backup has been created")
+			veleroBackup := &velerov1.Backup{}
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: oneshotBackupName, Namespace: utils.DefaultSystemNamespace}, veleroBackup)).To(Succeed())
+			Expect(metav1.IsControlledBy(veleroBackup, backup)).To(BeTrue())
+
+			By("Checking the Backup status")
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(backup), backup)).To(Succeed())
+			Expect(backup.Status.Reference).NotTo(BeNil())
+			Expect(backup.Status.Reference.Kind).To(Equal("Backup"))
+			Expect(backup.Status.Schedule).To(BeNil())
+			Expect(backup.Status.NextAttempt).To(BeNil())
+		})
+
+		It("should create velero Backup for the ClusterDeployment scoped Backup", func() {
+			By("creating the ClusterDeployment along with its Credential")
+			Expect(client.IgnoreAlreadyExists(k8sClient.Create(ctx, &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: clusterDeploymentNamespace,
+				},
+			}))).To(Succeed())
+
+			credential := &hmcmirantiscomv1alpha1.Credential{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      credentialName,
+					Namespace: clusterDeploymentNamespace,
+				},
+				Spec: hmcmirantiscomv1alpha1.CredentialSpec{
+					IdentityRef: &corev1.ObjectReference{
+						APIVersion: "v1",
+						Kind:       "Secret",
+						Name:       "test-backup-identity",
+						Namespace:  clusterDeploymentNamespace,
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, credential)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, credential)
+
+			clusterDeployment := &hmcmirantiscomv1alpha1.ClusterDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      clusterDeploymentName,
+					Namespace: clusterDeploymentNamespace,
+				},
+				Spec: hmcmirantiscomv1alpha1.ClusterDeploymentSpec{
+					Template:   "test-template",
+					Credential: credentialName,
+				},
+			}
+			Expect(k8sClient.Create(ctx, clusterDeployment)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, clusterDeployment)
+
+			backup := &hmcmirantiscomv1alpha1.Backup{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: scopedBackupName,
+				},
+				Spec: hmcmirantiscomv1alpha1.BackupSpec{
+					Oneshot: true,
+					ClusterDeployments: &hmcmirantiscomv1alpha1.ClusterDeploymentsSelector{
+						Namespace: clusterDeploymentNamespace,
+						Names:     []string{clusterDeploymentName},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, backup)).To(Succeed())
+
+			By("Reconciling the created resource")
+			controllerReconciler := &BackupReconciler{
+				Client:          k8sClient,
+				Scheme:          k8sClient.Scheme(),
+				SystemNamespace: utils.DefaultSystemNamespace,
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: client.ObjectKeyFromObject(backup),
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking the velero Backup has been created")
+			labelKey := hmcmirantiscomv1alpha1.ClusterDeploymentBackupLabelPrefix + clusterDeploymentName
+			veleroBackup := &velerov1.Backup{}
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: scopedBackupName, Namespace: utils.DefaultSystemNamespace}, veleroBackup)).To(Succeed())
+			Expect(veleroBackup.Labels).To(HaveKeyWithValue(hmcmirantiscomv1alpha1.BackupScopeLabel, hmcmirantiscomv1alpha1.BackupScopeClusterDeployments))
+			Expect(veleroBackup.Spec.IncludedNamespaces).To(ConsistOf(clusterDeploymentNamespace))
+			Expect(veleroBackup.Spec.OrLabelSelectors).To(ContainElement(&metav1.LabelSelector{
+				MatchLabels: map[string]string{labelKey: clusterDeploymentNamespace},
+			}))
+
+			By("Checking the dependencies have been labeled")
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(clusterDeployment), clusterDeployment)).To(Succeed())
+			Expect(clusterDeployment.Labels).To(HaveKeyWithValue(labelKey, clusterDeploymentNamespace))
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(credential), credential)).To(Succeed())
+			Expect(credential.Labels).To(HaveKeyWithValue(labelKey, clusterDeploymentNamespace))
+
+			By("Checking the Backup status")
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(backup), backup)).To(Succeed())
+			Expect(backup.Status.ClusterDeployments).To(ConsistOf(clusterDeploymentName))
+		})
+
+		It("should prune the scheduled velero Backups according to the retention policy", func() {
+			By("Setting the retention policy in the Management")
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(management), management)).To(Succeed())
+			management.Spec.Backup.Retention = &hmcmirantiscomv1alpha1.BackupRetention{KeepLast: 1}
+			Expect(k8sClient.Update(ctx, management)).To(Succeed())
+
+			backup := &hmcmirantiscomv1alpha1.Backup{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: scheduledBackupName,
+				},
+			}
+			Expect(k8sClient.Create(ctx, backup)).To(Succeed())
+
+			By("Creating the completed velero Backups on behalf of the Schedule")
+			for i := range 3 {
+				veleroBackup := &velerov1.Backup{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      fmt.Sprintf("%s-%d", scheduledBackupName, i),
+						Namespace: utils.DefaultSystemNamespace,
+						Labels:    map[string]string{velerov1.ScheduleNameLabel: scheduledBackupName},
+					},
+				}
+				Expect(k8sClient.Create(ctx, veleroBackup)).To(Succeed())
+
+				veleroBackup.Status.Phase = velerov1.BackupPhaseCompleted
+				Expect(k8sClient.Update(ctx, veleroBackup)).To(Succeed())
+			}
+			DeferCleanup(func() {
+				Expect(k8sClient.DeleteAllOf(ctx, &velerov1.Backup{}, client.InNamespace(utils.DefaultSystemNamespace))).To(Succeed())
+				Expect(k8sClient.DeleteAllOf(ctx, &velerov1.DeleteBackupRequest{}, client.InNamespace(utils.DefaultSystemNamespace))).To(Succeed())
+			})
+
+			By("Reconciling the created resource")
+			controllerReconciler := &BackupReconciler{
+				Client:          k8sClient,
+				Scheme:          k8sClient.Scheme(),
+				SystemNamespace: utils.DefaultSystemNamespace,
+			}
+
+			for range 2 { // the deletion must be requested only once
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: client.ObjectKeyFromObject(backup),
+				})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			By("Checking the deletion of the pruned velero Backups has been requested")
+			deleteRequests := &velerov1.DeleteBackupRequestList{}
+			Expect(k8sClient.List(ctx, deleteRequests, client.InNamespace(utils.DefaultSystemNamespace))).To(Succeed())
+			Expect(deleteRequests.Items).To(HaveLen(2))
+
+			By("Checking the Backup status")
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(backup), backup)).To(Succeed())
+			Expect(backup.Status.Pruning).NotTo(BeNil())
+			Expect(backup.Status.Pruning.Retained).To(Equal(1))
+			Expect(backup.Status.Pruning.LastPruned).To(HaveLen(2))
+			Expect(backup.Status.Pruning.LastPruneTime).NotTo(BeNil())
+		})
+
+		It("should count only the completed velero Backups towards the retention policy", func() {
+			now := time.Now()
+			veleroBackup := func(name string, age time.Duration, phase velerov1.BackupPhase) velerov1.Backup {
+				return velerov1.Backup{
+					ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(now.Add(-age))},
+					Status:     velerov1.BackupStatus{Phase: phase},
+				}
+			}
+
+			backups := []velerov1.Backup{
+				veleroBackup("in-progress", 0, velerov1.BackupPhaseInProgress),
+				veleroBackup("failed-recent", time.Hour, velerov1.BackupPhaseFailed),
+				veleroBackup("completed-recent", 2*time.Hour, velerov1.BackupPhaseCompleted),
+				veleroBackup("partially-failed", 3*time.Hour, velerov1.BackupPhasePartiallyFailed),
+				veleroBackup("failed-validation", 4*time.Hour, velerov1.BackupPhaseFailedValidation),
+				veleroBackup("completed-old", 5*time.Hour, velerov1.BackupPhaseCompleted),
+				veleroBackup("completed-oldest", 6*time.Hour, velerov1.BackupPhaseCompleted),
+			}
+
+			names := func(backups []velerov1.Backup) []string {
+				result := make([]string, 0, len(backups))
+				for _, b := range backups {
+					result = append(result, b.Name)
+				}
+				return result
+			}
+
+			By("Keeping the last two completed Backups")
+			retained, pruned := applyBackupRetention(backups, &hmcmirantiscomv1alpha1.BackupRetention{KeepLast: 2})
+			Expect(names(retained)).To(ConsistOf("in-progress", "failed-recent", "completed-recent", "completed-old"))
+			Expect(names(pruned)).To(ConsistOf("partially-failed", "failed-validation", "completed-oldest"))
+
+			By("Retaining the failed Backups if none has completed since")
+			retained, pruned = applyBackupRetention(backups[:2], &hmcmirantiscomv1alpha1.BackupRetention{KeepLast: 1})
+			Expect(names(retained)).To(ConsistOf("in-progress", "failed-recent"))
+			Expect(pruned).To(BeEmpty())
 		})
 	})
 })
@@ -0,0 +1,229 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
+)
+
+// reconcileStorageLocations ensures the Velero BackupStorageLocations and VolumeSnapshotLocations
+// defined in the Management exist and are up-to-date, removes the ones not defined anymore
+// and reflects the availability of the storage locations in the Management conditions.
+// The locations are left intact if the backup feature is disabled.
+func (r *ManagementReconciler) reconcileStorageLocations(ctx context.Context, mgmt *hmc.Management) error {
+	l := ctrl.LoggerFrom(ctx)
+
+	if !mgmt.Spec.Backup.Enabled {
+		apimeta.RemoveStatusCondition(mgmt.GetConditions(), hmc.BackupStorageAvailableCondition)
+		return nil
+	}
+
+	var (
+		problems    []string
+		unavailable bool
+	)
+	for _, loc := range mgmt.Spec.Backup.StorageLocations {
+		credential, err := r.getLocationCredential(ctx, loc.BackupLocationCredential)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("BackupStorageLocation %s: %s", loc.Name, err))
+			unavailable = true
+			continue
+		}
+
+		bsl := &velerov1.BackupStorageLocation{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      loc.Name,
+				Namespace: r.SystemNamespace,
+			},
+		}
+		operation, err := ctrl.CreateOrUpdate(ctx, r.Client, bsl, func() error {
+			setManagedLabel(bsl)
+			bsl.Spec.Provider = loc.Provider
+			bsl.Spec.Config = loc.Config
+			bsl.Spec.Credential = credential
+			bsl.Spec.Default = loc.Default
+			if bsl.Spec.ObjectStorage == nil {
+				bsl.Spec.ObjectStorage = new(velerov1.ObjectStorageLocation)
+			}
+			bsl.Spec.ObjectStorage.Bucket = loc.Bucket
+			bsl.Spec.ObjectStorage.Prefix = loc.Prefix
+			return controllerutil.SetControllerReference(mgmt, bsl, r.Scheme)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to reconcile velero BackupStorageLocation %s: %w", client.ObjectKeyFromObject(bsl), err)
+		}
+		if operation != controllerutil.OperationResultNone {
+			l.Info("Reconciled velero BackupStorageLocation", "velero_bsl", client.ObjectKeyFromObject(bsl), "operation", operation)
+		}
+
+		switch bsl.Status.Phase {
+		case velerov1.BackupStorageLocationPhaseAvailable:
+			// nothing to report
+		case velerov1.BackupStorageLocationPhaseUnavailable:
+			problems = append(problems, fmt.Sprintf("BackupStorageLocation %s is unavailable: %s", loc.Name, bsl.Status.Message))
+			unavailable = true
+		default:
+			problems = append(problems, fmt.Sprintf("BackupStorageLocation %s has not been validated yet", loc.Name))
+		}
+	}
+
+	for _, loc := range mgmt.Spec.Backup.SnapshotLocations {
+		credential, err := r.getLocationCredential(ctx, loc.BackupLocationCredential)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("VolumeSnapshotLocation %s: %s", loc.Name, err))
+			unavailable = true
+			continue
+		}
+
+		vsl := &velerov1.VolumeSnapshotLocation{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      loc.Name,
+				Namespace: r.SystemNamespace,
+			},
+		}
+		operation, err := ctrl.CreateOrUpdate(ctx, r.Client, vsl, func() error {
+			setManagedLabel(vsl)
+			vsl.Spec.Provider = loc.Provider
+			vsl.Spec.Config = loc.Config
+			vsl.Spec.Credential = credential
+			return controllerutil.SetControllerReference(mgmt, vsl, r.Scheme)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to reconcile velero VolumeSnapshotLocation %s: %w", client.ObjectKeyFromObject(vsl), err)
+		}
+		if operation != controllerutil.OperationResultNone {
+			l.Info("Reconciled velero VolumeSnapshotLocation", "velero_vsl", client.ObjectKeyFromObject(vsl), "operation", operation)
+		}
+	}
+
+	if err := r.removeStaleStorageLocations(ctx, mgmt); err != nil {
+		return err
+	}
+
+	if len(mgmt.Spec.Backup.StorageLocations) == 0 {
+		apimeta.RemoveStatusCondition(mgmt.GetConditions(), hmc.BackupStorageAvailableCondition)
+		return nil
+	}
+
+	condition := metav1.Condition{
+		Type:               hmc.BackupStorageAvailableCondition,
+		Status:             metav1.ConditionTrue,
+		Reason:             hmc.SucceededReason,
+		Message:            "All of the backup storage locations are available",
+		ObservedGeneration: mgmt.Generation,
+	}
+	if len(problems) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = hmc.ProgressingReason
+		condition.Message = strings.Join(problems, "; ")
+		if unavailable {
+			condition.Reason = hmc.FailedReason
+		}
+	}
+	apimeta.SetStatusCondition(mgmt.GetConditions(), condition)
+
+	return nil
+}
+
+// getLocationCredential returns the reference to the Secret key holding the credentials
+// of a Velero location, nil is returned if the location does not reference any Credential.
+func (r *ManagementReconciler) getLocationCredential(ctx context.Context, locCred hmc.BackupLocationCredential) (*corev1.SecretKeySelector, error) {
+	if locCred.Credential == "" {
+		return nil, nil
+	}
+
+	cred := new(hmc.Credential)
+	if err := r.Get(ctx, client.ObjectKey{Namespace: r.SystemNamespace, Name: locCred.Credential}, cred); err != nil {
+		return nil, fmt.Errorf("failed to get Credential %s: %w", locCred.Credential, err)
+	}
+
+	ref := cred.Spec.IdentityRef
+	if ref == nil || ref.Kind != "Secret" {
+		return nil, fmt.Errorf("identity of the Credential %s is not a Secret", cred.Name)
+	}
+	if ref.Namespace != "" && ref.Namespace != r.SystemNamespace {
+		return nil, fmt.Errorf("identity Secret of the Credential %s is not in the %s namespace", cred.Name, r.SystemNamespace)
+	}
+
+	key := locCred.CredentialKey
+	if key == "" {
+		key = "cloud"
+	}
+
+	return &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: ref.Name},
+		Key:                  key,
+	}, nil
+}
+
+// removeStaleStorageLocations removes the Velero locations created for the Management
+// which are not defined in the Management anymore.
+func (r *ManagementReconciler) removeStaleStorageLocations(ctx context.Context, mgmt *hmc.Management) error {
+	l := ctrl.LoggerFrom(ctx)
+
+	var (
+		listOpts = []client.ListOption{
+			client.InNamespace(r.SystemNamespace),
+			client.MatchingLabels{hmc.HMCManagedLabelKey: hmc.HMCManagedLabelValue},
+		}
+		stale []client.Object
+	)
+
+	bsls := new(velerov1.BackupStorageLocationList)
+	if err := r.List(ctx, bsls, listOpts...); err != nil {
+		return fmt.Errorf("failed to list velero BackupStorageLocations: %w", err)
+	}
+	for _, bsl := range bsls.Items {
+		if !slices.ContainsFunc(mgmt.Spec.Backup.StorageLocations, func(loc hmc.BackupStorageLocation) bool { return loc.Name == bsl.Name }) {
+			stale = append(stale, &bsl)
+		}
+	}
+
+	vsls := new(velerov1.VolumeSnapshotLocationList)
+	if err := r.List(ctx, vsls, listOpts...); err != nil {
+		return fmt.Errorf("failed to list velero VolumeSnapshotLocations: %w", err)
+	}
+	for _, vsl := range vsls.Items {
+		if !slices.ContainsFunc(mgmt.Spec.Backup.SnapshotLocations, func(loc hmc.VolumeSnapshotLocation) bool { return loc.Name == vsl.Name }) {
+			stale = append(stale, &vsl)
+		}
+	}
+
+	for _, obj := range stale {
+		if !metav1.IsControlledBy(obj, mgmt) {
+			continue
+		}
+
+		if err := r.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete stale velero location %s: %w", client.ObjectKeyFromObject(obj), err)
+		}
+		l.Info("Removed stale velero location", "location", client.ObjectKeyFromObject(obj))
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
+	"github.com/K0rdent/kcm/internal/utils"
+)
+
+var _ = Describe("Backup storage locations", func() {
+	const (
+		storageLocationName = "test-storage-location"
+		storageCredential   = "test-storage-credential"
+	)
+
+	ctx := context.Background()
+
+	management := &hmc.Management{}
+
+	BeforeEach(func() {
+		By("creating the system namespace")
+		Expect(client.IgnoreAlreadyExists(k8sClient.Create(ctx, &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: utils.DefaultSystemNamespace,
+			},
+		}))).To(Succeed())
+
+		By("creating the Credential referencing the storage credentials")
+		credential := &hmc.Credential{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      storageCredential,
+				Namespace: utils.DefaultSystemNamespace,
+			},
+			Spec: hmc.CredentialSpec{
+				IdentityRef: &corev1.ObjectReference{
+					APIVersion: "v1",
+					Kind:       "Secret",
+					Name:       storageCredential,
+					Namespace:  utils.DefaultSystemNamespace,
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, credential)).To(Succeed())
+		DeferCleanup(k8sClient.Delete, credential)
+
+		By("creating the Management with the storage location")
+		management = &hmc.Management{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: hmc.ManagementName,
+			},
+			Spec: hmc.ManagementSpec{
+				Release: "test-release",
+				Backup: hmc.ManagementBackup{
+					Enabled: true,
+					StorageLocations: []hmc.BackupStorageLocation{{
+						Name:     storageLocationName,
+						Provider: "aws",
+						Bucket:   "velero",
+						Config:   map[string]string{"s3Url": "http://minio:9000"},
+						BackupLocationCredential: hmc.BackupLocationCredential{
+							Credential:    storageCredential,
+							CredentialKey: "cloud",
+						},
+						Default: true,
+					}},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, management)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		By("Cleanup the velero locations and the Management")
+		Expect(k8sClient.DeleteAllOf(ctx, &velerov1.BackupStorageLocation{}, client.InNamespace(utils.DefaultSystemNamespace))).To(Succeed())
+		Expect(k8sClient.Delete(ctx, management)).To(Succeed())
+	})
+
+	It("should reconcile the velero BackupStorageLocations defined in the Management", func() {
+		reconciler := &ManagementReconciler{
+			Client:          k8sClient,
+			Scheme:          k8sClient.Scheme(),
+			SystemNamespace: utils.DefaultSystemNamespace,
+		}
+
+		Expect(reconciler.reconcileStorageLocations(ctx, management)).To(Succeed())
+
+		By("Checking the velero BackupStorageLocation has been created")
+		bsl := &velerov1.BackupStorageLocation{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: storageLocationName, Namespace: utils.DefaultSystemNamespace}, bsl)).To(Succeed())
+		Expect(bsl.Spec.Provider).To(Equal("aws"))
+		Expect(bsl.Spec.ObjectStorage).NotTo(BeNil())
+		Expect(bsl.Spec.ObjectStorage.Bucket).To(Equal("velero"))
+		Expect(bsl.Spec.Default).To(BeTrue())
+		Expect(bsl.Spec.Credential).NotTo(BeNil())
+		Expect(bsl.Spec.Credential.Name).To(Equal(storageCredential))
+		Expect(bsl.Spec.Credential.Key).To(Equal("cloud"))
+		Expect(metav1.IsControlledBy(bsl, management)).To(BeTrue())
+
+		cond := apimeta.FindStatusCondition(management.Status.Conditions, hmc.BackupStorageAvailableCondition)
+		Expect(cond).NotTo(BeNil())
+		Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+		Expect(cond.Reason).To(Equal(hmc.ProgressingReason))
+
+		By("Reporting the unreachable storage in the Management")
+		bsl.Status.Phase = velerov1.BackupStorageLocationPhaseUnavailable
+		bsl.Status.Message = "bucket not found"
+		Expect(k8sClient.Update(ctx, bsl)).To(Succeed())
+
+		Expect(reconciler.reconcileStorageLocations(ctx, management)).To(Succeed())
+
+		cond = apimeta.FindStatusCondition(management.Status.Conditions, hmc.BackupStorageAvailableCondition)
+		Expect(cond).NotTo(BeNil())
+		Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+		Expect(cond.Reason).To(Equal(hmc.FailedReason))
+		Expect(cond.Message).To(ContainSubstring("bucket not found"))
+
+		By("Reporting the available storage in the Management")
+		bsl.Status.Phase = velerov1.BackupStorageLocationPhaseAvailable
+		Expect(k8sClient.Update(ctx, bsl)).To(Succeed())
+
+		Expect(reconciler.reconcileStorageLocations(ctx, management)).To(Succeed())
+		Expect(apimeta.IsStatusConditionTrue(management.Status.Conditions, hmc.BackupStorageAvailableCondition)).To(BeTrue())
+
+		By("Removing the storage location no longer defined in the Management")
+		management.Spec.Backup.StorageLocations = nil
+		Expect(reconciler.reconcileStorageLocations(ctx, management)).To(Succeed())
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(bsl), bsl)).NotTo(Succeed())
+		Expect(apimeta.FindStatusCondition(management.Status.Conditions, hmc.BackupStorageAvailableCondition)).To(BeNil())
+	})
+})
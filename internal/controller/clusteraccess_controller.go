@@ -0,0 +1,373 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
+)
+
+const (
+	// clusterAccessLabel marks the objects created in the workload cluster for the ClusterAccess with the given name.
+	clusterAccessLabel = "hmc.mirantis.com/cluster-access"
+	// clusterAccessSecretSuffix is the suffix of the Secret holding the issued kubeconfig,
+	// it differs from the one of the admin kubeconfig of the cluster so that their names do not collide.
+	clusterAccessSecretSuffix = "-access-kubeconfig"
+)
+
+// ClusterAccessReconciler reconciles a ClusterAccess object
+type ClusterAccessReconciler struct {
+	client.Client
+}
+
+func (r *ClusterAccessReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, err error) {
+	l := ctrl.LoggerFrom(ctx)
+	l.Info("Reconciling ClusterAccess")
+
+	access := new(hmc.ClusterAccess)
+	if err := r.Get(ctx, req.NamespacedName, access); err != nil {
+		if apierrors.IsNotFound(err) {
+			l.Info("ClusterAccess not found, ignoring since object must be deleted")
+			return ctrl.Result{}, nil
+		}
+
+		l.Error(err, "Failed to get ClusterAccess")
+		return ctrl.Result{}, err
+	}
+
+	if !access.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.finalize(ctx, access)
+	}
+
+	if controllerutil.AddFinalizer(access, hmc.ClusterAccessFinalizer) {
+		if err := r.Update(ctx, access); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to update ClusterAccess %s: %w", client.ObjectKeyFromObject(access), err)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	access.Status.ObservedGeneration = access.Generation
+	defer func() {
+		err = errors.Join(err, r.updateStatus(ctx, access))
+	}()
+
+	if expiration := access.Status.ExpirationTime; expiration != nil {
+		if remaining := time.Until(expiration.Time); remaining > 0 {
+			return ctrl.Result{RequeueAfter: remaining}, nil
+		}
+
+		if cond := apimeta.FindStatusCondition(access.Status.Conditions, hmc.ReadyCondition); cond != nil && cond.Reason == hmc.ExpiredReason {
+			return ctrl.Result{}, nil
+		}
+
+		if err := r.revoke(ctx, access); err != nil {
+			r.setReadyCondition(access, metav1.ConditionFalse, hmc.FailedReason, err.Error())
+			return ctrl.Result{}, err
+		}
+
+		access.Status.KubeconfigSecret = ""
+		r.setReadyCondition(access, metav1.ConditionFalse, hmc.ExpiredReason, "Access has expired and has been revoked")
+		l.Info("Access to the cluster has expired and has been revoked")
+		return ctrl.Result{}, nil
+	}
+
+	allowed, err := r.clusterRoleAllowed(ctx, access.Spec.ClusterRole)
+	if err != nil {
+		r.setReadyCondition(access, metav1.ConditionFalse, hmc.FailedReason, err.Error())
+		return ctrl.Result{}, err
+	}
+	if !allowed {
+		r.setReadyCondition(access, metav1.ConditionFalse, hmc.FailedReason,
+			fmt.Sprintf("ClusterRole %s is not allowed to be granted by ClusterAccess", access.Spec.ClusterRole))
+		return ctrl.Result{}, nil
+	}
+
+	clusterClient, kubeconfig, found, err := getClusterClient(ctx, r.Client, access.Namespace, access.Spec.ClusterDeployment)
+	if err != nil {
+		r.setReadyCondition(access, metav1.ConditionFalse, hmc.FailedReason, err.Error())
+		return ctrl.Result{}, err
+	}
+	if !found {
+		r.setReadyCondition(access, metav1.ConditionFalse, hmc.ProgressingReason,
+			fmt.Sprintf("Waiting for the kubeconfig of ClusterDeployment %s to be created", access.Spec.ClusterDeployment))
+		return ctrl.Result{RequeueAfter: DefaultRequeueInterval}, nil
+	}
+
+	if err := r.issue(ctx, clusterClient, kubeconfig, access); err != nil {
+		r.setReadyCondition(access, metav1.ConditionFalse, hmc.FailedReason, err.Error())
+		return ctrl.Result{}, err
+	}
+
+	r.setReadyCondition(access, metav1.ConditionTrue, hmc.SucceededReason, "Kubeconfig has been issued")
+	l.Info("Issued kubeconfig of the cluster", "secret", access.Status.KubeconfigSecret, "expiration", access.Status.ExpirationTime)
+	return ctrl.Result{RequeueAfter: time.Until(access.Status.ExpirationTime.Time)}, nil
+}
+
+// clusterRoleAllowed reports whether the AccessManagement allows the ClusterAccesses to grant the given ClusterRole.
+func (r *ClusterAccessReconciler) clusterRoleAllowed(ctx context.Context, clusterRole string) (bool, error) {
+	accessManagement := new(hmc.AccessManagement)
+	if err := r.Get(ctx, client.ObjectKey{Name: hmc.AccessManagementName}, accessManagement); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return false, fmt.Errorf("failed to get AccessManagement: %w", err)
+		}
+		accessManagement = nil
+	}
+
+	return accessManagement.ClusterAccessRoleAllowed(clusterRole), nil
+}
+
+// getClusterClient returns the client of the workload cluster of the ClusterDeployment with the given name
+// along with its admin kubeconfig, found is false if the kubeconfig does not exist yet.
+func getClusterClient(ctx context.Context, cl client.Client, namespace, clusterDeploymentName string) (_ client.Client, _ *clientcmdapi.Config, found bool, _ error) {
+	secret := new(corev1.Secret)
+	key := client.ObjectKey{Namespace: namespace, Name: clusterDeploymentName + "-kubeconfig"}
+	if err := cl.Get(ctx, key, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil, false, nil
+		}
+
+		return nil, nil, false, fmt.Errorf("failed to get kubeconfig secret %s: %w", key, err)
+	}
+
+	kubeconfig, err := clientcmd.Load(secret.Data["value"])
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("failed to load kubeconfig from secret %s: %w", key, err)
+	}
+
+	restConfig, err := clientcmd.NewDefaultClientConfig(*kubeconfig, nil).ClientConfig()
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("failed to get REST config from secret %s: %w", key, err)
+	}
+
+	clusterClient, err := client.New(restConfig, client.Options{})
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("failed to create client of ClusterDeployment %s: %w", clusterDeploymentName, err)
+	}
+
+	return clusterClient, kubeconfig, true, nil
+}
+
+// issue creates the ServiceAccount of the given ClusterAccess in the workload cluster, binds it to the requested
+// ClusterRole and stores the kubeconfig authenticated with a token of the ServiceAccount in the Secret owned by the ClusterAccess.
+func (r *ClusterAccessReconciler) issue(ctx context.Context, clusterClient client.Client, adminKubeconfig *clientcmdapi.Config, access *hmc.ClusterAccess) error {
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: hmc.ClusterAccessNamespace}}
+	if err := clusterClient.Create(ctx, namespace); client.IgnoreAlreadyExists(err) != nil {
+		return fmt.Errorf("failed to create namespace %s in the cluster: %w", namespace.Name, err)
+	}
+
+	sa := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      access.Name,
+			Namespace: hmc.ClusterAccessNamespace,
+			Labels:    map[string]string{clusterAccessLabel: access.Name},
+		},
+	}
+	if err := clusterClient.Create(ctx, sa); client.IgnoreAlreadyExists(err) != nil {
+		return fmt.Errorf("failed to create ServiceAccount %s in the cluster: %w", client.ObjectKeyFromObject(sa), err)
+	}
+
+	for _, binding := range clusterAccessBindings(access) {
+		if err := clusterClient.Create(ctx, binding); client.IgnoreAlreadyExists(err) != nil {
+			return fmt.Errorf("failed to bind ClusterRole %s in the cluster: %w", access.Spec.ClusterRole, err)
+		}
+	}
+
+	tokenRequest := &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{
+			ExpirationSeconds: ptr.To(int64(access.Spec.TTL.Seconds())),
+		},
+	}
+	if err := clusterClient.SubResource("token").Create(ctx, sa, tokenRequest); err != nil {
+		return fmt.Errorf("failed to request token of ServiceAccount %s in the cluster: %w", client.ObjectKeyFromObject(sa), err)
+	}
+
+	kubeconfig, err := accessKubeconfig(adminKubeconfig, access, tokenRequest.Status.Token)
+	if err != nil {
+		return err
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      access.Name + clusterAccessSecretSuffix,
+			Namespace: access.Namespace,
+		},
+	}
+	_, err = ctrl.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		if !secret.CreationTimestamp.IsZero() && !metav1.IsControlledBy(secret, access) {
+			return fmt.Errorf("secret %s already exists and is not managed by the ClusterAccess", client.ObjectKeyFromObject(secret))
+		}
+		secret.Data = map[string][]byte{"value": kubeconfig}
+		return controllerutil.SetControllerReference(access, secret, r.Scheme())
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reconcile kubeconfig secret %s: %w", client.ObjectKeyFromObject(secret), err)
+	}
+
+	access.Status.KubeconfigSecret = secret.Name
+	access.Status.ExpirationTime = &tokenRequest.Status.ExpirationTimestamp
+	return nil
+}
+
+// clusterAccessBindings returns the bindings of the requested ClusterRole to the ServiceAccount of the given ClusterAccess,
+// either the RoleBindings in each of the requested namespaces or the ClusterRoleBinding.
+func clusterAccessBindings(access *hmc.ClusterAccess) []client.Object {
+	name := hmc.ClusterAccessNamespace + "-" + access.Name
+	labels := map[string]string{clusterAccessLabel: access.Name}
+	roleRef := rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: access.Spec.ClusterRole}
+	subjects := []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: access.Name, Namespace: hmc.ClusterAccessNamespace}}
+
+	if len(access.Spec.Namespaces) == 0 {
+		return []client.Object{&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+			RoleRef:    roleRef,
+			Subjects:   subjects,
+		}}
+	}
+
+	bindings := make([]client.Object, 0, len(access.Spec.Namespaces))
+	for _, namespace := range access.Spec.Namespaces {
+		bindings = append(bindings, &rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+			RoleRef:    roleRef,
+			Subjects:   subjects,
+		})
+	}
+
+	return bindings
+}
+
+// accessKubeconfig returns the kubeconfig pointing to the cluster of the given admin kubeconfig
+// and authenticated with the given token.
+func accessKubeconfig(adminKubeconfig *clientcmdapi.Config, access *hmc.ClusterAccess, token string) ([]byte, error) {
+	adminContext, ok := adminKubeconfig.Contexts[adminKubeconfig.CurrentContext]
+	if !ok {
+		return nil, fmt.Errorf("admin kubeconfig of ClusterDeployment %s has no current context", access.Spec.ClusterDeployment)
+	}
+	cluster, ok := adminKubeconfig.Clusters[adminContext.Cluster]
+	if !ok {
+		return nil, fmt.Errorf("admin kubeconfig of ClusterDeployment %s has no cluster %s", access.Spec.ClusterDeployment, adminContext.Cluster)
+	}
+
+	name := access.Spec.ClusterDeployment
+	user := access.Name + "@" + access.Spec.ClusterDeployment
+
+	kubeconfig := clientcmdapi.NewConfig()
+	kubeconfig.Clusters[name] = cluster
+	kubeconfig.AuthInfos[user] = &clientcmdapi.AuthInfo{Token: token}
+	kubeconfig.Contexts[user] = &clientcmdapi.Context{Cluster: name, AuthInfo: user}
+	kubeconfig.CurrentContext = user
+
+	data, err := clientcmd.Write(*kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write kubeconfig: %w", err)
+	}
+
+	return data, nil
+}
+
+// revoke deletes the ServiceAccount and the bindings of the given ClusterAccess in the workload cluster
+// invalidating the issued tokens, and deletes the kubeconfig Secret.
+// Nothing is done in the workload cluster if its kubeconfig no longer exists.
+func (r *ClusterAccessReconciler) revoke(ctx context.Context, access *hmc.ClusterAccess) error {
+	clusterClient, _, found, err := getClusterClient(ctx, r.Client, access.Namespace, access.Spec.ClusterDeployment)
+	if err != nil {
+		return err
+	}
+
+	if found {
+		objects := append(clusterAccessBindings(access), &corev1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{Name: access.Name, Namespace: hmc.ClusterAccessNamespace},
+		})
+		for _, obj := range objects {
+			if err := clusterClient.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("failed to revoke access in the cluster: %w", err)
+			}
+		}
+	}
+
+	if access.Status.KubeconfigSecret == "" {
+		return nil
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      access.Status.KubeconfigSecret,
+			Namespace: access.Namespace,
+		},
+	}
+	if err := r.Delete(ctx, secret); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to delete kubeconfig secret %s: %w", client.ObjectKeyFromObject(secret), err)
+	}
+
+	return nil
+}
+
+// finalize revokes the access issued by the given ClusterAccess and removes its finalizer.
+func (r *ClusterAccessReconciler) finalize(ctx context.Context, access *hmc.ClusterAccess) error {
+	if err := r.revoke(ctx, access); err != nil {
+		return err
+	}
+
+	if controllerutil.RemoveFinalizer(access, hmc.ClusterAccessFinalizer) {
+		if err := r.Update(ctx, access); err != nil {
+			return fmt.Errorf("failed to remove finalizer from ClusterAccess %s: %w", client.ObjectKeyFromObject(access), err)
+		}
+	}
+
+	ctrl.LoggerFrom(ctx).Info("Access to the cluster has been revoked")
+	return nil
+}
+
+func (*ClusterAccessReconciler) setReadyCondition(access *hmc.ClusterAccess, status metav1.ConditionStatus, reason, msg string) {
+	apimeta.SetStatusCondition(access.GetConditions(), metav1.Condition{
+		Type:               hmc.ReadyCondition,
+		Status:             status,
+		Reason:             reason,
+		Message:            msg,
+		ObservedGeneration: access.Generation,
+	})
+}
+
+func (r *ClusterAccessReconciler) updateStatus(ctx context.Context, access *hmc.ClusterAccess) error {
+	if err := r.Status().Update(ctx, access); err != nil {
+		return fmt.Errorf("failed to update status for ClusterAccess %s: %w", client.ObjectKeyFromObject(access), err)
+	}
+
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ClusterAccessReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&hmc.ClusterAccess{}).
+		Complete(r)
+}
@@ -0,0 +1,190 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
+)
+
+var _ = Describe("ClusterAccess Controller", func() {
+	const (
+		accessName            = "test-access"
+		clusterDeploymentName = "test-access-cluster"
+	)
+
+	ctx := context.Background()
+
+	access := &hmc.ClusterAccess{}
+	kubeconfigSecret := &corev1.Secret{}
+
+	BeforeEach(func() {
+		By("creating the kubeconfig secret of the cluster pointing to the test environment")
+		kubeconfig := clientcmdapi.NewConfig()
+		kubeconfig.Clusters["test"] = &clientcmdapi.Cluster{Server: cfg.Host, CertificateAuthorityData: cfg.CAData}
+		kubeconfig.AuthInfos["test"] = &clientcmdapi.AuthInfo{ClientCertificateData: cfg.CertData, ClientKeyData: cfg.KeyData}
+		kubeconfig.Contexts["test"] = &clientcmdapi.Context{Cluster: "test", AuthInfo: "test"}
+		kubeconfig.CurrentContext = "test"
+		data, err := clientcmd.Write(*kubeconfig)
+		Expect(err).NotTo(HaveOccurred())
+
+		kubeconfigSecret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      clusterDeploymentName + "-kubeconfig",
+				Namespace: metav1.NamespaceDefault,
+			},
+			Data: map[string][]byte{"value": data},
+		}
+		Expect(k8sClient.Create(ctx, kubeconfigSecret)).To(Succeed())
+
+		By("creating the ClusterAccess")
+		access = &hmc.ClusterAccess{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      accessName,
+				Namespace: metav1.NamespaceDefault,
+			},
+			Spec: hmc.ClusterAccessSpec{
+				ClusterDeployment: clusterDeploymentName,
+				ClusterRole:       "view",
+				Namespaces:        []string{metav1.NamespaceDefault},
+				TTL:               metav1.Duration{Duration: time.Hour},
+			},
+		}
+		Expect(k8sClient.Create(ctx, access)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		By("Cleanup the ClusterAccess")
+		Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, access))).To(Succeed())
+		_, err := (&ClusterAccessReconciler{Client: k8sClient}).Reconcile(ctx, reconcile.Request{
+			NamespacedName: client.ObjectKeyFromObject(access),
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		By("Cleanup the kubeconfig secret")
+		Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, kubeconfigSecret))).To(Succeed())
+	})
+
+	It("should issue the kubeconfig and revoke it on expiry", func() {
+		controllerReconciler := &ClusterAccessReconciler{Client: k8sClient}
+
+		By("Reconciling the created resource")
+		for range 2 {
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: client.ObjectKeyFromObject(access),
+			})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(access), access)).To(Succeed())
+		Expect(apimeta.IsStatusConditionTrue(access.Status.Conditions, hmc.ReadyCondition)).To(BeTrue())
+		Expect(access.Status.KubeconfigSecret).To(Equal(accessName + "-access-kubeconfig"))
+		Expect(access.Status.ExpirationTime.Time).To(BeTemporally("~", time.Now().Add(time.Hour), time.Minute))
+
+		By("Checking the issued kubeconfig")
+		issuedSecret := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: access.Status.KubeconfigSecret, Namespace: metav1.NamespaceDefault}, issuedSecret)).To(Succeed())
+		Expect(metav1.IsControlledBy(issuedSecret, access)).To(BeTrue())
+
+		issued, err := clientcmd.Load(issuedSecret.Data["value"])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(issued.Clusters).To(HaveKeyWithValue(clusterDeploymentName, HaveField("Server", cfg.Host)))
+		Expect(issued.AuthInfos[issued.Contexts[issued.CurrentContext].AuthInfo].Token).NotTo(BeEmpty())
+
+		By("Checking the access is limited to the requested namespaces")
+		sa := &corev1.ServiceAccount{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: accessName, Namespace: hmc.ClusterAccessNamespace}, sa)).To(Succeed())
+
+		binding := &rbacv1.RoleBinding{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: hmc.ClusterAccessNamespace + "-" + accessName, Namespace: metav1.NamespaceDefault}, binding)).To(Succeed())
+		Expect(binding.RoleRef.Name).To(Equal("view"))
+		Expect(binding.Subjects).To(ConsistOf(HaveField("Name", accessName)))
+
+		By("Expiring the access")
+		access.Status.ExpirationTime = &metav1.Time{Time: time.Now().Add(-time.Minute)}
+		Expect(k8sClient.Status().Update(ctx, access)).To(Succeed())
+
+		_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+			NamespacedName: client.ObjectKeyFromObject(access),
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(access), access)).To(Succeed())
+		cond := apimeta.FindStatusCondition(access.Status.Conditions, hmc.ReadyCondition)
+		Expect(cond).NotTo(BeNil())
+		Expect(cond.Reason).To(Equal(hmc.ExpiredReason))
+		Expect(access.Status.KubeconfigSecret).To(BeEmpty())
+
+		Expect(apierrors.IsNotFound(k8sClient.Get(ctx, client.ObjectKeyFromObject(issuedSecret), issuedSecret))).To(BeTrue())
+		Expect(apierrors.IsNotFound(k8sClient.Get(ctx, client.ObjectKeyFromObject(binding), binding))).To(BeTrue())
+		Expect(apierrors.IsNotFound(k8sClient.Get(ctx, client.ObjectKeyFromObject(sa), sa))).To(BeTrue())
+	})
+
+	It("should not grant the ClusterRole not allowed by the AccessManagement", func() {
+		controllerReconciler := &ClusterAccessReconciler{Client: k8sClient}
+
+		By("Creating the ClusterAccess requesting the cluster-admin ClusterRole")
+		adminAccess := &hmc.ClusterAccess{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      accessName + "-admin",
+				Namespace: metav1.NamespaceDefault,
+			},
+			Spec: hmc.ClusterAccessSpec{
+				ClusterDeployment: clusterDeploymentName,
+				ClusterRole:       "cluster-admin",
+				TTL:               metav1.Duration{Duration: time.Hour},
+			},
+		}
+		Expect(k8sClient.Create(ctx, adminAccess)).To(Succeed())
+		DeferCleanup(func() {
+			Expect(k8sClient.Delete(ctx, adminAccess)).To(Succeed())
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: client.ObjectKeyFromObject(adminAccess),
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		for range 2 {
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: client.ObjectKeyFromObject(adminAccess),
+			})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(adminAccess), adminAccess)).To(Succeed())
+		cond := apimeta.FindStatusCondition(adminAccess.Status.Conditions, hmc.ReadyCondition)
+		Expect(cond).NotTo(BeNil())
+		Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+		Expect(cond.Reason).To(Equal(hmc.FailedReason))
+		Expect(adminAccess.Status.KubeconfigSecret).To(BeEmpty())
+
+		binding := &rbacv1.ClusterRoleBinding{}
+		Expect(apierrors.IsNotFound(k8sClient.Get(ctx, client.ObjectKey{Name: hmc.ClusterAccessNamespace + "-" + adminAccess.Name}, binding))).To(BeTrue())
+	})
+})
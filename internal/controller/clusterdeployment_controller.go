@@ -35,6 +35,7 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
@@ -104,11 +105,18 @@ func (r *ClusterDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Re
 	return r.reconcileUpdate(ctx, clusterDeployment)
 }
 
-func (r *ClusterDeploymentReconciler) setStatusFromChildObjects(ctx context.Context, clusterDeployment *hmc.ClusterDeployment, gvr schema.GroupVersionResource, conditions []string) (requeue bool, _ error) {
+func (r *ClusterDeploymentReconciler) setStatusFromChildObjects(ctx context.Context, clusterDeployment *hmc.ClusterDeployment, healthCheck hmc.HealthCheck) (requeue bool, _ error) {
 	l := ctrl.LoggerFrom(ctx)
 
-	resourceConditions, err := status.GetResourceConditions(ctx, clusterDeployment.Namespace, r.DynamicClient, gvr,
-		labels.SelectorFromSet(map[string]string{hmc.FluxHelmChartNameKey: clusterDeployment.Name}).String())
+	gvr := schema.GroupVersionResource{Group: healthCheck.Group, Version: healthCheck.Version, Resource: healthCheck.Resource}
+	selector := labels.SelectorFromSet(map[string]string{hmc.FluxHelmChartNameKey: clusterDeployment.Name}).String()
+
+	getResourceConditions, conditions := status.GetResourceConditions, healthCheck.Conditions
+	if len(conditions) == 0 {
+		getResourceConditions = status.GetResourceReadyConditions
+	}
+
+	resourceConditions, err := getResourceConditions(ctx, clusterDeployment.Namespace, r.DynamicClient, gvr, selector)
 	if err != nil {
 		if errors.As(err, &status.ResourceNotFoundError{}) {
 			l.Info(err.Error())
@@ -118,6 +126,10 @@ func (r *ClusterDeploymentReconciler) setStatusFromChildObjects(ctx context.Cont
 		return false, fmt.Errorf("failed to get conditions: %w", err)
 	}
 
+	if len(conditions) == 0 {
+		conditions = []string{status.ReadyConditionType(resourceConditions.Kind)}
+	}
+
 	allConditionsComplete := true
 	for _, metaCondition := range resourceConditions.Conditions {
 		if slices.Contains(conditions, metaCondition.Type) {
@@ -146,6 +158,20 @@ func (r *ClusterDeploymentReconciler) reconcileUpdate(ctx context.Context, mc *h
 		return ctrl.Result{}, nil
 	}
 
+	if revision, ok := mc.Annotations[hmc.ClusterDeploymentRollbackAnnotation]; ok {
+		rolledBack, err := r.rollbackToRevision(ctx, mc, revision)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if rolledBack {
+			return ctrl.Result{Requeue: true}, nil
+		}
+	} else if cond := apimeta.FindStatusCondition(mc.Status.Conditions, hmc.RolledBackCondition); cond != nil &&
+		(cond.ObservedGeneration != mc.Generation || cond.Status == metav1.ConditionFalse) {
+		// the failed rollback is reported until its annotation is removed
+		apimeta.RemoveStatusCondition(mc.GetConditions(), hmc.RolledBackCondition)
+	}
+
 	if len(mc.Status.Conditions) == 0 {
 		mc.InitConditions()
 	}
@@ -171,8 +197,15 @@ func (r *ClusterDeploymentReconciler) reconcileUpdate(ctx context.Context, mc *h
 		return ctrl.Result{}, err
 	}
 
-	clusterRes, clusterErr := r.updateCluster(ctx, mc, clusterTpl)
-	servicesRes, servicesErr := r.updateServices(ctx, mc)
+	window, err := getMaintenanceWindow(ctx, r.Client, mc.Spec.MaintenanceWindow)
+	if err != nil {
+		setMaintenanceWindowFailed(mc.GetConditions(), err)
+		return ctrl.Result{}, err
+	}
+	apimeta.RemoveStatusCondition(mc.GetConditions(), hmc.PendingMaintenanceCondition)
+
+	clusterRes, clusterErr := r.updateCluster(ctx, mc, clusterTpl, window)
+	servicesRes, servicesErr := r.updateServices(ctx, mc, window)
 
 	if err = errors.Join(clusterErr, servicesErr); err != nil {
 		return ctrl.Result{}, err
@@ -187,7 +220,7 @@ func (r *ClusterDeploymentReconciler) reconcileUpdate(ctx context.Context, mc *h
 	return ctrl.Result{}, nil
 }
 
-func (r *ClusterDeploymentReconciler) updateCluster(ctx context.Context, mc *hmc.ClusterDeployment, clusterTpl *hmc.ClusterTemplate) (ctrl.Result, error) {
+func (r *ClusterDeploymentReconciler) updateCluster(ctx context.Context, mc *hmc.ClusterDeployment, clusterTpl *hmc.ClusterTemplate, window *maintenanceWindow) (ctrl.Result, error) {
 	l := ctrl.LoggerFrom(ctx)
 
 	if clusterTpl == nil {
@@ -244,6 +277,28 @@ func (r *ClusterDeploymentReconciler) updateCluster(ctx context.Context, mc *hmc
 		return ctrl.Result{}, err
 	}
 
+	if mc.Spec.Adopt && !apimeta.IsStatusConditionTrue(mc.Status.Conditions, hmc.ClusterAdoptedCondition) {
+		updated, err := r.setAdoptedClusterValues(ctx, mc)
+		if err != nil {
+			apimeta.SetStatusCondition(mc.GetConditions(), metav1.Condition{
+				Type:    hmc.ClusterAdoptedCondition,
+				Status:  metav1.ConditionFalse,
+				Reason:  hmc.FailedReason,
+				Message: err.Error(),
+			})
+			return ctrl.Result{}, err
+		}
+		if updated {
+			apimeta.SetStatusCondition(mc.GetConditions(), metav1.Condition{
+				Type:    hmc.ClusterAdoptedCondition,
+				Status:  metav1.ConditionFalse,
+				Reason:  hmc.ProgressingReason,
+				Message: "Computed the template values from the existing cluster",
+			})
+			return ctrl.Result{Requeue: true}, nil
+		}
+	}
+
 	l.Info("Validating Helm chart with provided values")
 	if err := validateReleaseWithValues(ctx, actionConfig, mc, hcChart); err != nil {
 		apimeta.SetStatusCondition(mc.GetConditions(), metav1.Condition{
@@ -293,15 +348,29 @@ func (r *ClusterDeploymentReconciler) updateCluster(ctx context.Context, mc *hmc
 		Message: "Credential is Ready",
 	})
 
-	if mc.Spec.DryRun {
-		return ctrl.Result{}, nil
-	}
-
 	helmValues, err := setIdentityHelmValues(mc.Spec.Config, cred.Spec.IdentityRef)
 	if err != nil {
 		return ctrl.Result{}, fmt.Errorf("error setting identity values: %w", err)
 	}
 
+	helmValues, err = setWorkerPoolsHelmValues(helmValues, mc.Spec.WorkerPools, clusterTpl.Status.WorkerPools)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("error setting worker pools values: %w", err)
+	}
+
+	helmValues, err = setAutoscalingHelmValues(helmValues, mc.Spec.Autoscaling)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("error setting autoscaling values: %w", err)
+	}
+
+	if mc.Spec.DryRun {
+		return ctrl.Result{}, r.reconcileDryRunResult(ctx, actionConfig, mc, hcChart, helmValues)
+	}
+
+	if err := r.deleteDryRunResult(ctx, mc); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	hrReconcileOpts := helm.ReconcileHelmReleaseOpts{
 		Values: helmValues,
 		OwnerReference: &metav1.OwnerReference{
@@ -311,22 +380,59 @@ func (r *ClusterDeploymentReconciler) updateCluster(ctx context.Context, mc *hmc
 			UID:        mc.UID,
 		},
 		ChartRef: clusterTpl.Status.ChartRef,
+		Suspend:  mc.Spec.Paused,
 	}
 	if clusterTpl.Spec.Helm.ChartSpec != nil {
 		hrReconcileOpts.ReconcileInterval = &clusterTpl.Spec.Helm.ChartSpec.Interval.Duration
 	}
 
-	hr, _, err := helm.ReconcileHelmRelease(ctx, r.Client, mc.Name, mc.Namespace, hrReconcileOpts)
+	hr, postponed, err := r.getPostponedHelmRelease(ctx, mc, window, hrReconcileOpts)
 	if err != nil {
-		apimeta.SetStatusCondition(mc.GetConditions(), metav1.Condition{
-			Type:    hmc.HelmReleaseReadyCondition,
-			Status:  metav1.ConditionFalse,
-			Reason:  hmc.FailedReason,
-			Message: err.Error(),
-		})
 		return ctrl.Result{}, err
 	}
 
+	var result ctrl.Result
+	if postponed {
+		result = window.postpone(mc.GetConditions(), "Template and config changes")
+	} else {
+		startUpgrade(mc)
+		recordRevision(mc, clusterTpl.Status.ChartVersion)
+
+		adopting := mc.Spec.Adopt && !apimeta.IsStatusConditionTrue(mc.Status.Conditions, hmc.ClusterAdoptedCondition)
+		if adopting {
+			if err := r.adoptClusterObjects(ctx, actionConfig, mc, hcChart, helmValues); err != nil {
+				apimeta.SetStatusCondition(mc.GetConditions(), metav1.Condition{
+					Type:    hmc.ClusterAdoptedCondition,
+					Status:  metav1.ConditionFalse,
+					Reason:  hmc.FailedReason,
+					Message: err.Error(),
+				})
+				return ctrl.Result{}, err
+			}
+		}
+
+		hr, _, err = helm.ReconcileHelmRelease(ctx, r.Client, mc.Name, mc.Namespace, hrReconcileOpts)
+		if err != nil {
+			apimeta.SetStatusCondition(mc.GetConditions(), metav1.Condition{
+				Type:    hmc.HelmReleaseReadyCondition,
+				Status:  metav1.ConditionFalse,
+				Reason:  hmc.FailedReason,
+				Message: err.Error(),
+			})
+			return ctrl.Result{}, err
+		}
+
+		// the cluster is adopted only once the HelmRelease taking over its objects exists
+		if adopting {
+			apimeta.SetStatusCondition(mc.GetConditions(), metav1.Condition{
+				Type:    hmc.ClusterAdoptedCondition,
+				Status:  metav1.ConditionTrue,
+				Reason:  hmc.SucceededReason,
+				Message: "CAPI Cluster has been adopted",
+			})
+		}
+	}
+
 	hrReadyCondition := fluxconditions.Get(hr, fluxmeta.ReadyCondition)
 	if hrReadyCondition != nil {
 		apimeta.SetStatusCondition(mc.GetConditions(), metav1.Condition{
@@ -337,7 +443,37 @@ func (r *ClusterDeploymentReconciler) updateCluster(ctx context.Context, mc *hmc
 		})
 	}
 
-	requeue, err := r.aggregateCapoConditions(ctx, mc)
+	if fluxconditions.IsStalled(hr) {
+		completeRevision(mc, hmc.RevisionOutcomeFailed, fluxconditions.GetMessage(hr, fluxmeta.StalledCondition))
+	}
+
+	if err := r.reconcileMachinesStatus(ctx, mc); err != nil {
+		// the inventory is informational only, hence do not block the reconciliation
+		l.Error(err, "failed to collect the machines of the cluster")
+	}
+
+	if err := r.reconcileClusterPause(ctx, mc); err != nil {
+		apimeta.SetStatusCondition(mc.GetConditions(), metav1.Condition{
+			Type:    hmc.PausedCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  hmc.FailedReason,
+			Message: err.Error(),
+		})
+		return ctrl.Result{}, err
+	}
+
+	if mc.Spec.Paused {
+		apimeta.SetStatusCondition(mc.GetConditions(), metav1.Condition{
+			Type:    hmc.PausedCondition,
+			Status:  metav1.ConditionTrue,
+			Reason:  hmc.SucceededReason,
+			Message: "ClusterDeployment is paused",
+		})
+		return ctrl.Result{}, nil
+	}
+	apimeta.RemoveStatusCondition(mc.GetConditions(), hmc.PausedCondition)
+
+	requeue, err := r.aggregateCapoConditions(ctx, mc, clusterTpl)
 	if err != nil {
 		if requeue {
 			return ctrl.Result{RequeueAfter: DefaultRequeueInterval}, err
@@ -346,6 +482,10 @@ func (r *ClusterDeploymentReconciler) updateCluster(ctx context.Context, mc *hmc
 		return ctrl.Result{}, err
 	}
 
+	if res, err := r.reconcileUpgrade(ctx, mc, hr, !requeue); err != nil || !res.IsZero() {
+		return res, err
+	}
+
 	if requeue {
 		return ctrl.Result{RequeueAfter: DefaultRequeueInterval}, nil
 	}
@@ -354,6 +494,27 @@ func (r *ClusterDeploymentReconciler) updateCluster(ctx context.Context, mc *hmc
 		return ctrl.Result{RequeueAfter: DefaultRequeueInterval}, nil
 	}
 
+	completeRevision(mc, hmc.RevisionOutcomeDeployed, "Cluster is ready")
+
+	if err := r.reconcileAutoscaling(ctx, mc); err != nil {
+		// the failure is reported in the condition, the rest of the cluster is reconciled regardless
+		l.Error(err, "failed to reconcile the autoscaling")
+		apimeta.SetStatusCondition(mc.GetConditions(), metav1.Condition{
+			Type:    hmc.AutoscalingReadyCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  hmc.FailedReason,
+			Message: err.Error(),
+		})
+		if result.RequeueAfter == 0 || result.RequeueAfter > DefaultRequeueInterval {
+			result.RequeueAfter = DefaultRequeueInterval
+		}
+	}
+
+	if err := r.reconcileDrift(ctx, mc, hr, window); err != nil {
+		// the drift is reported on the best effort basis, hence do not block the reconciliation
+		l.Error(err, "failed to detect the drift of the cluster objects")
+	}
+
 	if mc.Spec.PropagateCredentials {
 		if err := r.reconcileCredentialPropagation(ctx, mc, cred); err != nil {
 			l.Error(err, "failed to reconcile credentials propagation")
@@ -361,35 +522,30 @@ func (r *ClusterDeploymentReconciler) updateCluster(ctx context.Context, mc *hmc
 		}
 	}
 
-	return ctrl.Result{}, nil
+	if mc.Spec.DriftDetection != nil {
+		interval := mc.Spec.DriftDetection.Interval.Duration
+		if result.RequeueAfter == 0 || result.RequeueAfter > interval {
+			result.RequeueAfter = interval
+		}
+	}
+
+	return result, nil
 }
 
-func (r *ClusterDeploymentReconciler) aggregateCapoConditions(ctx context.Context, clusterDeployment *hmc.ClusterDeployment) (requeue bool, _ error) {
-	type objectToCheck struct {
-		gvr        schema.GroupVersionResource
-		conditions []string
+func (r *ClusterDeploymentReconciler) aggregateCapoConditions(ctx context.Context, clusterDeployment *hmc.ClusterDeployment, clusterTpl *hmc.ClusterTemplate) (requeue bool, _ error) {
+	healthChecks := clusterTpl.Status.HealthChecks
+	if len(healthChecks) == 0 { // the status has not been filled yet
+		healthChecks = hmc.DefaultHealthChecks()
+	}
+
+	infraHealthChecks, err := r.infrastructureHealthChecks(ctx, clusterTpl, healthChecks)
+	if err != nil {
+		return false, err
 	}
 
 	var errs error
-	for _, obj := range []objectToCheck{
-		{
-			gvr: schema.GroupVersionResource{
-				Group:    "cluster.x-k8s.io",
-				Version:  "v1beta1",
-				Resource: "clusters",
-			},
-			conditions: []string{"ControlPlaneInitialized", "ControlPlaneReady", "InfrastructureReady"},
-		},
-		{
-			gvr: schema.GroupVersionResource{
-				Group:    "cluster.x-k8s.io",
-				Version:  "v1beta1",
-				Resource: "machinedeployments",
-			},
-			conditions: []string{"Available"},
-		},
-	} {
-		needRequeue, err := r.setStatusFromChildObjects(ctx, clusterDeployment, obj.gvr, obj.conditions)
+	for _, healthCheck := range slices.Concat(healthChecks, infraHealthChecks) {
+		needRequeue, err := r.setStatusFromChildObjects(ctx, clusterDeployment, healthCheck)
 		errs = errors.Join(errs, err)
 		if needRequeue {
 			requeue = true
@@ -400,7 +556,7 @@ func (r *ClusterDeploymentReconciler) aggregateCapoConditions(ctx context.Contex
 }
 
 // updateServices reconciles services provided in ClusterDeployment.Spec.Services.
-func (r *ClusterDeploymentReconciler) updateServices(ctx context.Context, mc *hmc.ClusterDeployment) (_ ctrl.Result, err error) {
+func (r *ClusterDeploymentReconciler) updateServices(ctx context.Context, mc *hmc.ClusterDeployment, window *maintenanceWindow) (_ ctrl.Result, err error) {
 	l := ctrl.LoggerFrom(ctx)
 	l.Info("Reconciling Services")
 
@@ -442,7 +598,16 @@ func (r *ClusterDeploymentReconciler) updateServices(ctx context.Context, mc *hm
 		return ctrl.Result{}, err
 	}
 
-	if _, err = sveltos.ReconcileProfile(ctx, r.Client, mc.Namespace, mc.Name,
+	existingProfile := &sveltosv1beta1.Profile{ObjectMeta: metav1.ObjectMeta{Name: mc.Name, Namespace: mc.Namespace}}
+	postponed, err := servicesChangesPostponed(ctx, r.Client, window, existingProfile, &existingProfile.Spec, opts)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var result ctrl.Result
+	if postponed {
+		result = window.postpone(mc.GetConditions(), "Services changes")
+	} else if _, err = sveltos.ReconcileProfile(ctx, r.Client, mc.Namespace, mc.Name,
 		sveltos.ReconcileProfileOpts{
 			OwnerReference: &metav1.OwnerReference{
 				APIVersion: hmc.GroupVersion.String(),
@@ -459,6 +624,8 @@ func (r *ClusterDeploymentReconciler) updateServices(ctx context.Context, mc *hm
 			HelmChartOpts:  opts,
 			Priority:       mc.Spec.ServicesPriority,
 			StopOnConflict: mc.Spec.StopOnConflict,
+			Paused:         mc.Spec.Paused,
+			LeavePolicies:  mc.Spec.DeletionPolicy == hmc.DeletionPolicyOrphan,
 		}); err != nil {
 		return ctrl.Result{}, fmt.Errorf("failed to reconcile Profile: %w", err)
 	}
@@ -483,7 +650,7 @@ func (r *ClusterDeploymentReconciler) updateServices(ctx context.Context, mc *hm
 	mc.Status.Services = servicesStatus
 	l.Info("Successfully updated status of services")
 
-	return ctrl.Result{}, nil
+	return result, nil
 }
 
 func validateReleaseWithValues(ctx context.Context, actionConfig *action.Configuration, clusterDeployment *hmc.ClusterDeployment, hcChart *chart.Chart) error {
@@ -550,6 +717,17 @@ func (r *ClusterDeploymentReconciler) Delete(ctx context.Context, clusterDeploym
 		return ctrl.Result{}, nil
 	}
 
+	// the cluster-autoscaler must not scale the cluster being deleted or detached
+	if err := helm.DeleteHelmRelease(ctx, r.Client, clusterDeployment.Name+autoscalerReleaseSuffix, clusterDeployment.Namespace); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if clusterDeployment.Spec.DeletionPolicy == hmc.DeletionPolicyOrphan {
+		if err := r.orphanCluster(ctx, hr); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
 	if err := helm.DeleteHelmRelease(ctx, r.Client, clusterDeployment.Name, clusterDeployment.Namespace); err != nil {
 		return ctrl.Result{}, err
 	}
@@ -563,7 +741,7 @@ func (r *ClusterDeploymentReconciler) Delete(ctx context.Context, clusterDeploym
 		return ctrl.Result{}, err
 	}
 
-	if err := r.releaseCluster(ctx, clusterDeployment.Namespace, clusterDeployment.Name, clusterDeployment.Spec.Template); err != nil {
+	if err := r.releaseCluster(ctx, clusterDeployment.Namespace, clusterDeployment.Name); err != nil {
 		return ctrl.Result{}, err
 	}
 
@@ -571,64 +749,71 @@ func (r *ClusterDeploymentReconciler) Delete(ctx context.Context, clusterDeploym
 	return ctrl.Result{RequeueAfter: DefaultRequeueInterval}, nil
 }
 
-func (r *ClusterDeploymentReconciler) releaseCluster(ctx context.Context, namespace, name, templateName string) error {
-	providers, err := r.getInfraProvidersNames(ctx, namespace, templateName)
+// releaseCluster removes the blocking finalizer from the infrastructure clusters referenced
+// by the CAPI Clusters of the ClusterDeployment once all of their Machines are gone.
+func (r *ClusterDeploymentReconciler) releaseCluster(ctx context.Context, namespace, name string) error {
+	gvkMachine := schema.GroupVersionKind{
+		Group:   "cluster.x-k8s.io",
+		Version: "v1beta1",
+		Kind:    "Machine",
+	}
+
+	clusters, err := r.listCAPIObjects(ctx, namespace, "ClusterList", client.MatchingLabels{hmc.FluxHelmChartNameKey: name})
 	if err != nil {
 		return err
 	}
 
-	var (
-		gvkAWSCluster = schema.GroupVersionKind{
-			Group:   "infrastructure.cluster.x-k8s.io",
-			Version: "v1beta2",
-			Kind:    "AWSCluster",
+	for _, cluster := range clusters {
+		infraCluster, found, err := r.getInfrastructureCluster(ctx, &cluster)
+		if err != nil {
+			return err
 		}
-
-		gvkAzureCluster = schema.GroupVersionKind{
-			Group:   "infrastructure.cluster.x-k8s.io",
-			Version: "v1beta1",
-			Kind:    "AzureCluster",
+		if !found {
+			continue
 		}
 
-		gvkMachine = schema.GroupVersionKind{
-			Group:   "cluster.x-k8s.io",
-			Version: "v1beta1",
-			Kind:    "Machine",
+		found, err = r.objectsAvailable(ctx, namespace, cluster.GetName(), gvkMachine)
+		if err != nil {
+			return err
 		}
-	)
 
-	providerGVKs := map[string]schema.GroupVersionKind{
-		"aws":   gvkAWSCluster,
-		"azure": gvkAzureCluster,
+		if !found {
+			if err := r.removeClusterFinalizer(ctx, infraCluster); err != nil {
+				return err
+			}
+		}
 	}
 
-	// Associate the provider with it's GVK
-	for _, provider := range providers {
-		gvk, ok := providerGVKs[provider]
-		if !ok {
-			continue
-		}
+	return nil
+}
 
-		cluster, err := r.getCluster(ctx, namespace, name, gvk)
-		if err != nil {
-			if provider == "aws" && apierrors.IsNotFound(err) {
-				return nil
-			}
+// getInfrastructureCluster returns the metadata of the object referenced by the spec.infrastructureRef of the given CAPI Cluster,
+// found is false if the reference is not set or the object does not exist.
+func (r *ClusterDeploymentReconciler) getInfrastructureCluster(ctx context.Context, cluster *unstructured.Unstructured) (_ *metav1.PartialObjectMetadata, found bool, _ error) {
+	ref, found, err := unstructured.NestedStringMap(cluster.Object, "spec", "infrastructureRef")
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get infrastructure reference of CAPI Cluster %s: %w", client.ObjectKeyFromObject(cluster), err)
+	}
+	if !found || ref["kind"] == "" || ref["name"] == "" {
+		return nil, false, nil
+	}
 
-			return err
-		}
+	namespace := ref["namespace"]
+	if namespace == "" {
+		namespace = cluster.GetNamespace()
+	}
 
-		found, err := r.objectsAvailable(ctx, namespace, cluster.Name, gvkMachine)
-		if err != nil {
-			return err
+	infraCluster := new(metav1.PartialObjectMetadata)
+	infraCluster.SetGroupVersionKind(schema.FromAPIVersionAndKind(ref["apiVersion"], ref["kind"]))
+	if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref["name"]}, infraCluster); err != nil {
+		if apierrors.IsNotFound(err) || apimeta.IsNoMatchError(err) {
+			return nil, false, nil
 		}
 
-		if !found {
-			return r.removeClusterFinalizer(ctx, cluster)
-		}
+		return nil, false, fmt.Errorf("failed to get %s %s/%s: %w", ref["kind"], namespace, ref["name"], err)
 	}
 
-	return nil
+	return infraCluster, true, nil
 }
 
 func (r *ClusterDeploymentReconciler) getInfraProvidersNames(ctx context.Context, templateNamespace, templateName string) ([]string, error) {
@@ -639,37 +824,16 @@ func (r *ClusterDeploymentReconciler) getInfraProvidersNames(ctx context.Context
 		return nil, err
 	}
 
-	const infraPrefix = "infrastructure-"
-	var (
-		ips     = make([]string, 0, len(template.Status.Providers))
-		lprefix = len(infraPrefix)
-	)
+	ips := make([]string, 0, len(template.Status.Providers))
 	for _, v := range template.Status.Providers {
-		if idx := strings.Index(v, infraPrefix); idx > -1 {
-			ips = append(ips, v[idx+lprefix:])
+		if strings.HasPrefix(v, "infrastructure-") {
+			ips = append(ips, v)
 		}
 	}
 
 	return ips[:len(ips):len(ips)], nil
 }
 
-func (r *ClusterDeploymentReconciler) getCluster(ctx context.Context, namespace, name string, gvk schema.GroupVersionKind) (*metav1.PartialObjectMetadata, error) {
-	opts := &client.ListOptions{
-		LabelSelector: labels.SelectorFromSet(map[string]string{hmc.FluxHelmChartNameKey: name}),
-		Namespace:     namespace,
-	}
-	itemsList := &metav1.PartialObjectMetadataList{}
-	itemsList.SetGroupVersionKind(gvk)
-	if err := r.Client.List(ctx, itemsList, opts); err != nil {
-		return nil, err
-	}
-	if len(itemsList.Items) == 0 {
-		return nil, fmt.Errorf("%s with name %s was not found", gvk.Kind, name)
-	}
-
-	return &itemsList.Items[0], nil
-}
-
 func (r *ClusterDeploymentReconciler) removeClusterFinalizer(ctx context.Context, cluster *metav1.PartialObjectMetadata) error {
 	originalCluster := *cluster
 	if controllerutil.RemoveFinalizer(cluster, hmc.BlockingFinalizer) {
@@ -705,6 +869,11 @@ func (r *ClusterDeploymentReconciler) reconcileCredentialPropagation(ctx context
 		return fmt.Errorf("failed to get cluster providers for cluster %s/%s: %w", clusterDeployment.Namespace, clusterDeployment.Name, err)
 	}
 
+	mgmt := &hmc.Management{}
+	if err := r.Get(ctx, client.ObjectKey{Name: hmc.ManagementName}, mgmt); err != nil {
+		return fmt.Errorf("failed to get Management: %w", err)
+	}
+
 	kubeconfSecret := &corev1.Secret{}
 	if err := r.Client.Get(ctx, client.ObjectKey{
 		Name:      clusterDeployment.Name + "-kubeconfig",
@@ -722,10 +891,19 @@ func (r *ClusterDeploymentReconciler) reconcileCredentialPropagation(ctx context
 	}
 
 	for _, provider := range providers {
-		switch provider {
-		case "aws":
-			l.Info("Skipping creds propagation for AWS")
-		case "azure":
+		infrastructure, ok := mgmt.InfrastructureProvider(provider)
+		if !ok {
+			apimeta.SetStatusCondition(clusterDeployment.GetConditions(), metav1.Condition{
+				Type:    hmc.CredentialsPropagatedCondition,
+				Status:  metav1.ConditionFalse,
+				Reason:  hmc.FailedReason,
+				Message: "unsupported infrastructure provider " + provider,
+			})
+			continue
+		}
+
+		switch infrastructure.CredentialsPropagation {
+		case hmc.CredentialsPropagationAzure:
 			l.Info("Azure creds propagation start")
 			if err := credspropagation.PropagateAzureSecrets(ctx, propnCfg); err != nil {
 				errMsg := fmt.Sprintf("failed to create Azure CCM credentials: %s", err)
@@ -745,7 +923,7 @@ func (r *ClusterDeploymentReconciler) reconcileCredentialPropagation(ctx context
 				Reason:  hmc.SucceededReason,
 				Message: "Azure CCM credentials created",
 			})
-		case "vsphere":
+		case hmc.CredentialsPropagationVSphere:
 			l.Info("vSphere creds propagation start")
 			if err := credspropagation.PropagateVSphereSecrets(ctx, propnCfg); err != nil {
 				errMsg := fmt.Sprintf("failed to create vSphere CCM credentials: %s", err)
@@ -764,7 +942,7 @@ func (r *ClusterDeploymentReconciler) reconcileCredentialPropagation(ctx context
 				Reason:  hmc.SucceededReason,
 				Message: "vSphere CCM credentials created",
 			})
-		case "openstack":
+		case hmc.CredentialsPropagationOpenStack:
 			l.Info("OpenStack creds propagation start")
 			if err := credspropagation.PropagateOpenStackSecrets(ctx, propnCfg); err != nil {
 				errMsg := fmt.Sprintf("failed to create OpenStack CCM credentials: %s", err)
@@ -783,12 +961,14 @@ func (r *ClusterDeploymentReconciler) reconcileCredentialPropagation(ctx context
 				Reason:  hmc.SucceededReason,
 				Message: "OpenStack CCM credentials created",
 			})
+		case "", hmc.CredentialsPropagationNone:
+			l.Info("Skipping creds propagation", "provider", provider)
 		default:
 			apimeta.SetStatusCondition(clusterDeployment.GetConditions(), metav1.Condition{
 				Type:    hmc.CredentialsPropagatedCondition,
 				Status:  metav1.ConditionFalse,
 				Reason:  hmc.FailedReason,
-				Message: "unsupported infrastructure provider " + provider,
+				Message: fmt.Sprintf("unsupported credentials propagation %s of the infrastructure provider %s", infrastructure.CredentialsPropagation, provider),
 			})
 		}
 	}
@@ -922,5 +1102,32 @@ func (r *ClusterDeploymentReconciler) SetupWithManager(mgr ctrl.Manager) error {
 				return req
 			}),
 		).
+		Watches(&hmc.MaintenanceWindow{},
+			handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, o client.Object) []ctrl.Request {
+				// the postponed changes are evaluated again once the MaintenanceWindow
+				// is created, its schedule changes or it is removed
+				clusterDeployments := &hmc.ClusterDeploymentList{}
+				err := r.Client.List(ctx, clusterDeployments,
+					client.MatchingFields{hmc.ClusterDeploymentMaintenanceWindowIndexKey: o.GetName()})
+				if err != nil {
+					return []ctrl.Request{}
+				}
+
+				req := []ctrl.Request{}
+				for _, cluster := range clusterDeployments.Items {
+					req = append(req, ctrl.Request{
+						NamespacedName: client.ObjectKey{
+							Namespace: cluster.Namespace,
+							Name:      cluster.Name,
+						},
+					})
+				}
+
+				return req
+			}),
+			builder.WithPredicates(predicate.Funcs{
+				GenericFunc: func(event.GenericEvent) bool { return false },
+			}),
+		).
 		Complete(r)
 }
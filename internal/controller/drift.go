@@ -0,0 +1,233 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	hcv2 "github.com/fluxcd/helm-controller/api/v2"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
+)
+
+// maxDriftedFields is the maximum number of the drifted fields of a single object listed in the Drifted condition.
+const maxDriftedFields = 5
+
+// reconcileDrift compares the objects of the deployed Helm release of the given ClusterDeployment with the live ones
+// and reports the drifted fields with the Drifted condition. The drifted fields are reverted to the rendered values
+// if the drift correction is enabled and the maintenance window is open.
+func (r *ClusterDeploymentReconciler) reconcileDrift(ctx context.Context, clusterDeployment *hmc.ClusterDeployment, hr *hcv2.HelmRelease, window *maintenanceWindow) error {
+	if clusterDeployment.Spec.DriftDetection == nil {
+		apimeta.RemoveStatusCondition(clusterDeployment.GetConditions(), hmc.DriftedCondition)
+		return nil
+	}
+
+	release, found, err := r.getDeployedRelease(ctx, hr)
+	if err != nil || !found {
+		return err
+	}
+
+	objects, err := decodeManifests(release.Manifest)
+	if err != nil {
+		return fmt.Errorf("failed to decode manifest of Helm release %s: %w", release.Name, err)
+	}
+
+	correct := clusterDeployment.Spec.DriftDetection.Correct && window.open
+
+	var drifts, corrected []string
+	for _, desired := range objects {
+		name := desired.GetKind() + "/" + desired.GetName()
+
+		live := desired.DeepCopy()
+		found, err := r.getReleaseObject(ctx, live, release.Namespace)
+		if err != nil {
+			return err
+		}
+		if !found {
+			drifts = append(drifts, name+" is missing")
+			continue
+		}
+
+		expected := driftComparable(desired.Object)
+		if clusterDeployment.Spec.Autoscaling != nil && (desired.GetKind() == "MachineDeployment" || desired.GetKind() == "MachinePool") {
+			// the replicas are managed by the cluster-autoscaler
+			unstructured.RemoveNestedField(expected, "spec", "replicas")
+		}
+		fields := driftedFields("", expected, live.Object)
+		if len(fields) == 0 {
+			continue
+		}
+
+		drifts = append(drifts, name+": "+summarizeFields(fields))
+		if !correct {
+			continue
+		}
+
+		patch, err := json.Marshal(expected)
+		if err != nil {
+			return fmt.Errorf("failed to marshal the rendered %s: %w", name, err)
+		}
+		if err := r.Patch(ctx, live, client.RawPatch(types.MergePatchType, patch)); err != nil {
+			return fmt.Errorf("failed to correct the drift of %s: %w", name, err)
+		}
+		corrected = append(corrected, name)
+	}
+
+	if len(drifts) == 0 {
+		apimeta.RemoveStatusCondition(clusterDeployment.GetConditions(), hmc.DriftedCondition)
+		return nil
+	}
+
+	message := "Drift detected: " + strings.Join(drifts, "; ")
+	if len(corrected) > 0 {
+		ctrl.LoggerFrom(ctx).Info("Corrected the drift of the cluster objects", "objects", corrected)
+		message += ". Corrected " + strings.Join(corrected, ", ")
+	} else if clusterDeployment.Spec.DriftDetection.Correct && !window.open {
+		message += ". The correction is pending until the maintenance window"
+	}
+
+	apimeta.SetStatusCondition(clusterDeployment.GetConditions(), metav1.Condition{
+		Type:    hmc.DriftedCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  hmc.ProgressingReason,
+		Message: message,
+	})
+
+	return nil
+}
+
+// driftComparable returns the part of the given rendered object subject to the drift detection,
+// i.e. the object without its status and the metadata other than the labels and annotations.
+func driftComparable(obj map[string]any) map[string]any {
+	result := make(map[string]any, len(obj))
+	for k, v := range obj {
+		switch k {
+		case "apiVersion", "kind", "status":
+			// not subject to the drift
+		case "metadata":
+			metadata, ok := v.(map[string]any)
+			if !ok {
+				continue
+			}
+
+			resultMetadata := make(map[string]any)
+			for _, field := range []string{"labels", "annotations"} {
+				if value, ok := metadata[field]; ok {
+					resultMetadata[field] = value
+				}
+			}
+			if len(resultMetadata) > 0 {
+				result[k] = resultMetadata
+			}
+		default:
+			result[k] = v
+		}
+	}
+
+	return result
+}
+
+// driftedFields returns the paths of the fields set in the desired value which differ in the live one.
+// The fields absent in the desired value, e.g. the ones defaulted by the API server, are not compared.
+func driftedFields(path string, desired, live any) []string {
+	switch desired := desired.(type) {
+	case nil:
+		return nil
+	case map[string]any:
+		liveMap, ok := live.(map[string]any)
+		if !ok {
+			return []string{path}
+		}
+
+		keys := make([]string, 0, len(desired))
+		for k := range desired {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+
+		var fields []string
+		for _, k := range keys {
+			fieldPath := k
+			if path != "" {
+				fieldPath = path + "." + k
+			}
+			fields = append(fields, driftedFields(fieldPath, desired[k], liveMap[k])...)
+		}
+
+		return fields
+	case []any:
+		liveSlice, ok := live.([]any)
+		if !ok || len(liveSlice) != len(desired) {
+			return []string{path}
+		}
+
+		var fields []string
+		for i := range desired {
+			fields = append(fields, driftedFields(path+"["+strconv.Itoa(i)+"]", desired[i], liveSlice[i])...)
+		}
+
+		return fields
+	default:
+		if desiredNumber, ok := toFloat(desired); ok {
+			if liveNumber, ok := toFloat(live); ok && desiredNumber == liveNumber {
+				return nil
+			}
+			return []string{path}
+		}
+
+		if !reflect.DeepEqual(desired, live) {
+			return []string{path}
+		}
+
+		return nil
+	}
+}
+
+// toFloat converts the given decoded JSON or YAML number to float64.
+func toFloat(v any) (float64, bool) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+// summarizeFields joins the given field paths truncating the list to maxDriftedFields.
+func summarizeFields(fields []string) string {
+	if len(fields) <= maxDriftedFields {
+		return strings.Join(fields, ", ")
+	}
+
+	return fmt.Sprintf("%s and %d more", strings.Join(fields[:maxDriftedFields], ", "), len(fields)-maxDriftedFields)
+}
@@ -0,0 +1,79 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Drift detection", func() {
+	const manifest = `
+apiVersion: cluster.x-k8s.io/v1beta1
+kind: MachineDeployment
+metadata:
+  name: test-md
+  labels:
+    app: test
+spec:
+  replicas: 2
+  template:
+    spec:
+      version: v1.31.1+k0s.1
+      infrastructureRef:
+        kind: AWSMachineTemplate
+        name: test-mt
+status:
+  replicas: 2
+`
+
+	It("should ignore the fields not set by the template", func() {
+		objects, err := decodeManifests(manifest)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(objects).To(HaveLen(1))
+
+		live := objects[0].DeepCopy()
+		live.SetNamespace("default")
+		live.SetResourceVersion("1")
+		live.SetLabels(map[string]string{"app": "test", "cluster.x-k8s.io/cluster-name": "test"})
+		live.Object["spec"].(map[string]any)["replicas"] = int64(2)
+		live.Object["spec"].(map[string]any)["minReadySeconds"] = int64(0)
+		live.Object["status"] = map[string]any{"replicas": int64(1)}
+
+		Expect(driftedFields("", driftComparable(objects[0].Object), live.Object)).To(BeEmpty())
+	})
+
+	It("should report the changed fields", func() {
+		objects, err := decodeManifests(manifest)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(objects).To(HaveLen(1))
+
+		live := objects[0].DeepCopy()
+		live.SetLabels(nil)
+		live.Object["spec"].(map[string]any)["replicas"] = int64(3)
+		live.Object["spec"].(map[string]any)["template"].(map[string]any)["spec"].(map[string]any)["version"] = "v1.30.4+k0s.0"
+
+		Expect(driftedFields("", driftComparable(objects[0].Object), live.Object)).To(Equal([]string{
+			"metadata.labels",
+			"spec.replicas",
+			"spec.template.spec.version",
+		}))
+	})
+
+	It("should summarize the drifted fields", func() {
+		Expect(summarizeFields([]string{"a", "b"})).To(Equal("a, b"))
+		Expect(summarizeFields([]string{"a", "b", "c", "d", "e", "f", "g"})).To(Equal("a, b, c, d, e and 2 more"))
+	})
+})
@@ -0,0 +1,151 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/release"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
+)
+
+const (
+	// the ConfigMap holding the dry run result of a ClusterDeployment
+	dryRunResultSuffix = "-dry-run"
+	dryRunObjectsKey   = "objects"
+	dryRunManifestKey  = "manifest.yaml"
+	// leaves room for the list of the objects within the 1MiB limit of the ConfigMap
+	maxDryRunManifestSize = 768 * 1024
+)
+
+// renderTemplate renders the given chart of the template of the given ClusterDeployment with the given values
+// without installing it.
+func renderTemplate(ctx context.Context, actionConfig *action.Configuration, clusterDeployment *hmc.ClusterDeployment, hcChart *chart.Chart, values *apiextensionsv1.JSON) (*release.Release, error) {
+	install := action.NewInstall(actionConfig)
+	install.DryRun = true
+	install.ReleaseName = clusterDeployment.Name
+	install.Namespace = clusterDeployment.Namespace
+	install.ClientOnly = true
+
+	vals := make(map[string]any)
+	if values != nil {
+		if err := json.Unmarshal(values.Raw, &vals); err != nil {
+			return nil, fmt.Errorf("error unmarshalling values: %w", err)
+		}
+	}
+
+	return install.RunWithContext(ctx, hcChart, vals)
+}
+
+// reconcileDryRunResult renders the template of the given ClusterDeployment with the given values and stores
+// the rendered manifest along with the list of the objects to be created in the ConfigMap referenced from the status.
+func (r *ClusterDeploymentReconciler) reconcileDryRunResult(ctx context.Context, actionConfig *action.Configuration, clusterDeployment *hmc.ClusterDeployment, hcChart *chart.Chart, values *apiextensionsv1.JSON) error {
+	release, err := renderTemplate(ctx, actionConfig, clusterDeployment, hcChart, values)
+	if err != nil {
+		return fmt.Errorf("failed to render the template: %w", err)
+	}
+
+	objects, err := decodeManifests(release.Manifest)
+	if err != nil {
+		return fmt.Errorf("failed to decode the rendered template: %w", err)
+	}
+
+	var summary strings.Builder
+	for _, obj := range objects {
+		name, err := r.dryRunObjectName(obj, clusterDeployment.Namespace)
+		if err != nil {
+			return err
+		}
+		summary.WriteString(obj.GetKind() + " " + name + "\n")
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      clusterDeployment.Name + dryRunResultSuffix,
+			Namespace: clusterDeployment.Namespace,
+		},
+	}
+
+	_, err = ctrl.CreateOrUpdate(ctx, r.Client, cm, func() error {
+		cm.Data = map[string]string{dryRunObjectsKey: summary.String()}
+		// the manifest may not fit into the ConfigMap, the list of the objects is kept anyway
+		if len(release.Manifest) <= maxDryRunManifestSize {
+			cm.Data[dryRunManifestKey] = release.Manifest
+		}
+		return controllerutil.SetControllerReference(clusterDeployment, cm, r.Scheme())
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reconcile dry run result ConfigMap %s: %w", client.ObjectKeyFromObject(cm), err)
+	}
+
+	clusterDeployment.Status.DryRunResult = cm.Name
+	return nil
+}
+
+// dryRunObjectName returns the namespaced name of the given rendered object defaulting its namespace
+// to the release one if the object is namespaced. The kinds not installed yet are assumed to be namespaced.
+func (r *ClusterDeploymentReconciler) dryRunObjectName(obj client.Object, releaseNamespace string) (string, error) {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	namespaced := true
+	mapping, err := r.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	switch {
+	case err == nil:
+		namespaced = mapping.Scope.Name() == apimeta.RESTScopeNameNamespace
+	case !apimeta.IsNoMatchError(err):
+		return "", fmt.Errorf("failed to get REST mapping of %s: %w", gvk, err)
+	}
+
+	if !namespaced {
+		return obj.GetName(), nil
+	}
+	if obj.GetNamespace() == "" {
+		obj.SetNamespace(releaseNamespace)
+	}
+
+	return client.ObjectKeyFromObject(obj).String(), nil
+}
+
+// deleteDryRunResult deletes the ConfigMap with the dry run result of the given ClusterDeployment if any.
+func (r *ClusterDeploymentReconciler) deleteDryRunResult(ctx context.Context, clusterDeployment *hmc.ClusterDeployment) error {
+	if clusterDeployment.Status.DryRunResult == "" {
+		return nil
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      clusterDeployment.Status.DryRunResult,
+			Namespace: clusterDeployment.Namespace,
+		},
+	}
+	if err := r.Delete(ctx, cm); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to delete dry run result ConfigMap %s: %w", client.ObjectKeyFromObject(cm), err)
+	}
+
+	clusterDeployment.Status.DryRunResult = ""
+	return nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
+)
+
+var _ = Describe("ClusterDeployment dry run", func() {
+	ctx := context.Background()
+
+	// the ClusterDeployment itself is not created since only the dry run result is exercised
+	clusterDeployment := &hmc.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-dry-run",
+			Namespace: metav1.NamespaceDefault,
+			UID:       types.UID("test-dry-run-uid"),
+		},
+		Spec: hmc.ClusterDeploymentSpec{
+			Template: "test-template",
+			DryRun:   true,
+		},
+	}
+
+	hcChart := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "test-template", Version: "0.1.0"},
+		Templates: []*chart.File{{
+			Name: "templates/cluster.yaml",
+			Data: []byte(`apiVersion: cluster.x-k8s.io/v1beta1
+kind: Cluster
+metadata:
+  name: {{ .Release.Name }}
+  namespace: {{ .Release.Namespace }}
+spec:
+  controlPlaneEndpoint:
+    host: {{ .Values.host }}
+`),
+		}, {
+			// the templates commonly rely on the release namespace instead of setting it
+			Name: "templates/config.yaml",
+			Data: []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .Release.Name }}-config
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: {{ .Release.Name }}-role
+`),
+		}},
+	}
+
+	It("should store the rendered manifest", func() {
+		controllerReconciler := &ClusterDeploymentReconciler{Client: k8sClient}
+
+		values := &apiextensionsv1.JSON{Raw: []byte(`{"host":"test.example.com"}`)}
+		Expect(controllerReconciler.reconcileDryRunResult(ctx, &action.Configuration{Log: GinkgoWriter.Printf}, clusterDeployment, hcChart, values)).To(Succeed())
+		Expect(clusterDeployment.Status.DryRunResult).To(Equal("test-dry-run-dry-run"))
+
+		cm := &corev1.ConfigMap{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: clusterDeployment.Status.DryRunResult, Namespace: metav1.NamespaceDefault}, cm)).To(Succeed())
+		Expect(metav1.IsControlledBy(cm, clusterDeployment)).To(BeTrue())
+		Expect(strings.Split(strings.TrimSpace(cm.Data["objects"]), "\n")).To(ConsistOf(
+			"Cluster default/test-dry-run",
+			"ConfigMap default/test-dry-run-config",
+			"ClusterRole test-dry-run-role",
+		))
+		Expect(cm.Data).To(HaveKeyWithValue("manifest.yaml", ContainSubstring("host: test.example.com")))
+
+		By("Deleting the dry run result once the dry run is disabled")
+		Expect(controllerReconciler.deleteDryRunResult(ctx, clusterDeployment)).To(Succeed())
+		Expect(clusterDeployment.Status.DryRunResult).To(BeEmpty())
+		Expect(errors.IsNotFound(k8sClient.Get(ctx, client.ObjectKeyFromObject(cm), cm))).To(BeTrue())
+	})
+})
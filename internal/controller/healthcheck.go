@@ -0,0 +1,72 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
+)
+
+// infrastructureHealthChecks returns the health checks of the infrastructure cluster objects
+// declared by the infrastructure providers of the given template and missing from the given health checks.
+func (r *ClusterDeploymentReconciler) infrastructureHealthChecks(ctx context.Context, clusterTpl *hmc.ClusterTemplate, healthChecks []hmc.HealthCheck) ([]hmc.HealthCheck, error) {
+	mgmt := &hmc.Management{}
+	if err := r.Get(ctx, client.ObjectKey{Name: hmc.ManagementName}, mgmt); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get Management: %w", err)
+	}
+
+	var infraHealthChecks []hmc.HealthCheck
+	for _, provider := range clusterTpl.Status.Providers {
+		infrastructure, ok := mgmt.InfrastructureProvider(provider)
+		if !ok || infrastructure.Cluster == nil || len(infrastructure.HealthConditions) == 0 {
+			continue
+		}
+
+		gvk := schema.GroupVersionKind(*infrastructure.Cluster)
+		mapping, err := r.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			if apimeta.IsNoMatchError(err) { // the provider is not installed yet
+				continue
+			}
+			return nil, fmt.Errorf("failed to get REST mapping of %s: %w", gvk, err)
+		}
+
+		healthCheck := hmc.HealthCheck{
+			Group:      mapping.Resource.Group,
+			Version:    mapping.Resource.Version,
+			Resource:   mapping.Resource.Resource,
+			Conditions: infrastructure.HealthConditions,
+		}
+		if slices.ContainsFunc(healthChecks, func(hc hmc.HealthCheck) bool {
+			return hc.Group == healthCheck.Group && hc.Version == healthCheck.Version && hc.Resource == healthCheck.Resource
+		}) {
+			continue
+		}
+		infraHealthChecks = append(infraHealthChecks, healthCheck)
+	}
+
+	return infraHealthChecks, nil
+}
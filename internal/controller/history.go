@@ -0,0 +1,127 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"slices"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
+)
+
+// recordRevision appends a revision to the history of the given ClusterDeployment if either its template
+// or config has changed since the latest revision. The latest revision is superseded if it is still pending.
+func recordRevision(clusterDeployment *hmc.ClusterDeployment, chartVersion string) {
+	var configRaw []byte
+	if clusterDeployment.Spec.Config != nil {
+		configRaw = clusterDeployment.Spec.Config.Raw
+	}
+	valuesHash := fmt.Sprintf("sha256:%x", sha256.Sum256(configRaw))
+
+	now := metav1.Now()
+	revision := int64(1)
+	if history := clusterDeployment.Status.History; len(history) > 0 {
+		latest := &history[len(history)-1]
+		if latest.Template == clusterDeployment.Spec.Template && latest.ValuesHash == valuesHash {
+			return
+		}
+
+		revision = latest.Revision + 1
+		if latest.Outcome == hmc.RevisionOutcomePending {
+			latest.Outcome = hmc.RevisionOutcomeSuperseded
+			latest.CompletionTime = &now
+		}
+	}
+
+	clusterDeployment.Status.History = append(clusterDeployment.Status.History, hmc.ClusterRevision{
+		StartTime:    now,
+		Config:       clusterDeployment.Spec.Config.DeepCopy(),
+		Template:     clusterDeployment.Spec.Template,
+		ChartVersion: chartVersion,
+		ValuesHash:   valuesHash,
+		Outcome:      hmc.RevisionOutcomePending,
+		Revision:     revision,
+	})
+
+	if extra := len(clusterDeployment.Status.History) - hmc.ClusterDeploymentMaxHistory; extra > 0 {
+		clusterDeployment.Status.History = slices.Delete(clusterDeployment.Status.History, 0, extra)
+	}
+}
+
+// completeRevision sets the outcome of the latest revision of the given ClusterDeployment if it is still pending.
+func completeRevision(clusterDeployment *hmc.ClusterDeployment, outcome hmc.RevisionOutcome, message string) {
+	history := clusterDeployment.Status.History
+	if len(history) == 0 || history[len(history)-1].Outcome != hmc.RevisionOutcomePending {
+		return
+	}
+
+	now := metav1.Now()
+	latest := &history[len(history)-1]
+	latest.Outcome = outcome
+	latest.Message = message
+	latest.CompletionTime = &now
+}
+
+// rollbackToRevision sets the template and the config of the given ClusterDeployment to the ones
+// of the given revision from its history and drops the rollback annotation. The revision which is
+// either invalid or not found is kept in the annotation until the annotation is fixed or removed,
+// the failure is set in the RolledBack condition to be reported along with the rest of the status.
+func (r *ClusterDeploymentReconciler) rollbackToRevision(ctx context.Context, clusterDeployment *hmc.ClusterDeployment, revision string) (rolledBack bool, _ error) {
+	l := ctrl.LoggerFrom(ctx)
+
+	target, err := clusterDeployment.GetRevision(revision)
+	if err != nil {
+		l.Error(err, "Failed to roll back", "revision", revision)
+		apimeta.SetStatusCondition(clusterDeployment.GetConditions(), metav1.Condition{
+			Type:               hmc.RolledBackCondition,
+			Status:             metav1.ConditionFalse,
+			Reason:             hmc.FailedReason,
+			Message:            fmt.Sprintf("Failed to roll back: %s", err),
+			ObservedGeneration: clusterDeployment.Generation,
+		})
+		return false, nil
+	}
+
+	original := clusterDeployment.DeepCopy()
+	delete(clusterDeployment.Annotations, hmc.ClusterDeploymentRollbackAnnotation)
+	clusterDeployment.Spec.Template = target.Template
+	clusterDeployment.Spec.Config = target.Config.DeepCopy()
+
+	if err := r.Patch(ctx, clusterDeployment, client.MergeFrom(original)); err != nil {
+		return false, fmt.Errorf("failed to roll back clusterDeployment %s/%s: %w", clusterDeployment.Namespace, clusterDeployment.Name, err)
+	}
+
+	apimeta.SetStatusCondition(clusterDeployment.GetConditions(), metav1.Condition{
+		Type:               hmc.RolledBackCondition,
+		Status:             metav1.ConditionTrue,
+		Reason:             hmc.SucceededReason,
+		Message:            fmt.Sprintf("Rolled back to revision %d", target.Revision),
+		ObservedGeneration: clusterDeployment.Generation,
+	})
+	if err := r.Status().Update(ctx, clusterDeployment); err != nil {
+		return false, fmt.Errorf("failed to update status for clusterDeployment %s/%s: %w", clusterDeployment.Namespace, clusterDeployment.Name, err)
+	}
+
+	l.Info("Rolled back to revision", "revision", target.Revision, "template", target.Template)
+
+	return true, nil
+}
@@ -0,0 +1,164 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
+)
+
+var _ = Describe("ClusterDeployment history", func() {
+	It("should record the revisions of the cluster", func() {
+		clusterDeployment := &hmc.ClusterDeployment{
+			Spec: hmc.ClusterDeploymentSpec{
+				Template: "template-1-0-0",
+				Config:   &apiextensionsv1.JSON{Raw: []byte(`{"foo":"bar"}`)},
+			},
+		}
+
+		recordRevision(clusterDeployment, "1.0.0")
+		recordRevision(clusterDeployment, "1.0.0")
+		Expect(clusterDeployment.Status.History).To(HaveLen(1))
+		Expect(clusterDeployment.Status.History[0].Revision).To(Equal(int64(1)))
+		Expect(clusterDeployment.Status.History[0].Outcome).To(Equal(hmc.RevisionOutcomePending))
+
+		completeRevision(clusterDeployment, hmc.RevisionOutcomeDeployed, "Cluster is ready")
+		Expect(clusterDeployment.Status.History[0].Outcome).To(Equal(hmc.RevisionOutcomeDeployed))
+
+		By("changing the config")
+		clusterDeployment.Spec.Config = &apiextensionsv1.JSON{Raw: []byte(`{"foo":"baz"}`)}
+		recordRevision(clusterDeployment, "1.0.0")
+		Expect(clusterDeployment.Status.History).To(HaveLen(2))
+		Expect(clusterDeployment.Status.History[1].Revision).To(Equal(int64(2)))
+		Expect(clusterDeployment.Status.History[1].ValuesHash).NotTo(Equal(clusterDeployment.Status.History[0].ValuesHash))
+
+		By("changing the template before the revision is deployed")
+		clusterDeployment.Spec.Template = "template-1-0-1"
+		recordRevision(clusterDeployment, "1.0.1")
+		Expect(clusterDeployment.Status.History[1].Outcome).To(Equal(hmc.RevisionOutcomeSuperseded))
+		Expect(clusterDeployment.Status.History[2].ChartVersion).To(Equal("1.0.1"))
+
+		revision, err := clusterDeployment.GetRevision("1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(revision.Config.Raw).To(Equal([]byte(`{"foo":"bar"}`)))
+	})
+
+	It("should keep the latest revisions only", func() {
+		clusterDeployment := &hmc.ClusterDeployment{}
+		for i := range hmc.ClusterDeploymentMaxHistory + 2 {
+			clusterDeployment.Spec.Template = fmt.Sprintf("template-%d", i)
+			recordRevision(clusterDeployment, "")
+		}
+
+		Expect(clusterDeployment.Status.History).To(HaveLen(hmc.ClusterDeploymentMaxHistory))
+		Expect(clusterDeployment.Status.History[0].Revision).To(Equal(int64(3)))
+	})
+
+	Context("When rolling back to a revision", func() {
+		const clusterDeploymentName = "test-rollback"
+
+		ctx := context.Background()
+
+		clusterDeployment := &hmc.ClusterDeployment{}
+
+		BeforeEach(func() {
+			By("creating the ClusterDeployment with the history")
+			clusterDeployment = &hmc.ClusterDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      clusterDeploymentName,
+					Namespace: metav1.NamespaceDefault,
+				},
+				Spec: hmc.ClusterDeploymentSpec{
+					Template: "template-1-0-1",
+					Config:   &apiextensionsv1.JSON{Raw: []byte(`{"foo":"baz"}`)},
+				},
+			}
+			Expect(k8sClient.Create(ctx, clusterDeployment)).To(Succeed())
+
+			clusterDeployment.Status.History = []hmc.ClusterRevision{
+				{
+					Revision:   1,
+					Template:   "template-1-0-0",
+					Config:     &apiextensionsv1.JSON{Raw: []byte(`{"foo":"bar"}`)},
+					StartTime:  metav1.Now(),
+					ValuesHash: "hash-1",
+					Outcome:    hmc.RevisionOutcomeDeployed,
+				},
+				{
+					Revision:   2,
+					Template:   "template-1-0-1",
+					Config:     &apiextensionsv1.JSON{Raw: []byte(`{"foo":"baz"}`)},
+					StartTime:  metav1.Now(),
+					ValuesHash: "hash-2",
+					Outcome:    hmc.RevisionOutcomeFailed,
+				},
+			}
+			Expect(k8sClient.Status().Update(ctx, clusterDeployment)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			By("Cleanup the ClusterDeployment")
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, clusterDeployment))).To(Succeed())
+		})
+
+		It("should restore the template and the config of the revision", func() {
+			controllerReconciler := &ClusterDeploymentReconciler{Client: k8sClient}
+
+			clusterDeployment.Annotations = map[string]string{hmc.ClusterDeploymentRollbackAnnotation: "1"}
+			Expect(controllerReconciler.rollbackToRevision(ctx, clusterDeployment, "1")).To(BeTrue())
+
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(clusterDeployment), clusterDeployment)).To(Succeed())
+			Expect(clusterDeployment.Annotations).NotTo(HaveKey(hmc.ClusterDeploymentRollbackAnnotation))
+			Expect(clusterDeployment.Spec.Template).To(Equal("template-1-0-0"))
+			Expect(clusterDeployment.Spec.Config.Raw).To(MatchJSON(`{"foo":"bar"}`))
+
+			cond := apimeta.FindStatusCondition(clusterDeployment.Status.Conditions, hmc.RolledBackCondition)
+			Expect(cond).NotTo(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+			Expect(cond.ObservedGeneration).To(Equal(clusterDeployment.Generation))
+		})
+
+		DescribeTable("should keep the invalid rollback request",
+			func(revision, expectedMessage string) {
+				controllerReconciler := &ClusterDeploymentReconciler{Client: k8sClient}
+
+				clusterDeployment.Annotations = map[string]string{hmc.ClusterDeploymentRollbackAnnotation: revision}
+				Expect(k8sClient.Update(ctx, clusterDeployment)).To(Succeed())
+				Expect(controllerReconciler.rollbackToRevision(ctx, clusterDeployment, revision)).To(BeFalse())
+
+				cond := apimeta.FindStatusCondition(clusterDeployment.Status.Conditions, hmc.RolledBackCondition)
+				Expect(cond).NotTo(BeNil())
+				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+				Expect(cond.Reason).To(Equal(hmc.FailedReason))
+				Expect(cond.Message).To(ContainSubstring(expectedMessage))
+
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(clusterDeployment), clusterDeployment)).To(Succeed())
+				Expect(clusterDeployment.Annotations).To(HaveKeyWithValue(hmc.ClusterDeploymentRollbackAnnotation, revision))
+				Expect(clusterDeployment.Spec.Template).To(Equal("template-1-0-1"))
+			},
+			Entry("unknown revision", "5", "revision 5 is not found in the history"),
+			Entry("unparsable revision", "latest", `invalid revision "latest"`),
+		)
+	})
+})
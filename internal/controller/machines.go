@@ -0,0 +1,200 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
+)
+
+// reconcileMachinesStatus summarizes the CAPI control planes, MachineDeployments,
+// MachinePools and Machines of the given ClusterDeployment into its status.
+func (r *ClusterDeploymentReconciler) reconcileMachinesStatus(ctx context.Context, clusterDeployment *hmc.ClusterDeployment) error {
+	clusters, err := r.listCAPIObjects(ctx, clusterDeployment.Namespace, "ClusterList",
+		client.MatchingLabels{hmc.FluxHelmChartNameKey: clusterDeployment.Name})
+	if err != nil {
+		return err
+	}
+
+	if len(clusters) == 0 {
+		clusterDeployment.Status.Machines = nil
+		return nil
+	}
+
+	var controlPlanes, machineDeployments, machinePools, machines []unstructured.Unstructured
+	for _, cluster := range clusters {
+		controlPlane, found, err := r.getControlPlane(ctx, &cluster)
+		if err != nil {
+			return err
+		}
+		if found {
+			controlPlanes = append(controlPlanes, *controlPlane)
+		}
+
+		selector := client.MatchingLabels{hmc.ClusterNameLabelKey: cluster.GetName()}
+		for kind, list := range map[string]*[]unstructured.Unstructured{
+			"MachineDeploymentList": &machineDeployments,
+			"MachinePoolList":       &machinePools,
+			"MachineList":           &machines,
+		} {
+			items, err := r.listCAPIObjects(ctx, cluster.GetNamespace(), kind, selector)
+			if err != nil {
+				return err
+			}
+			*list = append(*list, items...)
+		}
+	}
+
+	clusterDeployment.Status.Machines = summarizeMachines(controlPlanes, machineDeployments, machinePools, machines)
+
+	return nil
+}
+
+// listCAPIObjects lists the cluster.x-k8s.io/v1beta1 objects of the given list kind,
+// returns no objects if the kind is not installed.
+func (r *ClusterDeploymentReconciler) listCAPIObjects(ctx context.Context, namespace, listKind string, selector client.MatchingLabels) ([]unstructured.Unstructured, error) {
+	list := new(unstructured.UnstructuredList)
+	list.SetGroupVersionKind(schema.GroupVersionKind{Group: "cluster.x-k8s.io", Version: "v1beta1", Kind: listKind})
+	if err := r.List(ctx, list, client.InNamespace(namespace), selector); err != nil {
+		if apimeta.IsNoMatchError(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to list %s: %w", strings.TrimSuffix(listKind, "List"), err)
+	}
+
+	return list.Items, nil
+}
+
+// getControlPlane returns the object referenced by the spec.controlPlaneRef of the given CAPI Cluster,
+// found is false if the reference is not set or the object does not exist.
+func (r *ClusterDeploymentReconciler) getControlPlane(ctx context.Context, cluster *unstructured.Unstructured) (_ *unstructured.Unstructured, found bool, _ error) {
+	ref, found, err := unstructured.NestedStringMap(cluster.Object, "spec", "controlPlaneRef")
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get control plane reference of CAPI Cluster %s: %w", client.ObjectKeyFromObject(cluster), err)
+	}
+	if !found || ref["kind"] == "" || ref["name"] == "" {
+		return nil, false, nil
+	}
+
+	namespace := ref["namespace"]
+	if namespace == "" {
+		namespace = cluster.GetNamespace()
+	}
+
+	controlPlane := new(unstructured.Unstructured)
+	controlPlane.SetAPIVersion(ref["apiVersion"])
+	controlPlane.SetKind(ref["kind"])
+	if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref["name"]}, controlPlane); err != nil {
+		if apierrors.IsNotFound(err) || apimeta.IsNoMatchError(err) {
+			return nil, false, nil
+		}
+
+		return nil, false, fmt.Errorf("failed to get %s %s/%s: %w", ref["kind"], namespace, ref["name"], err)
+	}
+
+	return controlPlane, true, nil
+}
+
+// summarizeMachines builds the inventory of the machines from the given CAPI objects.
+// The control planes without replicas, e.g. the managed ones, are omitted.
+func summarizeMachines(controlPlanes, machineDeployments, machinePools, machines []unstructured.Unstructured) *hmc.MachinesStatus {
+	machinesStatus := new(hmc.MachinesStatus)
+
+	addReplicas := func(obj *unstructured.Unstructured) (hmc.ReplicasStatus, bool) {
+		desired, found, err := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+		if err != nil || !found {
+			return hmc.ReplicasStatus{}, false
+		}
+		ready, _, _ := unstructured.NestedInt64(obj.Object, "status", "readyReplicas")
+
+		replicas := hmc.ReplicasStatus{
+			Kind:    obj.GetKind(),
+			Name:    obj.GetName(),
+			Desired: int32(desired),
+			Ready:   int32(ready),
+		}
+		replicas.Summary = fmt.Sprintf("%d/%d", replicas.Ready, replicas.Desired)
+
+		machinesStatus.Desired += replicas.Desired
+		machinesStatus.Ready += replicas.Ready
+
+		return replicas, true
+	}
+
+	for _, controlPlane := range controlPlanes {
+		replicas, ok := addReplicas(&controlPlane)
+		if ok && machinesStatus.ControlPlane == nil {
+			machinesStatus.ControlPlane = &replicas
+		}
+	}
+
+	for _, md := range machineDeployments {
+		if replicas, ok := addReplicas(&md); ok {
+			machinesStatus.MachineDeployments = append(machinesStatus.MachineDeployments, replicas)
+		}
+	}
+
+	var providerIDs []string
+	for _, mp := range machinePools {
+		if replicas, ok := addReplicas(&mp); ok {
+			machinesStatus.MachinePools = append(machinesStatus.MachinePools, replicas)
+		}
+
+		ids, _, _ := unstructured.NestedStringSlice(mp.Object, "spec", "providerIDList")
+		providerIDs = append(providerIDs, ids...)
+	}
+
+	for _, machine := range machines {
+		providerID, _, _ := unstructured.NestedString(machine.Object, "spec", "providerID")
+		if providerID != "" {
+			providerIDs = append(providerIDs, providerID)
+		}
+
+		phase, _, _ := unstructured.NestedString(machine.Object, "status", "phase")
+		failureReason, _, _ := unstructured.NestedString(machine.Object, "status", "failureReason")
+		failureMessage, _, _ := unstructured.NestedString(machine.Object, "status", "failureMessage")
+		if phase != "Failed" && failureReason == "" && failureMessage == "" {
+			continue
+		}
+
+		nodeName, _, _ := unstructured.NestedString(machine.Object, "status", "nodeRef", "name")
+		machinesStatus.FailedMachines = append(machinesStatus.FailedMachines, hmc.FailedMachine{
+			Name:           machine.GetName(),
+			ProviderID:     providerID,
+			NodeName:       nodeName,
+			Phase:          phase,
+			FailureReason:  failureReason,
+			FailureMessage: failureMessage,
+		})
+	}
+
+	slices.Sort(providerIDs)
+	machinesStatus.ProviderIDs = slices.Compact(providerIDs)
+	machinesStatus.Failed = int32(len(machinesStatus.FailedMachines))
+	machinesStatus.Summary = fmt.Sprintf("%d/%d", machinesStatus.Ready, machinesStatus.Desired)
+
+	return machinesStatus
+}
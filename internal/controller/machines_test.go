@@ -0,0 +1,89 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
+)
+
+var _ = Describe("ClusterDeployment machines inventory", func() {
+	newObject := func(kind, name string, fields map[string]any) unstructured.Unstructured {
+		obj := unstructured.Unstructured{Object: fields}
+		obj.SetKind(kind)
+		obj.SetName(name)
+		return obj
+	}
+
+	It("should summarize the CAPI objects", func() {
+		controlPlanes := []unstructured.Unstructured{
+			newObject("K0sControlPlane", "cp", map[string]any{
+				"spec":   map[string]any{"replicas": int64(3)},
+				"status": map[string]any{"readyReplicas": int64(2)},
+			}),
+		}
+		machineDeployments := []unstructured.Unstructured{
+			newObject("MachineDeployment", "md", map[string]any{
+				"spec":   map[string]any{"replicas": int64(2)},
+				"status": map[string]any{"readyReplicas": int64(2)},
+			}),
+		}
+		machinePools := []unstructured.Unstructured{
+			newObject("MachinePool", "mp", map[string]any{
+				"spec": map[string]any{"replicas": int64(1), "providerIDList": []any{"azure:///mp-0"}},
+			}),
+		}
+		machines := []unstructured.Unstructured{
+			newObject("Machine", "cp-0", map[string]any{
+				"spec":   map[string]any{"providerID": "aws:///cp-0"},
+				"status": map[string]any{"phase": "Running", "nodeRef": map[string]any{"name": "node-0"}},
+			}),
+			newObject("Machine", "cp-1", map[string]any{
+				"spec": map[string]any{"providerID": "aws:///cp-1"},
+				"status": map[string]any{
+					"phase":          "Failed",
+					"failureReason":  "CreateError",
+					"failureMessage": "instance quota exceeded",
+				},
+			}),
+		}
+
+		machinesStatus := summarizeMachines(controlPlanes, machineDeployments, machinePools, machines)
+		Expect(machinesStatus.ControlPlane).To(Equal(&hmc.ReplicasStatus{Kind: "K0sControlPlane", Name: "cp", Summary: "2/3", Desired: 3, Ready: 2}))
+		Expect(machinesStatus.MachineDeployments).To(ConsistOf(hmc.ReplicasStatus{Kind: "MachineDeployment", Name: "md", Summary: "2/2", Desired: 2, Ready: 2}))
+		Expect(machinesStatus.MachinePools).To(ConsistOf(hmc.ReplicasStatus{Kind: "MachinePool", Name: "mp", Summary: "0/1", Desired: 1}))
+		Expect(machinesStatus.ProviderIDs).To(Equal([]string{"aws:///cp-0", "aws:///cp-1", "azure:///mp-0"}))
+		Expect(machinesStatus.FailedMachines).To(ConsistOf(hmc.FailedMachine{
+			Name:           "cp-1",
+			ProviderID:     "aws:///cp-1",
+			Phase:          "Failed",
+			FailureReason:  "CreateError",
+			FailureMessage: "instance quota exceeded",
+		}))
+		Expect(machinesStatus.Failed).To(Equal(int32(1)))
+		Expect(machinesStatus.Summary).To(Equal("4/6"))
+	})
+
+	It("should omit the control planes without replicas", func() {
+		machinesStatus := summarizeMachines([]unstructured.Unstructured{
+			newObject("AWSManagedControlPlane", "eks", map[string]any{"spec": map[string]any{}}),
+		}, nil, nil, nil)
+		Expect(machinesStatus.ControlPlane).To(BeNil())
+		Expect(machinesStatus.Summary).To(Equal("0/0"))
+	})
+})
@@ -0,0 +1,193 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	hcv2 "github.com/fluxcd/helm-controller/api/v2"
+	sveltosv1beta1 "github.com/projectsveltos/addon-controller/api/v1beta1"
+	"github.com/robfig/cron/v3"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
+	"github.com/K0rdent/kcm/internal/helm"
+	"github.com/K0rdent/kcm/internal/sveltos"
+)
+
+// maintenanceWindow is the state of the MaintenanceWindow referenced by an object at the time of its reconciliation.
+type maintenanceWindow struct {
+	// next is the start of the next window.
+	next time.Time
+	// pending is the list of the changes postponed until the next window.
+	pending []string
+	// open indicates the changes can be applied right away.
+	open bool
+}
+
+// getMaintenanceWindow evaluates the MaintenanceWindow with the given name at the current time.
+// The window is always open if no name is given.
+func getMaintenanceWindow(ctx context.Context, cl client.Client, name string) (*maintenanceWindow, error) {
+	if name == "" {
+		return &maintenanceWindow{open: true}, nil
+	}
+
+	mw := new(hmc.MaintenanceWindow)
+	if err := cl.Get(ctx, client.ObjectKey{Name: name}, mw); err != nil {
+		return nil, fmt.Errorf("failed to get MaintenanceWindow %s: %w", name, err)
+	}
+
+	loc := time.UTC
+	if mw.Spec.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(mw.Spec.Timezone); err != nil {
+			return nil, fmt.Errorf("invalid timezone of MaintenanceWindow %s: %w", name, err)
+		}
+	}
+
+	schedule, err := cron.ParseStandard(mw.Spec.Schedule)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule of MaintenanceWindow %s: %w", name, err)
+	}
+
+	open, start := windowAt(schedule, time.Now().In(loc), mw.Spec.Duration.Duration)
+	return &maintenanceWindow{open: open, next: start}, nil
+}
+
+// windowAt reports whether the given time falls into a window of the given duration starting at a time
+// activating the given schedule. Returns the start of that window if so, the start of the next window otherwise.
+func windowAt(schedule cron.Schedule, t time.Time, d time.Duration) (open bool, start time.Time) {
+	// the earliest start after the beginning of the window ending at t is
+	// either the start of the current window or the start of the next one
+	start = schedule.Next(t.Add(-d))
+	return !start.IsZero() && !start.After(t), start
+}
+
+// postpone records the given changes as pending until the next window, sets the PendingMaintenance
+// condition accordingly and returns the result requeueing the object at the start of the window.
+func (w *maintenanceWindow) postpone(conditions *[]metav1.Condition, changes string) ctrl.Result {
+	w.pending = append(w.pending, changes)
+
+	message := strings.Join(w.pending, " and ") + " are pending until the maintenance window starting at " + w.next.Format(time.RFC3339)
+	if w.next.IsZero() {
+		message = strings.Join(w.pending, " and ") + " are pending, no maintenance window is scheduled"
+	}
+
+	apimeta.SetStatusCondition(conditions, metav1.Condition{
+		Type:    hmc.PendingMaintenanceCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  hmc.ProgressingReason,
+		Message: message,
+	})
+
+	if w.next.IsZero() {
+		return ctrl.Result{}
+	}
+
+	return ctrl.Result{RequeueAfter: time.Until(w.next)}
+}
+
+// servicesChangesPostponed reports whether the given existing Sveltos Profile or ClusterProfile has to be left intact
+// since its Helm charts differ from the ones defined by the given options while the maintenance window is closed.
+// The spec must point to the spec of the given profile.
+func servicesChangesPostponed(ctx context.Context, cl client.Client, window *maintenanceWindow, profile client.Object, spec *sveltosv1beta1.Spec, opts []sveltos.HelmChartOpts) (bool, error) {
+	if window.open {
+		return false, nil
+	}
+
+	if err := cl.Get(ctx, client.ObjectKeyFromObject(profile), profile); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("failed to get Sveltos profile %s: %w", client.ObjectKeyFromObject(profile), err)
+	}
+
+	return sveltos.HelmChartsChanged(spec, opts), nil
+}
+
+// setMaintenanceWindowFailed sets the PendingMaintenance condition reporting the MaintenanceWindow could not be evaluated.
+func setMaintenanceWindowFailed(conditions *[]metav1.Condition, err error) {
+	apimeta.SetStatusCondition(conditions, metav1.Condition{
+		Type:    hmc.PendingMaintenanceCondition,
+		Status:  metav1.ConditionFalse,
+		Reason:  hmc.FailedReason,
+		Message: err.Error(),
+	})
+}
+
+// getPostponedHelmRelease returns the existing HelmRelease of the given ClusterDeployment and whether either its chart
+// or values differ from the given ones while the maintenance window is closed, i.e. the changes have to be postponed.
+// Only the suspension of the HelmRelease is synced with the given options in this case.
+func (r *ClusterDeploymentReconciler) getPostponedHelmRelease(ctx context.Context, clusterDeployment *hmc.ClusterDeployment, window *maintenanceWindow, opts helm.ReconcileHelmReleaseOpts) (_ *hcv2.HelmRelease, postponed bool, _ error) {
+	if window.open {
+		return nil, false, nil
+	}
+
+	hr := new(hcv2.HelmRelease)
+	if err := r.Get(ctx, client.ObjectKeyFromObject(clusterDeployment), hr); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, false, nil
+		}
+
+		return nil, false, fmt.Errorf("failed to get HelmRelease %s: %w", client.ObjectKeyFromObject(clusterDeployment), err)
+	}
+
+	valuesChanged, err := helmValuesChanged(hr.Spec.Values, opts.Values)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !valuesChanged && hr.Spec.ChartRef != nil && opts.ChartRef != nil && *hr.Spec.ChartRef == *opts.ChartRef {
+		return nil, false, nil
+	}
+
+	if hr.Spec.Suspend != opts.Suspend {
+		original := hr.DeepCopy()
+		hr.Spec.Suspend = opts.Suspend
+		if err := r.Patch(ctx, hr, client.MergeFrom(original)); err != nil {
+			return nil, false, fmt.Errorf("failed to patch HelmRelease %s: %w", client.ObjectKeyFromObject(hr), err)
+		}
+	}
+
+	return hr, true, nil
+}
+
+// helmValuesChanged reports whether the given Helm values differ semantically.
+func helmValuesChanged(current, desired *apiextensionsv1.JSON) (bool, error) {
+	var currentValues, desiredValues map[string]any
+	if current != nil {
+		if err := json.Unmarshal(current.Raw, &currentValues); err != nil {
+			return false, fmt.Errorf("error unmarshalling values: %w", err)
+		}
+	}
+	if desired != nil {
+		if err := json.Unmarshal(desired.Raw, &desiredValues); err != nil {
+			return false, fmt.Errorf("error unmarshalling values: %w", err)
+		}
+	}
+
+	return !equality.Semantic.DeepEqual(currentValues, desiredValues), nil
+}
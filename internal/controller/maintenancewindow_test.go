@@ -0,0 +1,101 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/robfig/cron/v3"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
+)
+
+var _ = Describe("MaintenanceWindow", func() {
+	const maintenanceWindowName = "test-maintenance-window"
+
+	ctx := context.Background()
+
+	mw := &hmc.MaintenanceWindow{}
+
+	BeforeEach(func() {
+		By("creating the MaintenanceWindow")
+		// the window opening in an hour is closed at the moment
+		start := time.Now().UTC().Add(time.Hour)
+		mw = &hmc.MaintenanceWindow{
+			ObjectMeta: metav1.ObjectMeta{Name: maintenanceWindowName},
+			Spec: hmc.MaintenanceWindowSpec{
+				Schedule: start.Format("4 15 * * *"),
+				Duration: metav1.Duration{Duration: 30 * time.Minute},
+			},
+		}
+		Expect(k8sClient.Create(ctx, mw)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		By("Cleanup the MaintenanceWindow")
+		Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, mw))).To(Succeed())
+	})
+
+	It("should postpone the changes until the next window", func() {
+		window, err := getMaintenanceWindow(ctx, k8sClient, maintenanceWindowName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(window.open).To(BeFalse())
+		Expect(window.next).To(BeTemporally("~", time.Now().Add(time.Hour), time.Minute))
+
+		var conditions []metav1.Condition
+		result := window.postpone(&conditions, "Template and config changes")
+		Expect(result.RequeueAfter).To(BeNumerically("~", time.Hour, time.Minute))
+		Expect(apimeta.IsStatusConditionTrue(conditions, hmc.PendingMaintenanceCondition)).To(BeTrue())
+	})
+
+	It("should evaluate the window around the schedule", func() {
+		schedule, err := cron.ParseStandard("0 22 * * sat")
+		Expect(err).NotTo(HaveOccurred())
+
+		start := time.Date(2024, time.December, 28, 22, 0, 0, 0, time.UTC)
+
+		open, next := windowAt(schedule, start.Add(3*time.Hour), 4*time.Hour)
+		Expect(open).To(BeTrue())
+		Expect(next).To(BeTemporally("==", start))
+
+		open, next = windowAt(schedule, start.Add(4*time.Hour), 4*time.Hour)
+		Expect(open).To(BeFalse())
+		Expect(next).To(BeTemporally("==", start.AddDate(0, 0, 7)))
+
+		open, next = windowAt(schedule, start.Add(-time.Minute), 4*time.Hour)
+		Expect(open).To(BeFalse())
+		Expect(next).To(BeTemporally("==", start))
+	})
+
+	It("should always be open if no MaintenanceWindow is referenced", func() {
+		window, err := getMaintenanceWindow(ctx, k8sClient, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(window.open).To(BeTrue())
+	})
+
+	It("should fail on the invalid timezone", func() {
+		mw.Spec.Timezone = "Mars/Olympus_Mons"
+		Expect(k8sClient.Update(ctx, mw)).To(Succeed())
+
+		_, err := getMaintenanceWindow(ctx, k8sClient, maintenanceWindowName)
+		Expect(err).To(MatchError(ContainSubstring("invalid timezone")))
+	})
+})
@@ -26,9 +26,11 @@ import (
 	fluxmeta "github.com/fluxcd/pkg/apis/meta"
 	fluxconditions "github.com/fluxcd/pkg/runtime/conditions"
 	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
 	"helm.sh/helm/v3/pkg/chartutil"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -39,6 +41,8 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	hmc "github.com/K0rdent/kcm/api/v1alpha1"
 	"github.com/K0rdent/kcm/internal/certmanager"
@@ -55,6 +59,9 @@ type ManagementReconciler struct {
 	DynamicClient          *dynamic.DynamicClient
 	SystemNamespace        string
 	CreateAccessManagement bool
+	// VeleroControllers sets up the controllers depending on Velero once it is installed,
+	// Velero is assumed to be installed if nil
+	VeleroControllers *VeleroControllers
 }
 
 func (r *ManagementReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -101,6 +108,49 @@ func (r *ManagementReconciler) Update(ctx context.Context, management *hmc.Manag
 		return ctrl.Result{}, err
 	}
 
+	if err := r.ensureBackup(ctx, management); err != nil {
+		l.Error(err, "failed to ensure scheduled Backup")
+		return ctrl.Result{}, err
+	}
+
+	// the velero objects can be managed only once Velero is installed along with the enabled backup
+	veleroInstalled := true
+	if management.Spec.Backup.Enabled && r.VeleroControllers != nil {
+		installed, err := r.VeleroControllers.Setup()
+		if err != nil {
+			l.Error(err, "failed to set up the backup controllers")
+			return ctrl.Result{}, err
+		}
+		veleroInstalled = installed
+	}
+
+	if !veleroInstalled {
+		l.Info("Velero is not installed yet, postponing the reconciliation of the backup storage locations")
+		apimeta.SetStatusCondition(management.GetConditions(), metav1.Condition{
+			Type:               hmc.BackupStorageAvailableCondition,
+			Status:             metav1.ConditionFalse,
+			Reason:             hmc.ProgressingReason,
+			Message:            "Waiting for Velero to be installed",
+			ObservedGeneration: management.Generation,
+		})
+	} else if err := r.reconcileStorageLocations(ctx, management); err != nil {
+		l.Error(err, "failed to reconcile backup storage locations")
+		return ctrl.Result{}, err
+	}
+
+	upgradeAllowed, err := r.ensurePreUpgradeBackup(ctx, management)
+	if err != nil {
+		l.Error(err, "failed to ensure pre-upgrade Backup")
+		return ctrl.Result{}, err
+	}
+	if !upgradeAllowed {
+		l.Info("Upgrade to the new Release is blocked by the pre-upgrade Backup", "release", management.Spec.Release, "backup", management.Status.PreUpgradeBackupName)
+		if err := r.Status().Update(ctx, management); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to update status for Management %s: %w", management.Name, err)
+		}
+		return ctrl.Result{RequeueAfter: DefaultRequeueInterval}, nil
+	}
+
 	if err := r.enableAdditionalComponents(ctx, management); err != nil { // TODO (zerospiel): i wonder, do we need to reflect these changes and changes from the `wrappedComponents` in the spec?
 		l.Error(err, "failed to enable additional HMC components")
 		return ctrl.Result{}, err
@@ -119,62 +169,58 @@ func (r *ManagementReconciler) Update(ctx context.Context, management *hmc.Manag
 			providers:              hmc.Providers{"infrastructure-internal"},
 			components:             make(map[string]hmc.ComponentStatus),
 			compatibilityContracts: make(map[string]hmc.CompatibilityContracts),
+			infrastructureProviders: map[string]hmc.InfrastructureProvider{
+				// the adopted clusters are not backed by any provider chart
+				"infrastructure-internal": {
+					ClusterIdentityKinds:   []string{"Secret"},
+					CredentialsPropagation: hmc.CredentialsPropagationNone,
+				},
+			},
 		}
 
 		requeue bool
 	)
 
+	rollout := newComponentRollout(management)
 	for _, component := range components {
 		l.V(1).Info("reconciling components", "component", component)
-		template := new(hmc.ProviderTemplate)
-		if err := r.Get(ctx, client.ObjectKey{Name: component.Template}, template); err != nil {
-			errMsg := fmt.Sprintf("Failed to get ProviderTemplate %s: %s", component.Template, err)
-			updateComponentsStatus(statusAccumulator, component, nil, errMsg)
-			errs = errors.Join(errs, errors.New(errMsg))
-
+		if rollout != nil && !rollout.start(&component) {
+			if err := r.keepComponentStatus(ctx, management, component, statusAccumulator); err != nil {
+				errs = errors.Join(errs, err)
+			}
 			continue
 		}
 
-		if !template.Status.Valid {
-			errMsg := fmt.Sprintf("Template %s is not marked as valid", component.Template)
-			updateComponentsStatus(statusAccumulator, component, nil, errMsg)
-			errs = errors.Join(errs, errors.New(errMsg))
+		componentRequeue, err := r.reconcileComponent(ctx, component, statusAccumulator)
+		errs = errors.Join(errs, err)
+		requeue = requeue || componentRequeue
 
+		if rollout == nil {
 			continue
 		}
 
-		hrReconcileOpts := helm.ReconcileHelmReleaseOpts{
-			Values:          component.Config,
-			ChartRef:        template.Status.ChartRef,
-			DependsOn:       component.dependsOn,
-			TargetNamespace: component.targetNamespace,
-			CreateNamespace: component.createNamespace,
-		}
-		if template.Spec.Helm.ChartSpec != nil {
-			hrReconcileOpts.ReconcileInterval = &template.Spec.Helm.ChartSpec.Interval.Duration
-		}
-
-		if _, _, err := helm.ReconcileHelmRelease(ctx, r.Client, component.helmReleaseName, r.SystemNamespace, hrReconcileOpts); err != nil {
-			errMsg := fmt.Sprintf("Failed to reconcile HelmRelease %s/%s: %s", r.SystemNamespace, component.helmReleaseName, err)
-			updateComponentsStatus(statusAccumulator, component, nil, errMsg)
-			errs = errors.Join(errs, errors.New(errMsg))
+		if rollout.timedOut(component, statusAccumulator) {
+			component = rollout.revert(component)
+			l.Info("Component has not become healthy within the rollout timeout, reverting it to the previous template", "component", component.helmReleaseName, "template", component.Template)
 
-			continue
-		}
-
-		if err := r.checkProviderStatus(ctx, component); err != nil {
-			l.Info("Provider is not yet ready", "template", component.Template, "err", err)
-			requeue = true
-			updateComponentsStatus(statusAccumulator, component, nil, err.Error())
-			continue
+			componentRequeue, err := r.reconcileComponent(ctx, component, statusAccumulator)
+			errs = errors.Join(errs, err)
+			requeue = requeue || componentRequeue
 		}
+		rollout.record(component, statusAccumulator)
+	}
 
-		updateComponentsStatus(statusAccumulator, component, template, "")
+	if rollout != nil {
+		requeue = requeue || rollout.inProgress()
+		rollout.setCondition(management)
+	} else {
+		apimeta.RemoveStatusCondition(management.GetConditions(), hmc.RolloutInProgressCondition)
 	}
 
 	management.Status.AvailableProviders = statusAccumulator.providers
 	management.Status.CAPIContracts = statusAccumulator.compatibilityContracts
 	management.Status.Components = statusAccumulator.components
+	management.Status.InfrastructureProviders = statusAccumulator.infrastructureProviders
 	management.Status.ObservedGeneration = management.Generation
 	management.Status.Release = management.Spec.Release
 
@@ -186,13 +232,63 @@ func (r *ManagementReconciler) Update(ctx context.Context, management *hmc.Manag
 		l.Error(errs, "Multiple errors during Management reconciliation")
 		return ctrl.Result{}, errs
 	}
-	if requeue {
+	if requeue || !veleroInstalled {
 		return ctrl.Result{RequeueAfter: DefaultRequeueInterval}, nil
 	}
 
 	return ctrl.Result{}, nil
 }
 
+// reconcileComponent reconciles the HelmRelease of the given component and collects its status.
+// Returns true if the component is not yet ready and has to be checked again.
+func (r *ManagementReconciler) reconcileComponent(ctx context.Context, component component, statusAccumulator *mgmtStatusAccumulator) (bool, error) {
+	l := ctrl.LoggerFrom(ctx)
+
+	template := new(hmc.ProviderTemplate)
+	if err := r.Get(ctx, client.ObjectKey{Name: component.Template}, template); err != nil {
+		errMsg := fmt.Sprintf("Failed to get ProviderTemplate %s: %s", component.Template, err)
+		updateComponentsStatus(statusAccumulator, component, nil, errMsg)
+		return false, errors.New(errMsg)
+	}
+
+	if !template.Status.Valid {
+		errMsg := fmt.Sprintf("Template %s is not marked as valid", component.Template)
+		updateComponentsStatus(statusAccumulator, component, nil, errMsg)
+		return false, errors.New(errMsg)
+	}
+
+	// the providers are installed with the CAPI operator if their templates declare any
+	if len(template.Status.Providers) > 0 {
+		component.isCAPIProvider = true
+	}
+
+	hrReconcileOpts := helm.ReconcileHelmReleaseOpts{
+		Values:          component.Config,
+		ChartRef:        template.Status.ChartRef,
+		DependsOn:       component.dependsOn,
+		TargetNamespace: component.targetNamespace,
+		CreateNamespace: component.createNamespace,
+	}
+	if template.Spec.Helm.ChartSpec != nil {
+		hrReconcileOpts.ReconcileInterval = &template.Spec.Helm.ChartSpec.Interval.Duration
+	}
+
+	if _, _, err := helm.ReconcileHelmRelease(ctx, r.Client, component.helmReleaseName, r.SystemNamespace, hrReconcileOpts); err != nil {
+		errMsg := fmt.Sprintf("Failed to reconcile HelmRelease %s/%s: %s", r.SystemNamespace, component.helmReleaseName, err)
+		updateComponentsStatus(statusAccumulator, component, nil, errMsg)
+		return false, errors.New(errMsg)
+	}
+
+	if err := r.checkProviderStatus(ctx, component); err != nil {
+		l.Info("Provider is not yet ready", "template", component.Template, "err", err)
+		updateComponentsStatus(statusAccumulator, component, nil, err.Error())
+		return true, nil
+	}
+
+	updateComponentsStatus(statusAccumulator, component, template, "")
+	return false, nil
+}
+
 func (r *ManagementReconciler) cleanupRemovedComponents(ctx context.Context, management *hmc.Management) error {
 	var (
 		errs error
@@ -270,6 +366,115 @@ func (r *ManagementReconciler) ensureAccessManagement(ctx context.Context, mgmt
 	return nil
 }
 
+// ensureBackup creates the scheduled management Backup if the backup feature is enabled
+// and removes it otherwise.
+func (r *ManagementReconciler) ensureBackup(ctx context.Context, mgmt *hmc.Management) error {
+	l := ctrl.LoggerFrom(ctx)
+
+	backup := &hmc.Backup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: mgmt.Name,
+		},
+	}
+
+	if !mgmt.Spec.Backup.Enabled {
+		mgmt.Status.BackupName = ""
+		if err := r.Client.Delete(ctx, backup); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete %s Backup object: %w", backup.Name, err)
+		}
+		return nil
+	}
+
+	err := r.Get(ctx, client.ObjectKeyFromObject(backup), backup)
+	if apierrors.IsNotFound(err) {
+		backup.OwnerReferences = []metav1.OwnerReference{
+			{
+				APIVersion: hmc.GroupVersion.String(),
+				Kind:       hmc.ManagementKind,
+				Name:       mgmt.Name,
+				UID:        mgmt.UID,
+			},
+		}
+		if err := r.Create(ctx, backup); err != nil {
+			return fmt.Errorf("failed to create %s Backup object: %w", backup.Name, err)
+		}
+		l.Info("Successfully created scheduled Backup object", "backup", backup.Name)
+	} else if err != nil {
+		return fmt.Errorf("failed to get %s Backup object: %w", backup.Name, err)
+	}
+
+	mgmt.Status.BackupName = backup.Name
+	return nil
+}
+
+// ensurePreUpgradeBackup creates a oneshot Backup if the Management is being upgraded to a new Release
+// and the pre-upgrade backup is enabled. Returns true if the components of the new Release may be rolled out.
+func (r *ManagementReconciler) ensurePreUpgradeBackup(ctx context.Context, mgmt *hmc.Management) (bool, error) {
+	if !mgmt.Spec.Backup.Enabled || !mgmt.Spec.Backup.PreUpgrade ||
+		mgmt.Status.Release == "" || mgmt.Status.Release == mgmt.Spec.Release {
+		return true, nil
+	}
+
+	l := ctrl.LoggerFrom(ctx)
+
+	backup := &hmc.Backup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: mgmt.Name + "-pre-upgrade-" + mgmt.Spec.Release,
+		},
+	}
+
+	err := r.Get(ctx, client.ObjectKeyFromObject(backup), backup)
+	if apierrors.IsNotFound(err) {
+		backup.Spec.Oneshot = true
+		backup.OwnerReferences = []metav1.OwnerReference{
+			{
+				APIVersion: hmc.GroupVersion.String(),
+				Kind:       hmc.ManagementKind,
+				Name:       mgmt.Name,
+				UID:        mgmt.UID,
+			},
+		}
+		if err := r.Create(ctx, backup); err != nil {
+			return false, fmt.Errorf("failed to create %s Backup object: %w", backup.Name, err)
+		}
+		l.Info("Successfully created pre-upgrade Backup object", "backup", backup.Name, "release", mgmt.Spec.Release)
+	} else if err != nil {
+		return false, fmt.Errorf("failed to get %s Backup object: %w", backup.Name, err)
+	}
+
+	mgmt.Status.PreUpgradeBackupName = backup.Name
+
+	var phase velerov1.BackupPhase
+	if backup.Status.LastBackup != nil {
+		phase = backup.Status.LastBackup.Phase
+	}
+
+	condition := metav1.Condition{
+		Type:               hmc.PreUpgradeBackupCondition,
+		ObservedGeneration: mgmt.Generation,
+	}
+
+	switch phase {
+	case velerov1.BackupPhaseCompleted:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = hmc.SucceededReason
+		condition.Message = fmt.Sprintf("Backup %s has been taken before the upgrade to the Release %s", backup.Name, mgmt.Spec.Release)
+	case velerov1.BackupPhaseFailed, velerov1.BackupPhasePartiallyFailed, velerov1.BackupPhaseFailedValidation:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = hmc.FailedReason
+		condition.Message = fmt.Sprintf("Backup %s is %s, the upgrade to the Release %s is blocked. "+
+			"Delete the Backup to retry or disable the pre-upgrade backup", backup.Name, phase, mgmt.Spec.Release)
+	default:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = hmc.ProgressingReason
+		condition.Message = fmt.Sprintf("Waiting for Backup %s to be completed before the upgrade to the Release %s", backup.Name, mgmt.Spec.Release)
+	}
+
+	apimeta.SetStatusCondition(mgmt.GetConditions(), condition)
+
+	return condition.Status == metav1.ConditionTrue, nil
+}
+
 // checkProviderStatus checks the status of a provider associated with a given
 // ProviderTemplate name. Since there's no way to determine resource Kind from
 // the given template iterate over all possible provider types.
@@ -298,11 +503,18 @@ func (r *ManagementReconciler) checkProviderStatus(ctx context.Context, componen
 	}
 	var errs error
 	var providerFound bool
+	providerNamespace := r.SystemNamespace
+	if component.targetNamespace != "" {
+		providerNamespace = component.targetNamespace
+	}
+
 	for _, resourceType := range []string{
 		"coreproviders",
 		"infrastructureproviders",
 		"controlplaneproviders",
 		"bootstrapproviders",
+		"ipamproviders",
+		"addonproviders",
 	} {
 		gvr := schema.GroupVersionResource{
 			Group:    "operator.cluster.x-k8s.io",
@@ -310,7 +522,7 @@ func (r *ManagementReconciler) checkProviderStatus(ctx context.Context, componen
 			Resource: resourceType,
 		}
 
-		resourceConditions, err := status.GetResourceConditions(ctx, r.SystemNamespace, r.DynamicClient, gvr,
+		resourceConditions, err := status.GetResourceConditions(ctx, providerNamespace, r.DynamicClient, gvr,
 			labels.SelectorFromSet(map[string]string{hmc.FluxHelmChartNameKey: hr.Status.History.Latest().Name}).String(),
 		)
 		if err != nil {
@@ -478,32 +690,75 @@ func getWrappedComponents(ctx context.Context, cl client.Client, mgmt *hmc.Manag
 	}
 	components = append(components, capiComp)
 
-	const sveltosTargetNamespace = "projectsveltos"
-
 	for _, p := range mgmt.Spec.Providers {
 		c := component{
 			Component: p.Component, helmReleaseName: p.Name,
-			dependsOn: []fluxmeta.NamespacedObjectReference{{Name: hmc.CoreCAPIName}}, isCAPIProvider: true,
+			targetNamespace: p.TargetNamespace, createNamespace: p.CreateNamespace,
+			dependsOn: []fluxmeta.NamespacedObjectReference{{Name: hmc.CoreCAPIName}},
+		}
+		for _, dependency := range p.DependsOn {
+			// the core CAPI dependency is always set, the listed dependencies may repeat it
+			if slices.ContainsFunc(c.dependsOn, func(ref fluxmeta.NamespacedObjectReference) bool { return ref.Name == dependency }) {
+				continue
+			}
+			c.dependsOn = append(c.dependsOn, fluxmeta.NamespacedObjectReference{Name: dependency})
 		}
 		// Try to find corresponding provider in the Release object
 		if c.Template == "" {
 			c.Template = release.ProviderTemplate(p.Name)
 		}
 
-		if p.Name == hmc.ProviderSveltosName {
-			c.targetNamespace = sveltosTargetNamespace
+		// the Managements created before the providers could define their target namespace
+		// still expect Sveltos to be installed to its own namespace
+		if p.Name == hmc.ProviderSveltosName && p.TargetNamespace == "" {
+			c.targetNamespace = hmc.ProviderSveltosTargetNamespace
 			c.createNamespace = true
-			c.isCAPIProvider = false
 		}
 
 		components = append(components, c)
 	}
 
-	return components, nil
+	return sortByDependencies(components), nil
+}
+
+// sortByDependencies orders the given components so each of them follows the components it depends on,
+// keeping their original order otherwise. This way the ordered rollout never updates a component
+// before its dependencies are healthy, regardless of the order the providers are listed in the Management.
+func sortByDependencies(components []component) []component {
+	indexes := make(map[string]int, len(components))
+	for i, c := range components {
+		indexes[c.helmReleaseName] = i
+	}
+
+	sorted := make([]component, 0, len(components))
+	visited := make([]bool, len(components))
+
+	// the dependencies cannot form a cycle as it is rejected by the webhook,
+	// marking the component before visiting its dependencies only guards against that
+	var visit func(i int)
+	visit = func(i int) {
+		if visited[i] {
+			return
+		}
+		visited[i] = true
+
+		for _, dependency := range components[i].dependsOn {
+			if j, ok := indexes[dependency.Name]; ok {
+				visit(j)
+			}
+		}
+		sorted = append(sorted, components[i])
+	}
+
+	for i := range components {
+		visit(i)
+	}
+
+	return sorted
 }
 
 // enableAdditionalComponents enables the admission controller and cluster api operator
-// once the cert manager is ready
+// once the cert manager is ready, and toggles velero according to the backup settings
 func (r *ManagementReconciler) enableAdditionalComponents(ctx context.Context, mgmt *hmc.Management) error {
 	l := ctrl.LoggerFrom(ctx)
 
@@ -526,6 +781,16 @@ func (r *ManagementReconciler) enableAdditionalComponents(ctx context.Context, m
 		admissionWebhookValues = v
 	}
 
+	veleroValues := make(map[string]any)
+	if config["velero"] != nil {
+		v, ok := config["velero"].(map[string]any)
+		if !ok {
+			return fmt.Errorf("failed to cast 'velero' (type %T) to map[string]any", config["velero"])
+		}
+
+		veleroValues = v
+	}
+
 	capiOperatorValues := make(map[string]any)
 	if config["cluster-api-operator"] != nil {
 		v, ok := config["cluster-api-operator"].(map[string]any)
@@ -559,6 +824,13 @@ func (r *ManagementReconciler) enableAdditionalComponents(ctx context.Context, m
 	}
 	config["cluster-api-operator"] = capiOperatorValues
 
+	// Velero is required only for the backup feature, unless explicitly enabled or
+	// disabled in the config, e.g. to support installation with existing Velero
+	if _, ok := veleroValues["enabled"].(bool); !ok {
+		veleroValues["enabled"] = mgmt.Spec.Backup.Enabled
+	}
+	config["velero"] = veleroValues
+
 	updatedConfig, err := json.Marshal(config)
 	if err != nil {
 		return fmt.Errorf("failed to marshal HMC config: %w", err)
@@ -570,9 +842,10 @@ func (r *ManagementReconciler) enableAdditionalComponents(ctx context.Context, m
 }
 
 type mgmtStatusAccumulator struct {
-	components             map[string]hmc.ComponentStatus
-	compatibilityContracts map[string]hmc.CompatibilityContracts
-	providers              hmc.Providers
+	components              map[string]hmc.ComponentStatus
+	compatibilityContracts  map[string]hmc.CompatibilityContracts
+	infrastructureProviders map[string]hmc.InfrastructureProvider
+	providers               hmc.Providers
 }
 
 func updateComponentsStatus(
@@ -598,13 +871,29 @@ func updateComponentsStatus(
 
 		for _, v := range template.Status.Providers {
 			stAcc.compatibilityContracts[v] = template.Status.CAPIContracts
+			if template.Status.Infrastructure != nil && strings.HasPrefix(v, "infrastructure-") && stAcc.infrastructureProviders != nil {
+				stAcc.infrastructureProviders[v] = *template.Status.Infrastructure.DeepCopy()
+			}
 		}
 	}
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ManagementReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	c, err := ctrl.NewControllerManagedBy(mgr).
 		For(&hmc.Management{}).
-		Complete(r)
+		Build(r)
+	if err != nil {
+		return err
+	}
+
+	if r.VeleroControllers == nil {
+		return c.Watch(source.Kind(mgr.GetCache(), &velerov1.BackupStorageLocation{},
+			handler.TypedEnqueueRequestForOwner[*velerov1.BackupStorageLocation](mgr.GetScheme(), mgr.GetRESTMapper(), &hmc.Management{}, handler.OnlyControllerOwner()),
+		))
+	}
+
+	r.VeleroControllers.management = c
+	_, err = r.VeleroControllers.Setup()
+	return err
 }
@@ -16,7 +16,9 @@ package controller
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"slices"
 	"time"
 
 	helmcontrollerv2 "github.com/fluxcd/helm-controller/api/v2"
@@ -25,8 +27,11 @@ import (
 	sourcev1 "github.com/fluxcd/source-controller/api/v1"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
 	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	capioperator "sigs.k8s.io/cluster-api-operator/api/v1alpha2"
@@ -415,5 +420,212 @@ var _ = Describe("Management Controller", func() {
 				return apierrors.IsNotFound(k8sClient.Get(ctx, client.ObjectKeyFromObject(coreProvider), &capioperator.CoreProvider{}))
 			}).WithTimeout(timeout).WithPolling(interval).Should(BeTrue())
 		})
+
+		It("should block the upgrade until the pre-upgrade Backup succeeds", func() {
+			const (
+				mgmtName       = "test-management-name-pre-upgrade"
+				oldReleaseName = "test-release-name-old"
+				newReleaseName = "test-release-name-new"
+			)
+
+			By("Creating the hmc-system namespace")
+			Expect(client.IgnoreAlreadyExists(k8sClient.Create(ctx, &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.DefaultSystemNamespace,
+				},
+			}))).To(Succeed())
+
+			By("Creating a Management object being upgraded to the new Release")
+			mgmt := &hmcmirantiscomv1alpha1.Management{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       mgmtName,
+					Finalizers: []string{hmcmirantiscomv1alpha1.ManagementFinalizer},
+				},
+				Spec: hmcmirantiscomv1alpha1.ManagementSpec{
+					Release: newReleaseName,
+					Backup: hmcmirantiscomv1alpha1.ManagementBackup{
+						Enabled:    true,
+						PreUpgrade: true,
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, mgmt)).To(Succeed())
+			mgmt.Status.Release = oldReleaseName
+			Expect(k8sClient.Status().Update(ctx, mgmt)).To(Succeed())
+
+			By("Reconciling the Management object")
+			controllerReconciler := &ManagementReconciler{
+				Client:          k8sClient,
+				Scheme:          k8sClient.Scheme(),
+				SystemNamespace: utils.DefaultSystemNamespace,
+				DynamicClient:   dynamicClient,
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: client.ObjectKeyFromObject(mgmt),
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking the oneshot Backup has been created and the upgrade is blocked")
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(mgmt), mgmt)).To(Succeed())
+			Expect(mgmt.Status.PreUpgradeBackupName).To(Equal(mgmtName + "-pre-upgrade-" + newReleaseName))
+			Expect(mgmt.Status.Release).To(Equal(oldReleaseName))
+
+			backup := &hmcmirantiscomv1alpha1.Backup{}
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: mgmt.Status.PreUpgradeBackupName}, backup)).To(Succeed())
+			Expect(backup.Spec.Oneshot).To(BeTrue())
+
+			cond := apimeta.FindStatusCondition(mgmt.Status.Conditions, hmcmirantiscomv1alpha1.PreUpgradeBackupCondition)
+			Expect(cond).NotTo(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(hmcmirantiscomv1alpha1.ProgressingReason))
+
+			By("Failing the pre-upgrade Backup")
+			backup.Status.LastBackup = &velerov1.BackupStatus{Phase: velerov1.BackupPhaseFailed}
+			Expect(k8sClient.Status().Update(ctx, backup)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: client.ObjectKeyFromObject(mgmt),
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(mgmt), mgmt)).To(Succeed())
+			Expect(mgmt.Status.Release).To(Equal(oldReleaseName))
+			cond = apimeta.FindStatusCondition(mgmt.Status.Conditions, hmcmirantiscomv1alpha1.PreUpgradeBackupCondition)
+			Expect(cond).NotTo(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(hmcmirantiscomv1alpha1.FailedReason))
+
+			By("Removing the leftover objects")
+			Expect(k8sClient.Delete(ctx, backup)).To(Succeed())
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &hmcmirantiscomv1alpha1.Backup{
+				ObjectMeta: metav1.ObjectMeta{Name: mgmtName}, // scheduled backup
+			}))).To(Succeed())
+			mgmt.Finalizers = nil
+			Expect(k8sClient.Update(ctx, mgmt)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, mgmt)).To(Succeed())
+		})
+	})
+})
+
+var _ = Describe("Management infrastructure providers", func() {
+	It("should expose the third-party infrastructure provider declared only through the chart annotations", func() {
+		const providerName = "infrastructure-example"
+
+		_, builtin := hmcmirantiscomv1alpha1.BuiltinInfrastructureProvider(providerName)
+		Expect(builtin).To(BeFalse())
+
+		template := &hmcmirantiscomv1alpha1.ProviderTemplate{
+			TypeMeta:   metav1.TypeMeta{Kind: hmcmirantiscomv1alpha1.ProviderTemplateKind},
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-api-provider-example-0-0-1"},
+		}
+		Expect(template.FillStatusWithProviders(map[string]string{
+			hmcmirantiscomv1alpha1.ChartAnnotationProviderName:                   providerName,
+			hmcmirantiscomv1alpha1.ChartAnnotationInfrastructureCluster:          "infrastructure.cluster.x-k8s.io/v1beta1/ExampleCluster",
+			hmcmirantiscomv1alpha1.ChartAnnotationClusterIdentityKinds:           "ExampleClusterIdentity",
+			hmcmirantiscomv1alpha1.ChartAnnotationCredentialsPropagation:         "None",
+			hmcmirantiscomv1alpha1.ChartAnnotationInfrastructureHealthConditions: "Ready",
+		})).To(Succeed())
+
+		acc := &mgmtStatusAccumulator{
+			components:              make(map[string]hmcmirantiscomv1alpha1.ComponentStatus),
+			compatibilityContracts:  make(map[string]hmcmirantiscomv1alpha1.CompatibilityContracts),
+			infrastructureProviders: make(map[string]hmcmirantiscomv1alpha1.InfrastructureProvider),
+		}
+		updateComponentsStatus(acc, component{
+			Component:       hmcmirantiscomv1alpha1.Component{Template: template.Name},
+			helmReleaseName: "cluster-api-provider-example",
+		}, template, "")
+
+		mgmt := &hmcmirantiscomv1alpha1.Management{
+			Status: hmcmirantiscomv1alpha1.ManagementStatus{
+				AvailableProviders:      acc.providers,
+				InfrastructureProviders: acc.infrastructureProviders,
+			},
+		}
+		Expect(mgmt.Status.AvailableProviders).To(ConsistOf(providerName))
+
+		infrastructure, ok := mgmt.InfrastructureProvider(providerName)
+		Expect(ok).To(BeTrue())
+		Expect(infrastructure).To(Equal(hmcmirantiscomv1alpha1.InfrastructureProvider{
+			Cluster:                &metav1.GroupVersionKind{Group: "infrastructure.cluster.x-k8s.io", Version: "v1beta1", Kind: "ExampleCluster"},
+			ClusterIdentityKinds:   []string{"ExampleClusterIdentity"},
+			CredentialsPropagation: hmcmirantiscomv1alpha1.CredentialsPropagationNone,
+			HealthConditions:       []string{"Ready"},
+		}))
+	})
+})
+
+var _ = Describe("Management additional components", func() {
+	It("should enable Velero with the backup unless it is explicitly configured", func() {
+		ctx := context.Background()
+		r := &ManagementReconciler{}
+
+		veleroValues := func(mgmt *hmcmirantiscomv1alpha1.Management) map[string]any {
+			config := make(map[string]map[string]any)
+			Expect(json.Unmarshal(mgmt.Spec.Core.HMC.Config.Raw, &config)).To(Succeed())
+			return config["velero"]
+		}
+
+		mgmt := &hmcmirantiscomv1alpha1.Management{
+			Spec: hmcmirantiscomv1alpha1.ManagementSpec{
+				Core:   &hmcmirantiscomv1alpha1.Core{},
+				Backup: hmcmirantiscomv1alpha1.ManagementBackup{Enabled: true},
+			},
+		}
+		Expect(r.enableAdditionalComponents(ctx, mgmt)).To(Succeed())
+		Expect(veleroValues(mgmt)).To(HaveKeyWithValue("enabled", true))
+
+		By("Keeping Velero disabled in the config with the backup enabled")
+		mgmt.Spec.Core.HMC.Config = &apiextensionsv1.JSON{Raw: []byte(`{"velero":{"enabled":false}}`)}
+		Expect(r.enableAdditionalComponents(ctx, mgmt)).To(Succeed())
+		Expect(veleroValues(mgmt)).To(HaveKeyWithValue("enabled", false))
+
+		By("Keeping Velero enabled in the config with the backup disabled")
+		mgmt.Spec.Backup.Enabled = false
+		mgmt.Spec.Core.HMC.Config = &apiextensionsv1.JSON{Raw: []byte(`{"velero":{"enabled":true}}`)}
+		Expect(r.enableAdditionalComponents(ctx, mgmt)).To(Succeed())
+		Expect(veleroValues(mgmt)).To(HaveKeyWithValue("enabled", true))
+	})
+})
+
+var _ = Describe("Management components", func() {
+	It("should not repeat the dependencies of the providers", func() {
+		ctx := context.Background()
+
+		release := &hmcmirantiscomv1alpha1.Release{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-components-release"},
+			Spec: hmcmirantiscomv1alpha1.ReleaseSpec{
+				Version: "test-version",
+				HMC:     hmcmirantiscomv1alpha1.CoreProviderTemplate{Template: "hmc-0-0-1"},
+				CAPI:    hmcmirantiscomv1alpha1.CoreProviderTemplate{Template: "capi-0-0-1"},
+			},
+		}
+		Expect(k8sClient.Create(ctx, release)).To(Succeed())
+		DeferCleanup(k8sClient.Delete, release)
+
+		mgmt := &hmcmirantiscomv1alpha1.Management{
+			Spec: hmcmirantiscomv1alpha1.ManagementSpec{
+				Release: release.Name,
+				Core:    &hmcmirantiscomv1alpha1.Core{},
+				Providers: []hmcmirantiscomv1alpha1.Provider{
+					{Name: "ipam", Component: hmcmirantiscomv1alpha1.Component{Template: "ipam-0-0-1"}},
+					{
+						Name:      "infra",
+						Component: hmcmirantiscomv1alpha1.Component{Template: "infra-0-0-1"},
+						DependsOn: []string{hmcmirantiscomv1alpha1.CoreCAPIName, "ipam", "ipam"},
+					},
+				},
+			},
+		}
+		components, err := getWrappedComponents(ctx, k8sClient, mgmt)
+		Expect(err).NotTo(HaveOccurred())
+
+		idx := slices.IndexFunc(components, func(c component) bool { return c.helmReleaseName == "infra" })
+		Expect(idx).NotTo(Equal(-1))
+		Expect(components[idx].dependsOn).To(Equal([]fluxmeta.NamespacedObjectReference{
+			{Name: hmcmirantiscomv1alpha1.CoreCAPIName},
+			{Name: "ipam"},
+		}))
 	})
 })
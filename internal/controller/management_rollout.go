@@ -0,0 +1,226 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
+)
+
+// defaultComponentRolloutTimeout is the time a component is given to become healthy
+// if the timeout is not set in the Management.
+const defaultComponentRolloutTimeout = 15 * time.Minute
+
+// componentRolloutState is the state of the rollout of a single component.
+type componentRolloutState struct {
+	startTime *metav1.Time
+	// previousTemplate is the template the component is reverted to, empty if there is nothing to revert to
+	previousTemplate string
+	// revertedTemplate is the template the component has been reverted from
+	revertedTemplate string
+}
+
+// componentRollout rolls out the components of the Management one by one:
+// a component is updated only once all of the preceding ones are healthy
+// and is reverted to its previous template if it does not become healthy in time.
+type componentRollout struct {
+	now      metav1.Time
+	spec     *hmc.ManagementRollout
+	previous map[string]hmc.ComponentStatus
+	states   map[string]componentRolloutState
+
+	// blockedBy is the first of the components which are not healthy
+	blockedBy string
+	// rollingOut are the components being rolled out
+	rollingOut []string
+	// pending are the components waiting for the preceding ones to become healthy
+	pending []string
+	// reverted are the messages describing the components reverted to their previous templates
+	reverted []string
+}
+
+// newComponentRollout returns the rollout of the components of the given Management,
+// nil if the ordered rollout is not enabled.
+func newComponentRollout(mgmt *hmc.Management) *componentRollout {
+	if mgmt.Spec.Rollout == nil {
+		return nil
+	}
+
+	return &componentRollout{
+		now:      metav1.Now(),
+		spec:     mgmt.Spec.Rollout,
+		previous: mgmt.Status.Components,
+		states:   make(map[string]componentRolloutState),
+	}
+}
+
+// start prepares the rollout of the given component. The template of the component
+// is replaced with the previous one if the desired template has already failed to roll out.
+// Returns false if the component has to be left as is until the preceding components are healthy.
+func (ro *componentRollout) start(c *component) bool {
+	name := c.helmReleaseName
+	prev, installed := ro.previous[name]
+
+	if installed && prev.RevertedTemplate != "" && prev.RevertedTemplate == c.Template {
+		ro.states[name] = componentRolloutState{revertedTemplate: prev.RevertedTemplate}
+		c.Template = prev.Template
+		return true
+	}
+
+	changed := !installed || prev.Template != c.Template
+	if changed && ro.blockedBy != "" {
+		ro.pending = append(ro.pending, name)
+		return false
+	}
+
+	switch {
+	case changed && installed && prev.Template != "":
+		state := componentRolloutState{startTime: &ro.now, previousTemplate: prev.PreviousTemplate}
+		if prev.Success && prev.PreviousTemplate == "" {
+			state.previousTemplate = prev.Template
+		}
+		ro.states[name] = state
+	case !changed && prev.PreviousTemplate != "":
+		ro.states[name] = componentRolloutState{startTime: prev.RolloutStartTime, previousTemplate: prev.PreviousTemplate}
+	}
+
+	return true
+}
+
+// timedOut returns true if the given component has not become healthy within the rollout timeout
+// and can be reverted to its previous template.
+func (ro *componentRollout) timedOut(c component, statusAccumulator *mgmtStatusAccumulator) bool {
+	state := ro.states[c.helmReleaseName]
+	if state.previousTemplate == "" || state.startTime == nil || statusAccumulator.components[c.helmReleaseName].Success {
+		return false
+	}
+
+	return ro.now.Sub(state.startTime.Time) > ro.timeout(c.helmReleaseName)
+}
+
+// revert returns the given component with its previous template.
+func (ro *componentRollout) revert(c component) component {
+	name := c.helmReleaseName
+	state := ro.states[name]
+	ro.states[name] = componentRolloutState{revertedTemplate: c.Template}
+	c.Template = state.previousTemplate
+	return c
+}
+
+// record stores the rollout state of the given component in its status
+// and blocks the rollout of the following components if the component is not healthy.
+func (ro *componentRollout) record(c component, statusAccumulator *mgmtStatusAccumulator) {
+	name := c.helmReleaseName
+	state := ro.states[name]
+	componentStatus := statusAccumulator.components[name]
+
+	componentStatus.RevertedTemplate = state.revertedTemplate
+	if state.revertedTemplate != "" {
+		ro.reverted = append(ro.reverted, fmt.Sprintf("%s has not become healthy with %s within %s and has been reverted to %s",
+			name, state.revertedTemplate, ro.timeout(name), c.Template))
+	}
+	if !componentStatus.Success {
+		if ro.blockedBy == "" {
+			ro.blockedBy = name
+		}
+		if state.previousTemplate != "" {
+			componentStatus.PreviousTemplate = state.previousTemplate
+			componentStatus.RolloutStartTime = state.startTime
+			ro.rollingOut = append(ro.rollingOut, name)
+		}
+	}
+
+	statusAccumulator.components[name] = componentStatus
+}
+
+// inProgress returns true if any of the components is being rolled out or waits for its rollout.
+func (ro *componentRollout) inProgress() bool {
+	return len(ro.rollingOut) > 0 || len(ro.pending) > 0
+}
+
+// setCondition reflects the state of the rollout in the RolloutInProgress condition of the given Management.
+func (ro *componentRollout) setCondition(mgmt *hmc.Management) {
+	if !ro.inProgress() && len(ro.reverted) == 0 {
+		apimeta.RemoveStatusCondition(mgmt.GetConditions(), hmc.RolloutInProgressCondition)
+		return
+	}
+
+	condition := metav1.Condition{
+		Type:               hmc.RolloutInProgressCondition,
+		Status:             metav1.ConditionTrue,
+		Reason:             hmc.ProgressingReason,
+		ObservedGeneration: mgmt.Generation,
+	}
+
+	var msgs []string
+	if len(ro.reverted) > 0 {
+		condition.Reason = hmc.FailedReason
+		msgs = append(msgs, "Components reverted: "+strings.Join(ro.reverted, "; "))
+	}
+	if len(ro.rollingOut) > 0 {
+		msgs = append(msgs, "Components being rolled out: "+strings.Join(ro.rollingOut, ", "))
+	}
+	if len(ro.pending) > 0 {
+		msgs = append(msgs, fmt.Sprintf("Components waiting for %s to become healthy: %s", ro.blockedBy, strings.Join(ro.pending, ", ")))
+	}
+	if !ro.inProgress() {
+		condition.Status = metav1.ConditionFalse
+	}
+	condition.Message = strings.Join(msgs, ". ")
+
+	apimeta.SetStatusCondition(mgmt.GetConditions(), condition)
+}
+
+// timeout returns the rollout timeout of the component with the given name.
+func (ro *componentRollout) timeout(name string) time.Duration {
+	if timeout, ok := ro.spec.ComponentTimeouts[name]; ok && timeout.Duration > 0 {
+		return timeout.Duration
+	}
+	if ro.spec.Timeout.Duration > 0 {
+		return ro.spec.Timeout.Duration
+	}
+
+	return defaultComponentRolloutTimeout
+}
+
+// keepComponentStatus keeps the status of the given component which rollout is postponed,
+// so the providers of the component currently installed remain available.
+func (r *ManagementReconciler) keepComponentStatus(ctx context.Context, mgmt *hmc.Management, c component, statusAccumulator *mgmtStatusAccumulator) error {
+	prev, installed := mgmt.Status.Components[c.helmReleaseName]
+	if !installed {
+		updateComponentsStatus(statusAccumulator, c, nil, "Waiting for the preceding components to become healthy")
+		return nil
+	}
+
+	if prev.Success {
+		c.Template = prev.Template
+		template := new(hmc.ProviderTemplate)
+		if err := r.Get(ctx, client.ObjectKey{Name: prev.Template}, template); err != nil {
+			return fmt.Errorf("failed to get ProviderTemplate %s: %w", prev.Template, err)
+		}
+		updateComponentsStatus(statusAccumulator, c, template, "")
+	}
+
+	statusAccumulator.components[c.helmReleaseName] = prev
+	return nil
+}
@@ -0,0 +1,179 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"time"
+
+	fluxmeta "github.com/fluxcd/pkg/apis/meta"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
+)
+
+var _ = Describe("Management components rollout", func() {
+	newComponent := func(name, template string) component {
+		return component{Component: hmc.Component{Template: template}, helmReleaseName: name}
+	}
+
+	newAccumulator := func() *mgmtStatusAccumulator {
+		return &mgmtStatusAccumulator{
+			components:             make(map[string]hmc.ComponentStatus),
+			compatibilityContracts: make(map[string]hmc.CompatibilityContracts),
+		}
+	}
+
+	It("should be disabled if the rollout is not set", func() {
+		Expect(newComponentRollout(&hmc.Management{})).To(BeNil())
+	})
+
+	It("should postpone the following components until the rolled out one is healthy", func() {
+		mgmt := &hmc.Management{
+			Spec: hmc.ManagementSpec{Rollout: &hmc.ManagementRollout{Timeout: metav1.Duration{Duration: time.Hour}}},
+			Status: hmc.ManagementStatus{Components: map[string]hmc.ComponentStatus{
+				hmc.CoreHMCName:  {Template: "hmc-0-0-1", Success: true},
+				hmc.CoreCAPIName: {Template: "capi-0-0-1", Success: true},
+			}},
+		}
+		rollout := newComponentRollout(mgmt)
+		acc := newAccumulator()
+
+		hmcComp := newComponent(hmc.CoreHMCName, "hmc-0-0-2")
+		Expect(rollout.start(&hmcComp)).To(BeTrue())
+		updateComponentsStatus(acc, hmcComp, nil, "HelmRelease is not yet ready")
+		Expect(rollout.timedOut(hmcComp, acc)).To(BeFalse())
+		rollout.record(hmcComp, acc)
+
+		Expect(acc.components[hmc.CoreHMCName].PreviousTemplate).To(Equal("hmc-0-0-1"))
+		Expect(acc.components[hmc.CoreHMCName].RolloutStartTime).NotTo(BeNil())
+
+		capiComp := newComponent(hmc.CoreCAPIName, "capi-0-0-2")
+		Expect(rollout.start(&capiComp)).To(BeFalse())
+
+		rollout.setCondition(mgmt)
+		cond := apimeta.FindStatusCondition(mgmt.Status.Conditions, hmc.RolloutInProgressCondition)
+		Expect(cond).NotTo(BeNil())
+		Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+		Expect(cond.Reason).To(Equal(hmc.ProgressingReason))
+	})
+
+	It("should revert the component not becoming healthy within the timeout", func() {
+		startTime := metav1.NewTime(time.Now().Add(-time.Hour))
+		mgmt := &hmc.Management{
+			Spec: hmc.ManagementSpec{Rollout: &hmc.ManagementRollout{
+				Timeout:           metav1.Duration{Duration: 2 * time.Hour},
+				ComponentTimeouts: map[string]metav1.Duration{hmc.CoreCAPIName: {Duration: time.Minute}},
+			}},
+			Status: hmc.ManagementStatus{Components: map[string]hmc.ComponentStatus{
+				hmc.CoreCAPIName: {Template: "capi-0-0-2", PreviousTemplate: "capi-0-0-1", RolloutStartTime: &startTime},
+			}},
+		}
+		rollout := newComponentRollout(mgmt)
+		acc := newAccumulator()
+
+		capiComp := newComponent(hmc.CoreCAPIName, "capi-0-0-2")
+		Expect(rollout.start(&capiComp)).To(BeTrue())
+		updateComponentsStatus(acc, capiComp, nil, "HelmRelease is not yet ready")
+		Expect(rollout.timedOut(capiComp, acc)).To(BeTrue())
+
+		capiComp = rollout.revert(capiComp)
+		Expect(capiComp.Template).To(Equal("capi-0-0-1"))
+		updateComponentsStatus(acc, capiComp, nil, "")
+		rollout.record(capiComp, acc)
+
+		Expect(acc.components[hmc.CoreCAPIName]).To(Equal(hmc.ComponentStatus{
+			Template:         "capi-0-0-1",
+			RevertedTemplate: "capi-0-0-2",
+			Success:          true,
+		}))
+
+		rollout.setCondition(mgmt)
+		cond := apimeta.FindStatusCondition(mgmt.Status.Conditions, hmc.RolloutInProgressCondition)
+		Expect(cond).NotTo(BeNil())
+		Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+		Expect(cond.Reason).To(Equal(hmc.FailedReason))
+
+		By("keeping the component on the previous template until another one is requested")
+		mgmt.Status.Components = acc.components
+		rollout = newComponentRollout(mgmt)
+		capiComp = newComponent(hmc.CoreCAPIName, "capi-0-0-2")
+		Expect(rollout.start(&capiComp)).To(BeTrue())
+		Expect(capiComp.Template).To(Equal("capi-0-0-1"))
+
+		capiComp = newComponent(hmc.CoreCAPIName, "capi-0-0-3")
+		Expect(rollout.start(&capiComp)).To(BeTrue())
+		Expect(capiComp.Template).To(Equal("capi-0-0-3"))
+	})
+
+	It("should roll out the providers after their dependencies regardless of their order in the Management", func() {
+		dependsOn := func(c component, names ...string) component {
+			for _, name := range names {
+				c.dependsOn = append(c.dependsOn, fluxmeta.NamespacedObjectReference{Name: name})
+			}
+			return c
+		}
+
+		components := sortByDependencies([]component{
+			newComponent(hmc.CoreHMCName, "hmc-0-0-1"),
+			dependsOn(newComponent(hmc.CoreCAPIName, "capi-0-0-1"), hmc.CoreHMCName),
+			dependsOn(newComponent("infra", "infra-0-0-2"), hmc.CoreCAPIName, "ipam"),
+			dependsOn(newComponent("bootstrap", "bootstrap-0-0-1"), hmc.CoreCAPIName),
+			dependsOn(newComponent("ipam", "ipam-0-0-2"), hmc.CoreCAPIName),
+		})
+
+		var names []string
+		for _, c := range components {
+			names = append(names, c.helmReleaseName)
+		}
+		Expect(names).To(Equal([]string{hmc.CoreHMCName, hmc.CoreCAPIName, "ipam", "infra", "bootstrap"}))
+
+		mgmt := &hmc.Management{
+			Spec: hmc.ManagementSpec{Rollout: &hmc.ManagementRollout{Timeout: metav1.Duration{Duration: time.Hour}}},
+			Status: hmc.ManagementStatus{Components: map[string]hmc.ComponentStatus{
+				hmc.CoreHMCName:  {Template: "hmc-0-0-1", Success: true},
+				hmc.CoreCAPIName: {Template: "capi-0-0-1", Success: true},
+				"ipam":           {Template: "ipam-0-0-1", Success: true},
+				"infra":          {Template: "infra-0-0-1", Success: true},
+				"bootstrap":      {Template: "bootstrap-0-0-1", Success: true},
+			}},
+		}
+		rollout := newComponentRollout(mgmt)
+		acc := newAccumulator()
+
+		for _, c := range components {
+			if !rollout.start(&c) {
+				continue
+			}
+			if c.helmReleaseName == "ipam" {
+				updateComponentsStatus(acc, c, nil, "HelmRelease is not yet ready")
+			} else {
+				updateComponentsStatus(acc, c, nil, "")
+			}
+			rollout.record(c, acc)
+		}
+
+		By("postponing the provider until the provider it depends on is healthy")
+		Expect(acc.components).NotTo(HaveKey("infra"))
+		Expect(acc.components["ipam"].PreviousTemplate).To(Equal("ipam-0-0-1"))
+
+		rollout.setCondition(mgmt)
+		cond := apimeta.FindStatusCondition(mgmt.Status.Conditions, hmc.RolloutInProgressCondition)
+		Expect(cond).NotTo(BeNil())
+		Expect(cond.Message).To(ContainSubstring("Components waiting for ipam to become healthy: infra"))
+	})
+})
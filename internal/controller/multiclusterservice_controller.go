@@ -120,7 +120,23 @@ func (r *MultiClusterServiceReconciler) reconcileUpdate(ctx context.Context, mcs
 		return ctrl.Result{}, err
 	}
 
-	if _, err = sveltos.ReconcileClusterProfile(ctx, r.Client, mcs.Name,
+	window, err := getMaintenanceWindow(ctx, r.Client, mcs.Spec.MaintenanceWindow)
+	if err != nil {
+		setMaintenanceWindowFailed(&mcs.Status.Conditions, err)
+		return ctrl.Result{}, err
+	}
+	apimeta.RemoveStatusCondition(&mcs.Status.Conditions, hmc.PendingMaintenanceCondition)
+
+	existingProfile := &sveltosv1beta1.ClusterProfile{ObjectMeta: metav1.ObjectMeta{Name: mcs.Name}}
+	postponed, err := servicesChangesPostponed(ctx, r.Client, window, existingProfile, &existingProfile.Spec, opts)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var result ctrl.Result
+	if postponed {
+		result = window.postpone(&mcs.Status.Conditions, "Services changes")
+	} else if _, err = sveltos.ReconcileClusterProfile(ctx, r.Client, mcs.Name,
 		sveltos.ReconcileProfileOpts{
 			OwnerReference: &metav1.OwnerReference{
 				APIVersion: hmc.GroupVersion.String(),
@@ -155,7 +171,7 @@ func (r *MultiClusterServiceReconciler) reconcileUpdate(ctx context.Context, mcs
 	}
 	mcs.Status.Services = servicesStatus
 
-	return ctrl.Result{}, nil
+	return result, nil
 }
 
 // updateStatus updates the status for the MultiClusterService object.
@@ -0,0 +1,176 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	hcv2 "github.com/fluxcd/helm-controller/api/v2"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/yaml"
+
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
+	"github.com/K0rdent/kcm/internal/helm"
+)
+
+// orphanCluster suspends the given HelmRelease so that its deletion does not
+// uninstall the release and detaches the objects of the release from HMC and Flux.
+func (r *ClusterDeploymentReconciler) orphanCluster(ctx context.Context, hr *hcv2.HelmRelease) error {
+	l := ctrl.LoggerFrom(ctx)
+
+	if !hr.Spec.Suspend {
+		original := hr.DeepCopy()
+		hr.Spec.Suspend = true
+		if err := r.Patch(ctx, hr, client.MergeFrom(original)); err != nil {
+			return fmt.Errorf("failed to suspend HelmRelease %s: %w", client.ObjectKeyFromObject(hr), err)
+		}
+		l.Info("Suspended HelmRelease to orphan the cluster")
+	}
+
+	release, found, err := r.getDeployedRelease(ctx, hr)
+	if err != nil || !found {
+		return err
+	}
+
+	objects, err := decodeManifests(release.Manifest)
+	if err != nil {
+		return fmt.Errorf("failed to decode manifest of Helm release %s: %w", release.Name, err)
+	}
+
+	for _, obj := range objects {
+		if err := r.orphanObject(ctx, obj, release.Namespace); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getDeployedRelease returns the latest Helm release of the given HelmRelease, found is false if it does not exist.
+func (r *ClusterDeploymentReconciler) getDeployedRelease(ctx context.Context, hr *hcv2.HelmRelease) (_ *release.Release, found bool, _ error) {
+	getter := helm.NewMemoryRESTClientGetter(r.Config, r.RESTMapper())
+	actionConfig := new(action.Configuration)
+	if err := actionConfig.Init(getter, hr.GetStorageNamespace(), "secret", ctrl.LoggerFrom(ctx).Info); err != nil {
+		return nil, false, err
+	}
+
+	rel, err := action.NewGet(actionConfig).Run(hr.GetReleaseName())
+	if err != nil {
+		if errors.Is(err, driver.ErrReleaseNotFound) {
+			return nil, false, nil
+		}
+
+		return nil, false, fmt.Errorf("failed to get Helm release %s: %w", hr.GetReleaseName(), err)
+	}
+
+	return rel, true, nil
+}
+
+// decodeManifests decodes the objects of the given Helm release manifest.
+func decodeManifests(manifest string) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+	for _, m := range releaseutil.SplitManifests(manifest) {
+		obj := new(unstructured.Unstructured)
+		if err := yaml.Unmarshal([]byte(m), &obj.Object); err != nil {
+			return nil, err
+		}
+		if obj.GetKind() == "" {
+			continue
+		}
+
+		objects = append(objects, obj)
+	}
+
+	return objects, nil
+}
+
+// orphanObject drops the Flux labels, the owner references to the HMC and Flux objects and the HMC finalizer
+// blocking the deletion of the infrastructure cluster from the given object.
+func (r *ClusterDeploymentReconciler) orphanObject(ctx context.Context, obj *unstructured.Unstructured, releaseNamespace string) error {
+	found, err := r.getReleaseObject(ctx, obj, releaseNamespace)
+	if err != nil || !found {
+		return err
+	}
+
+	original := obj.DeepCopy()
+
+	objLabels := obj.GetLabels()
+	delete(objLabels, hmc.FluxHelmChartNameKey)
+	delete(objLabels, hmc.FluxHelmChartNamespaceKey)
+	obj.SetLabels(objLabels)
+
+	obj.SetOwnerReferences(slices.DeleteFunc(obj.GetOwnerReferences(), func(ref metav1.OwnerReference) bool {
+		gv, err := schema.ParseGroupVersion(ref.APIVersion)
+		return err == nil && (gv.Group == hmc.GroupVersion.Group || gv.Group == hcv2.GroupVersion.Group)
+	}))
+
+	// the cluster is not released by HMC anymore once it is detached from the release
+	finalizerRemoved := controllerutil.RemoveFinalizer(obj, hmc.BlockingFinalizer)
+
+	if !finalizerRemoved && len(obj.GetLabels()) == len(original.GetLabels()) && len(obj.GetOwnerReferences()) == len(original.GetOwnerReferences()) {
+		return nil
+	}
+
+	if err := r.Patch(ctx, obj, client.MergeFrom(original)); err != nil {
+		return fmt.Errorf("failed to orphan %s %s: %w", obj.GetKind(), client.ObjectKeyFromObject(obj), err)
+	}
+
+	ctrl.LoggerFrom(ctx).Info("Orphaned object", "kind", obj.GetKind(), "object", client.ObjectKeyFromObject(obj))
+
+	return nil
+}
+
+// getReleaseObject fetches the live state of the given object rendered by a Helm release
+// defaulting its namespace to the release one if the object is namespaced.
+// found is false if either the object or its kind does not exist.
+func (r *ClusterDeploymentReconciler) getReleaseObject(ctx context.Context, obj *unstructured.Unstructured, releaseNamespace string) (found bool, _ error) {
+	gvk := obj.GroupVersionKind()
+	mapping, err := r.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		if apimeta.IsNoMatchError(err) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("failed to get REST mapping of %s: %w", gvk, err)
+	}
+
+	if mapping.Scope.Name() == apimeta.RESTScopeNameNamespace && obj.GetNamespace() == "" {
+		obj.SetNamespace(releaseNamespace)
+	}
+
+	if err := r.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("failed to get %s %s: %w", gvk.Kind, client.ObjectKeyFromObject(obj), err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,236 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	hcv2 "github.com/fluxcd/helm-controller/api/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
+)
+
+var _ = Describe("ClusterDeployment orphaning", func() {
+	const (
+		clusterDeploymentName      = "test-orphaned-cluster-deployment"
+		clusterDeploymentNamespace = "test-orphan"
+		configMapName              = "test-orphaned-config"
+		infraClusterName           = "test-orphaned-infra-cluster"
+	)
+
+	ctx := context.Background()
+
+	namespace := &corev1.Namespace{}
+	clusterDeployment := &hmc.ClusterDeployment{}
+	hr := &hcv2.HelmRelease{}
+	configMap := &corev1.ConfigMap{}
+	infraCluster := &unstructured.Unstructured{}
+
+	BeforeEach(func() {
+		By("creating the namespace")
+		namespace = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: clusterDeploymentNamespace}}
+		Expect(client.IgnoreAlreadyExists(k8sClient.Create(ctx, namespace))).To(Succeed())
+
+		// the ClusterDeployment itself is not created since only its deletion is exercised
+		clusterDeployment = &hmc.ClusterDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      clusterDeploymentName,
+				Namespace: clusterDeploymentNamespace,
+				UID:       types.UID("test-orphaned-cluster-deployment-uid"),
+			},
+			Spec: hmc.ClusterDeploymentSpec{
+				Template:       "test-template",
+				DeletionPolicy: hmc.DeletionPolicyOrphan,
+			},
+		}
+
+		owner := metav1.OwnerReference{
+			APIVersion: hmc.GroupVersion.String(),
+			Kind:       hmc.ClusterDeploymentKind,
+			Name:       clusterDeployment.Name,
+			UID:        clusterDeployment.UID,
+		}
+
+		By("creating the HelmRelease")
+		hr = &hcv2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            clusterDeploymentName,
+				Namespace:       clusterDeploymentNamespace,
+				OwnerReferences: []metav1.OwnerReference{owner},
+				// emulate the helm-controller which uninstalls the release on deletion
+				Finalizers: []string{"finalizers.fluxcd.io"},
+			},
+			Spec: hcv2.HelmReleaseSpec{
+				ChartRef: &hcv2.CrossNamespaceSourceReference{
+					Kind: "HelmChart",
+					Name: "ref-test",
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, hr)).To(Succeed())
+
+		By("creating the object of the Helm release")
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      configMapName,
+				Namespace: clusterDeploymentNamespace,
+				Labels: map[string]string{
+					hmc.FluxHelmChartNameKey:      clusterDeploymentName,
+					hmc.FluxHelmChartNamespaceKey: clusterDeploymentNamespace,
+					"app":                         "test",
+				},
+				OwnerReferences: []metav1.OwnerReference{owner},
+			},
+		}
+		Expect(k8sClient.Create(ctx, configMap)).To(Succeed())
+
+		By("creating the infrastructure cluster of the Helm release")
+		_, err := envtest.InstallCRDs(cfg, envtest.CRDInstallOptions{
+			CRDs: []*apiextensionsv1.CustomResourceDefinition{{
+				ObjectMeta: metav1.ObjectMeta{Name: "exampleclusters.infrastructure.cluster.x-k8s.io"},
+				Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+					Group: "infrastructure.cluster.x-k8s.io",
+					Names: apiextensionsv1.CustomResourceDefinitionNames{
+						Kind:     "ExampleCluster",
+						ListKind: "ExampleClusterList",
+						Plural:   "exampleclusters",
+						Singular: "examplecluster",
+					},
+					Scope: apiextensionsv1.NamespaceScoped,
+					Versions: []apiextensionsv1.CustomResourceDefinitionVersion{{
+						Name:    "v1beta1",
+						Served:  true,
+						Storage: true,
+						Schema: &apiextensionsv1.CustomResourceValidation{
+							OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
+								Type:                   "object",
+								XPreserveUnknownFields: ptr.To(true),
+							},
+						},
+					}},
+				},
+			}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		infraCluster = &unstructured.Unstructured{}
+		infraCluster.SetAPIVersion("infrastructure.cluster.x-k8s.io/v1beta1")
+		infraCluster.SetKind("ExampleCluster")
+		infraCluster.SetName(infraClusterName)
+		infraCluster.SetNamespace(clusterDeploymentNamespace)
+		infraCluster.SetLabels(map[string]string{
+			hmc.FluxHelmChartNameKey:      clusterDeploymentName,
+			hmc.FluxHelmChartNamespaceKey: clusterDeploymentNamespace,
+		})
+		infraCluster.SetOwnerReferences([]metav1.OwnerReference{owner})
+		infraCluster.SetFinalizers([]string{hmc.BlockingFinalizer})
+		Expect(k8sClient.Create(ctx, infraCluster)).To(Succeed())
+
+		rel := &release.Release{
+			Name:      clusterDeploymentName,
+			Namespace: clusterDeploymentNamespace,
+			Version:   1,
+			Info:      &release.Info{Status: release.StatusDeployed},
+			Manifest: fmt.Sprintf(`---
+# Source: test/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: %s
+---
+# Source: test/templates/cluster.yaml
+apiVersion: infrastructure.cluster.x-k8s.io/v1beta1
+kind: ExampleCluster
+metadata:
+  name: %s
+`, configMapName, infraClusterName),
+		}
+		secrets := driver.NewSecrets(kubernetes.NewForConfigOrDie(cfg).CoreV1().Secrets(clusterDeploymentNamespace))
+		Expect(secrets.Create(fmt.Sprintf("sh.helm.release.v1.%s.v%d", rel.Name, rel.Version), rel)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		By("Cleanup")
+		Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, configMap))).To(Succeed())
+		if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(infraCluster), infraCluster); err == nil {
+			infraCluster.SetFinalizers(nil)
+			Expect(k8sClient.Update(ctx, infraCluster)).To(Succeed())
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, infraCluster))).To(Succeed())
+		}
+		if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(hr), hr); err == nil {
+			hr.Finalizers = nil
+			Expect(k8sClient.Update(ctx, hr)).To(Succeed())
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, hr))).To(Succeed())
+		}
+		Expect(k8sClient.DeleteAllOf(ctx, &corev1.Secret{}, client.InNamespace(clusterDeploymentNamespace))).To(Succeed())
+	})
+
+	It("should detach the objects of the cluster", func() {
+		controllerReconciler := &ClusterDeploymentReconciler{
+			Client: k8sClient,
+			Config: cfg,
+		}
+
+		_, err := controllerReconciler.Delete(ctx, clusterDeployment)
+		Expect(err).NotTo(HaveOccurred())
+
+		By("Checking the object has been detached")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(configMap), configMap)).To(Succeed())
+		Expect(configMap.Labels).To(Equal(map[string]string{"app": "test"}))
+		Expect(configMap.OwnerReferences).To(BeEmpty())
+
+		By("Checking the HelmRelease has been suspended before the deletion")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(hr), hr)).To(Succeed())
+		Expect(hr.Spec.Suspend).To(BeTrue())
+		Expect(hr.DeletionTimestamp).NotTo(BeNil())
+	})
+
+	It("should let the orphaned infrastructure cluster be deleted", func() {
+		controllerReconciler := &ClusterDeploymentReconciler{
+			Client: k8sClient,
+			Config: cfg,
+		}
+
+		_, err := controllerReconciler.Delete(ctx, clusterDeployment)
+		Expect(err).NotTo(HaveOccurred())
+
+		By("Checking the infrastructure cluster has been detached")
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(infraCluster), infraCluster)).To(Succeed())
+		Expect(infraCluster.GetLabels()).To(BeEmpty())
+		Expect(infraCluster.GetOwnerReferences()).To(BeEmpty())
+		Expect(infraCluster.GetFinalizers()).NotTo(ContainElement(hmc.BlockingFinalizer))
+
+		By("Deleting the orphaned infrastructure cluster")
+		Expect(k8sClient.Delete(ctx, infraCluster)).To(Succeed())
+		Eventually(func() bool {
+			return apierrors.IsNotFound(k8sClient.Get(ctx, client.ObjectKeyFromObject(infraCluster), infraCluster))
+		}).Should(BeTrue())
+	})
+})
@@ -0,0 +1,70 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
+)
+
+// reconcileClusterPause pauses the CAPI Clusters of the given ClusterDeployment if it is paused
+// and unpauses them otherwise. Only the Clusters previously paused by the ClusterDeployment are unpaused.
+func (r *ClusterDeploymentReconciler) reconcileClusterPause(ctx context.Context, clusterDeployment *hmc.ClusterDeployment) error {
+	l := ctrl.LoggerFrom(ctx)
+
+	clusters := new(unstructured.UnstructuredList)
+	clusters.SetGroupVersionKind(schema.GroupVersionKind{Group: "cluster.x-k8s.io", Version: "v1beta1", Kind: "ClusterList"})
+	if err := r.List(ctx, clusters,
+		client.InNamespace(clusterDeployment.Namespace),
+		client.MatchingLabels{hmc.FluxHelmChartNameKey: clusterDeployment.Name},
+	); err != nil {
+		if apimeta.IsNoMatchError(err) {
+			return nil
+		}
+
+		return fmt.Errorf("failed to list CAPI Clusters: %w", err)
+	}
+
+	for _, cluster := range clusters.Items {
+		_, pausedByDeployment := cluster.GetAnnotations()[hmc.ClusterDeploymentPausedAnnotation]
+
+		var patch string
+		switch {
+		case clusterDeployment.Spec.Paused && !pausedByDeployment:
+			patch = fmt.Sprintf(`{"metadata":{"annotations":{%q:"true"}},"spec":{"paused":true}}`, hmc.ClusterDeploymentPausedAnnotation)
+		case !clusterDeployment.Spec.Paused && pausedByDeployment:
+			patch = fmt.Sprintf(`{"metadata":{"annotations":{%q:null}},"spec":{"paused":false}}`, hmc.ClusterDeploymentPausedAnnotation)
+		default:
+			continue
+		}
+
+		if err := r.Patch(ctx, &cluster, client.RawPatch(types.MergePatchType, []byte(patch))); err != nil {
+			return fmt.Errorf("failed to patch CAPI Cluster %s: %w", client.ObjectKeyFromObject(&cluster), err)
+		}
+
+		l.Info("Patched CAPI Cluster", "cluster", client.ObjectKeyFromObject(&cluster), "paused", clusterDeployment.Spec.Paused)
+	}
+
+	return nil
+}
@@ -0,0 +1,303 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
+)
+
+// releaseUpgradePlanRefreshInterval is the interval the plans are recomputed at
+// to catch up with the changes of the templates and the ClusterDeployments.
+const releaseUpgradePlanRefreshInterval = 5 * time.Minute
+
+// ReleaseUpgradePlanReconciler reconciles a ReleaseUpgradePlan object
+type ReleaseUpgradePlanReconciler struct {
+	client.Client
+}
+
+func (r *ReleaseUpgradePlanReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, err error) {
+	l := ctrl.LoggerFrom(ctx)
+	l.Info("Reconciling ReleaseUpgradePlan")
+
+	plan := new(hmc.ReleaseUpgradePlan)
+	if err := r.Get(ctx, req.NamespacedName, plan); err != nil {
+		if apierrors.IsNotFound(err) {
+			l.Info("ReleaseUpgradePlan not found, ignoring since object must be deleted")
+			return ctrl.Result{}, nil
+		}
+
+		l.Error(err, "Failed to get ReleaseUpgradePlan")
+		return ctrl.Result{}, err
+	}
+
+	plan.Status.ObservedGeneration = plan.Generation
+	defer func() {
+		err = errors.Join(err, r.updateStatus(ctx, plan))
+	}()
+
+	release := new(hmc.Release)
+	if err := r.Get(ctx, client.ObjectKey{Name: plan.Spec.Release}, release); err != nil {
+		r.setReadyCondition(plan, metav1.ConditionFalse, hmc.FailedReason, fmt.Sprintf("Failed to get Release %s: %s", plan.Spec.Release, err))
+		return ctrl.Result{}, fmt.Errorf("failed to get Release %s: %w", plan.Spec.Release, err)
+	}
+
+	if !release.Status.Ready {
+		r.setReadyCondition(plan, metav1.ConditionFalse, hmc.ProgressingReason, fmt.Sprintf("Waiting for the templates of Release %s to be created and validated", release.Name))
+		return ctrl.Result{RequeueAfter: DefaultRequeueInterval}, nil
+	}
+
+	if err := r.computePlan(ctx, plan); err != nil {
+		r.setReadyCondition(plan, metav1.ConditionFalse, hmc.FailedReason, err.Error())
+		return ctrl.Result{}, err
+	}
+
+	r.setReadyCondition(plan, metav1.ConditionTrue, hmc.SucceededReason,
+		fmt.Sprintf("%d ProviderTemplates are changed, %d ClusterTemplates become incompatible, %d ClusterDeployments are affected",
+			len(plan.Status.ProviderTemplates), len(plan.Status.IncompatibleClusterTemplates), len(plan.Status.AffectedClusterDeployments)))
+
+	return ctrl.Result{RequeueAfter: releaseUpgradePlanRefreshInterval}, nil
+}
+
+// computePlan fills the status of the given ReleaseUpgradePlan with the effect of switching the Management to the planned Release.
+func (r *ReleaseUpgradePlanReconciler) computePlan(ctx context.Context, plan *hmc.ReleaseUpgradePlan) error {
+	mgmt := new(hmc.Management)
+	if err := r.Get(ctx, client.ObjectKey{Name: hmc.ManagementName}, mgmt); err != nil {
+		return fmt.Errorf("failed to get Management: %w", err)
+	}
+
+	plannedMgmt := mgmt.DeepCopy()
+	plannedMgmt.Spec.Release = plan.Spec.Release
+
+	currentComponents, err := getWrappedComponents(ctx, r.Client, mgmt)
+	if err != nil {
+		return err
+	}
+	plannedComponents, err := getWrappedComponents(ctx, r.Client, plannedMgmt)
+	if err != nil {
+		return err
+	}
+
+	plan.Status.CurrentRelease = mgmt.Spec.Release
+	plan.Status.ProviderTemplates = providerTemplateChanges(currentComponents, plannedComponents)
+
+	var providers []string
+	capiContracts := make(map[string]hmc.CompatibilityContracts)
+	for _, component := range plannedComponents {
+		if component.Template == "" {
+			continue
+		}
+
+		template := new(hmc.ProviderTemplate)
+		if err := r.Get(ctx, client.ObjectKey{Name: component.Template}, template); err != nil {
+			return fmt.Errorf("failed to get ProviderTemplate %s: %w", component.Template, err)
+		}
+
+		providers = append(providers, template.Status.Providers...)
+		for _, provider := range template.Status.Providers {
+			capiContracts[provider] = template.Status.CAPIContracts
+		}
+	}
+	slices.Sort(providers)
+	providers = slices.Compact(providers)
+
+	clusterTemplates := new(hmc.ClusterTemplateList)
+	if err := r.List(ctx, clusterTemplates); err != nil {
+		return fmt.Errorf("failed to list ClusterTemplates: %w", err)
+	}
+
+	compatible := make(map[client.ObjectKey]*hmc.ClusterTemplate)
+	incompatible := make(map[client.ObjectKey]bool)
+	plan.Status.IncompatibleClusterTemplates = nil
+	for i := range clusterTemplates.Items {
+		template := &clusterTemplates.Items[i]
+		if !template.Status.Valid {
+			continue
+		}
+
+		key := client.ObjectKeyFromObject(template)
+		if err := checkClusterTemplateCompatibility(ctx, template, providers, capiContracts); err != nil {
+			incompatible[key] = true
+			plan.Status.IncompatibleClusterTemplates = append(plan.Status.IncompatibleClusterTemplates, hmc.IncompatibleTemplate{
+				Namespace: template.Namespace,
+				Name:      template.Name,
+				Reason:    strings.ReplaceAll(err.Error(), "\n", "; "),
+			})
+			continue
+		}
+		compatible[key] = template
+	}
+
+	clusterDeployments := new(hmc.ClusterDeploymentList)
+	if err := r.List(ctx, clusterDeployments); err != nil {
+		return fmt.Errorf("failed to list ClusterDeployments: %w", err)
+	}
+
+	plan.Status.AffectedClusterDeployments = nil
+	plan.Status.IncompatibleServiceTemplates = nil
+	for _, cd := range clusterDeployments.Items {
+		if !incompatible[client.ObjectKey{Namespace: cd.Namespace, Name: cd.Spec.Template}] {
+			continue
+		}
+
+		affected := hmc.AffectedClusterDeployment{Namespace: cd.Namespace, Name: cd.Name, Template: cd.Spec.Template}
+		availableUpgrades := slices.Sorted(slices.Values(cd.Status.AvailableUpgrades))
+		for _, upgrade := range availableUpgrades {
+			if _, ok := compatible[client.ObjectKey{Namespace: cd.Namespace, Name: upgrade}]; ok {
+				affected.UpgradeTo = upgrade
+				break
+			}
+		}
+		plan.Status.AffectedClusterDeployments = append(plan.Status.AffectedClusterDeployments, affected)
+
+		if affected.UpgradeTo == "" {
+			continue
+		}
+
+		incompatibleServices, err := r.incompatibleServiceTemplates(ctx, &cd, compatible[client.ObjectKey{Namespace: cd.Namespace, Name: affected.UpgradeTo}])
+		if err != nil {
+			return err
+		}
+		plan.Status.IncompatibleServiceTemplates = append(plan.Status.IncompatibleServiceTemplates, incompatibleServices...)
+	}
+
+	plan.Status.Upgradable = len(plan.Status.AffectedClusterDeployments) == 0
+
+	return nil
+}
+
+// providerTemplateChanges returns the components whose ProviderTemplates differ between the given current and planned ones.
+func providerTemplateChanges(current, planned []component) []hmc.ProviderTemplateChange {
+	currentTemplates := make(map[string]string, len(current))
+	for _, c := range current {
+		currentTemplates[c.helmReleaseName] = c.Template
+	}
+
+	var changes []hmc.ProviderTemplateChange
+	for _, c := range planned {
+		if from := currentTemplates[c.helmReleaseName]; from != c.Template {
+			changes = append(changes, hmc.ProviderTemplateChange{Name: c.helmReleaseName, From: from, To: c.Template})
+		}
+		delete(currentTemplates, c.helmReleaseName)
+	}
+	for _, c := range current {
+		if from, ok := currentTemplates[c.helmReleaseName]; ok {
+			changes = append(changes, hmc.ProviderTemplateChange{Name: c.helmReleaseName, From: from})
+		}
+	}
+
+	return changes
+}
+
+// incompatibleServiceTemplates returns the ServiceTemplates of the given ClusterDeployment
+// whose Kubernetes constraints are not satisfied by the Kubernetes version of the given ClusterTemplate.
+func (r *ReleaseUpgradePlanReconciler) incompatibleServiceTemplates(ctx context.Context, cd *hmc.ClusterDeployment, template *hmc.ClusterTemplate) ([]hmc.IncompatibleTemplate, error) {
+	if len(cd.Spec.Services) == 0 || template.Status.KubernetesVersion == "" {
+		return nil, nil
+	}
+
+	version, err := semver.NewVersion(template.Status.KubernetesVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse k8s version %s of the ClusterTemplate %s: %w", template.Status.KubernetesVersion, client.ObjectKeyFromObject(template), err)
+	}
+
+	var result []hmc.IncompatibleTemplate
+	for _, svc := range cd.Spec.Services {
+		if svc.Disable {
+			continue
+		}
+
+		svcTpl := new(hmc.ServiceTemplate)
+		key := client.ObjectKey{Namespace: cd.Namespace, Name: svc.Template}
+		if err := r.Get(ctx, key, svcTpl); err != nil {
+			return nil, fmt.Errorf("failed to get ServiceTemplate %s: %w", key, err)
+		}
+
+		constraint := svcTpl.Status.KubernetesConstraint
+		if constraint == "" {
+			continue
+		}
+
+		tplConstraint, err := semver.NewConstraint(constraint)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse k8s constrained version %s of the ServiceTemplate %s: %w", constraint, key, err)
+		}
+
+		if !tplConstraint.Check(version) {
+			result = append(result, hmc.IncompatibleTemplate{
+				Namespace: cd.Namespace,
+				Name:      svc.Template,
+				Reason: fmt.Sprintf("k8s version %s of the ClusterTemplate %s the ClusterDeployment %s is to be upgraded to does not satisfy constrained version %s",
+					template.Status.KubernetesVersion, template.Name, cd.Name, constraint),
+			})
+		}
+	}
+
+	return result, nil
+}
+
+func (*ReleaseUpgradePlanReconciler) setReadyCondition(plan *hmc.ReleaseUpgradePlan, status metav1.ConditionStatus, reason, msg string) {
+	apimeta.SetStatusCondition(plan.GetConditions(), metav1.Condition{
+		Type:               hmc.ReadyCondition,
+		Status:             status,
+		Reason:             reason,
+		Message:            msg,
+		ObservedGeneration: plan.Generation,
+	})
+}
+
+func (r *ReleaseUpgradePlanReconciler) updateStatus(ctx context.Context, plan *hmc.ReleaseUpgradePlan) error {
+	if err := r.Status().Update(ctx, plan); err != nil {
+		return fmt.Errorf("failed to update status for ReleaseUpgradePlan %s: %w", plan.Name, err)
+	}
+
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ReleaseUpgradePlanReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	enqueueAllPlans := handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, _ client.Object) []ctrl.Request {
+		plans := new(hmc.ReleaseUpgradePlanList)
+		if err := r.List(ctx, plans); err != nil {
+			return nil
+		}
+
+		requests := make([]ctrl.Request, 0, len(plans.Items))
+		for _, plan := range plans.Items {
+			requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&plan)})
+		}
+		return requests
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&hmc.ReleaseUpgradePlan{}).
+		Watches(&hmc.Management{}, enqueueAllPlans).
+		Watches(&hmc.Release{}, enqueueAllPlans).
+		Complete(r)
+}
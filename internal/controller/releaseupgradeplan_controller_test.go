@@ -0,0 +1,268 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
+	"github.com/K0rdent/kcm/test/objects/clusterdeployment"
+	"github.com/K0rdent/kcm/test/objects/release"
+	"github.com/K0rdent/kcm/test/objects/template"
+)
+
+var _ = Describe("ReleaseUpgradePlan Controller", func() {
+	It("should list the changed ProviderTemplates", func() {
+		current := []component{
+			{Component: hmc.Component{Template: "hmc-0-0-5"}, helmReleaseName: "hmc"},
+			{Component: hmc.Component{Template: "cluster-api-0-0-4"}, helmReleaseName: "cluster-api"},
+			{Component: hmc.Component{Template: "cluster-api-provider-vsphere-0-0-3"}, helmReleaseName: "cluster-api-provider-vsphere"},
+		}
+		planned := []component{
+			{Component: hmc.Component{Template: "hmc-0-0-6"}, helmReleaseName: "hmc"},
+			{Component: hmc.Component{Template: "cluster-api-0-0-4"}, helmReleaseName: "cluster-api"},
+			{Component: hmc.Component{Template: "cluster-api-provider-aws-0-0-4"}, helmReleaseName: "cluster-api-provider-aws"},
+		}
+
+		Expect(providerTemplateChanges(current, planned)).To(Equal([]hmc.ProviderTemplateChange{
+			{Name: "hmc", From: "hmc-0-0-5", To: "hmc-0-0-6"},
+			{Name: "cluster-api-provider-aws", To: "cluster-api-provider-aws-0-0-4"},
+			{Name: "cluster-api-provider-vsphere", From: "cluster-api-provider-vsphere-0-0-3"},
+		}))
+	})
+
+	Context("When computing the plan", func() {
+		const (
+			currentRelease = "plan-test-0-0-1"
+			plannedRelease = "plan-test-0-0-2"
+
+			hmcTemplate        = "plan-hmc-0-0-1"
+			capiTemplate       = "plan-cluster-api-0-0-1"
+			currentAWSTemplate = "plan-cluster-api-provider-aws-0-0-1"
+			plannedAWSTemplate = "plan-cluster-api-provider-aws-0-0-2"
+		)
+
+		ctx := context.Background()
+		helmSpec := hmc.HelmSpec{ChartSpec: &sourcev1.HelmChartSpec{Chart: "test"}}
+
+		var namespace *corev1.Namespace
+
+		// createWithStatus creates the given object and sets the status it has been built with,
+		// since the status of a custom resource is not persisted on its creation.
+		createWithStatus := func(obj client.Object) {
+			GinkgoHelper()
+
+			withStatus := obj.DeepCopyObject().(client.Object)
+			Expect(k8sClient.Create(ctx, obj)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, obj)
+
+			withStatus.SetResourceVersion(obj.GetResourceVersion())
+			Expect(k8sClient.Status().Update(ctx, withStatus)).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			By("creating the namespace of the templates and the ClusterDeployments")
+			namespace = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{GenerateName: "plan-test-"}}
+			Expect(k8sClient.Create(ctx, namespace)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, namespace)
+
+			By("creating the current and the planned Releases")
+			for name, awsTemplate := range map[string]string{currentRelease: currentAWSTemplate, plannedRelease: plannedAWSTemplate} {
+				r := release.New(
+					release.WithName(name),
+					release.WithHMCTemplateName(hmcTemplate),
+					release.WithCAPITemplateName(capiTemplate),
+					release.WithProviders(hmc.NamedProviderTemplate{
+						Name:                 hmc.ProviderAWSName,
+						CoreProviderTemplate: hmc.CoreProviderTemplate{Template: awsTemplate},
+					}),
+				)
+				r.Spec.Version = "0.0.1"
+				createWithStatus(r)
+			}
+
+			By("creating the ProviderTemplates, the planned AWS provider dropping the v1beta1 contract")
+			createWithStatus(template.NewProviderTemplate(template.WithName(hmcTemplate), template.WithHelmSpec(helmSpec)))
+			createWithStatus(template.NewProviderTemplate(
+				template.WithName(capiTemplate),
+				template.WithHelmSpec(helmSpec),
+				template.WithProvidersStatus("cluster-api"),
+			))
+			createWithStatus(template.NewProviderTemplate(
+				template.WithName(currentAWSTemplate),
+				template.WithHelmSpec(helmSpec),
+				template.WithProvidersStatus("infrastructure-aws"),
+				template.WithProviderStatusCAPIContracts("v1beta1", "v1beta1_v1beta2"),
+			))
+			createWithStatus(template.NewProviderTemplate(
+				template.WithName(plannedAWSTemplate),
+				template.WithHelmSpec(helmSpec),
+				template.WithProvidersStatus("infrastructure-aws"),
+				template.WithProviderStatusCAPIContracts("v1beta1", "v1beta2"),
+			))
+
+			By("creating the Management")
+			mgmt := &hmc.Management{
+				ObjectMeta: metav1.ObjectMeta{Name: hmc.ManagementName},
+				Spec: hmc.ManagementSpec{
+					Release:   currentRelease,
+					Core:      &hmc.Core{},
+					Providers: []hmc.Provider{{Name: hmc.ProviderAWSName}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, mgmt)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, mgmt)
+		})
+
+		newClusterTemplate := func(name, k8sVersion string, valid bool, providerContracts hmc.CompatibilityContracts, providers ...string) *hmc.ClusterTemplate {
+			t := template.NewClusterTemplate(
+				template.WithName(name),
+				template.WithNamespace(namespace.Name),
+				template.WithHelmSpec(helmSpec),
+				template.WithValidationStatus(hmc.TemplateValidationStatus{Valid: valid}),
+				template.WithProvidersStatus(providers...),
+				template.WithClusterStatusK8sVersion(k8sVersion),
+			)
+			t.Status.ProviderContracts = providerContracts
+			return t
+		}
+
+		newClusterDeployment := func(name, clusterTemplate string, availableUpgrades []string, services ...hmc.ServiceSpec) *hmc.ClusterDeployment {
+			cd := clusterdeployment.NewClusterDeployment(
+				clusterdeployment.WithName(name),
+				clusterdeployment.WithNamespace(namespace.Name),
+				clusterdeployment.WithClusterTemplate(clusterTemplate),
+				clusterdeployment.WithConfig(`{}`),
+				clusterdeployment.WithAvailableUpgrades(availableUpgrades),
+			)
+			cd.Spec.Services = services
+			return cd
+		}
+
+		computePlan := func() *hmc.ReleaseUpgradePlan {
+			GinkgoHelper()
+
+			plan := &hmc.ReleaseUpgradePlan{
+				ObjectMeta: metav1.ObjectMeta{Name: plannedRelease},
+				Spec:       hmc.ReleaseUpgradePlanSpec{Release: plannedRelease},
+			}
+			Expect((&ReleaseUpgradePlanReconciler{Client: k8sClient}).computePlan(ctx, plan)).To(Succeed())
+			return plan
+		}
+
+		// inNamespace filters out the templates and the ClusterDeployments left over by the other specs.
+		inNamespace := func(templates []hmc.IncompatibleTemplate) []hmc.IncompatibleTemplate {
+			var result []hmc.IncompatibleTemplate
+			for _, t := range templates {
+				if t.Namespace == namespace.Name {
+					result = append(result, t)
+				}
+			}
+			return result
+		}
+
+		It("should list the ClusterTemplates incompatible with the providers of the planned Release", func() {
+			createWithStatus(newClusterTemplate("aws-v1beta1", "v1.30.0", true, hmc.CompatibilityContracts{"infrastructure-aws": "v1beta1"}, "infrastructure-aws"))
+			createWithStatus(newClusterTemplate("aws-v1beta2", "v1.30.0", true, hmc.CompatibilityContracts{"infrastructure-aws": "v1beta2"}, "infrastructure-aws"))
+			createWithStatus(newClusterTemplate("vsphere", "v1.30.0", true, nil, "infrastructure-vsphere"))
+			createWithStatus(newClusterTemplate("invalid", "v1.30.0", false, nil, "infrastructure-vsphere"))
+
+			plan := computePlan()
+
+			Expect(plan.Status.CurrentRelease).To(Equal(currentRelease))
+			Expect(plan.Status.ProviderTemplates).To(ConsistOf(hmc.ProviderTemplateChange{
+				Name: hmc.ProviderAWSName, From: currentAWSTemplate, To: plannedAWSTemplate,
+			}))
+
+			incompatible := inNamespace(plan.Status.IncompatibleClusterTemplates)
+			Expect(incompatible).To(HaveLen(2))
+			Expect(incompatible).To(ContainElement(And(
+				HaveField("Name", "aws-v1beta1"),
+				HaveField("Reason", ContainSubstring("provider infrastructure-aws does not support v1beta1")),
+			)))
+			Expect(incompatible).To(ContainElement(And(
+				HaveField("Name", "vsphere"),
+				HaveField("Reason", ContainSubstring("one or more required providers are not deployed yet: [infrastructure-vsphere]")),
+			)))
+		})
+
+		It("should list the affected ClusterDeployments along with the compatible template to upgrade to", func() {
+			createWithStatus(newClusterTemplate("aws-v1beta1", "v1.30.0", true, hmc.CompatibilityContracts{"infrastructure-aws": "v1beta1"}, "infrastructure-aws"))
+			createWithStatus(newClusterTemplate("aws-v1beta1-patch", "v1.30.1", true, hmc.CompatibilityContracts{"infrastructure-aws": "v1beta1"}, "infrastructure-aws"))
+			createWithStatus(newClusterTemplate("aws-v1beta2", "v1.30.0", true, hmc.CompatibilityContracts{"infrastructure-aws": "v1beta2"}, "infrastructure-aws"))
+			createWithStatus(newClusterTemplate("vsphere", "v1.30.0", true, nil, "infrastructure-vsphere"))
+
+			createWithStatus(newClusterDeployment("upgradable", "aws-v1beta1", []string{"aws-v1beta2", "aws-v1beta1-patch"}))
+			createWithStatus(newClusterDeployment("stuck", "vsphere", nil))
+			createWithStatus(newClusterDeployment("unaffected", "aws-v1beta2", nil))
+
+			plan := computePlan()
+
+			var affected []hmc.AffectedClusterDeployment
+			for _, cd := range plan.Status.AffectedClusterDeployments {
+				if cd.Namespace == namespace.Name {
+					affected = append(affected, cd)
+				}
+			}
+			Expect(affected).To(ConsistOf(
+				hmc.AffectedClusterDeployment{Namespace: namespace.Name, Name: "upgradable", Template: "aws-v1beta1", UpgradeTo: "aws-v1beta2"},
+				hmc.AffectedClusterDeployment{Namespace: namespace.Name, Name: "stuck", Template: "vsphere"},
+			))
+			Expect(plan.Status.Upgradable).To(BeFalse())
+		})
+
+		It("should list the ServiceTemplates whose constraints are not satisfied by the template to upgrade to", func() {
+			createWithStatus(newClusterTemplate("aws-v1beta1", "v1.29.0", true, hmc.CompatibilityContracts{"infrastructure-aws": "v1beta1"}, "infrastructure-aws"))
+			createWithStatus(newClusterTemplate("aws-v1beta2", "v1.31.0", true, hmc.CompatibilityContracts{"infrastructure-aws": "v1beta2"}, "infrastructure-aws"))
+
+			for name, constraint := range map[string]string{
+				"legacy-service":        "<1.30.0",
+				"compatible-service":    ">=1.29.0",
+				"unconstrained-service": "",
+				"disabled-service":      "<1.30.0",
+			} {
+				createWithStatus(template.NewServiceTemplate(
+					template.WithName(name),
+					template.WithNamespace(namespace.Name),
+					template.WithHelmSpec(helmSpec),
+					template.WithValidationStatus(hmc.TemplateValidationStatus{Valid: true}),
+					template.WithServiceK8sConstraint(constraint),
+				))
+			}
+
+			createWithStatus(newClusterDeployment("upgradable", "aws-v1beta1", []string{"aws-v1beta2"},
+				hmc.ServiceSpec{Name: "legacy", Template: "legacy-service"},
+				hmc.ServiceSpec{Name: "compatible", Template: "compatible-service"},
+				hmc.ServiceSpec{Name: "unconstrained", Template: "unconstrained-service"},
+				hmc.ServiceSpec{Name: "disabled", Template: "disabled-service", Disable: true},
+			))
+
+			plan := computePlan()
+
+			incompatible := inNamespace(plan.Status.IncompatibleServiceTemplates)
+			Expect(incompatible).To(HaveLen(1))
+			Expect(incompatible[0].Name).To(Equal("legacy-service"))
+			Expect(incompatible[0].Reason).To(ContainSubstring("k8s version v1.31.0 of the ClusterTemplate aws-v1beta2"))
+			Expect(incompatible[0].Reason).To(ContainSubstring("does not satisfy constrained version <1.30.0"))
+		})
+	})
+})
@@ -0,0 +1,554 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/yaml"
+
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
+)
+
+// RestoreReconciler reconciles a Restore object
+type RestoreReconciler struct {
+	client.Client
+	Scheme          *runtime.Scheme
+	SystemNamespace string
+}
+
+// restoreStage describes a single step of the management cluster restoration.
+type restoreStage struct {
+	// resources returns the resources to restore, all of the backed up resources are restored if empty.
+	resources func(ctx context.Context, cl client.Client) ([]string, error)
+	// ready reports whether the restored objects have been reconciled, otherwise
+	// returns a message describing what is being waited for.
+	ready func(ctx context.Context, cl client.Client) (bool, string, error)
+	name  hmc.RestoreStage
+	// updateExisting instructs velero to update the objects already existing in the cluster,
+	// e.g. the Management created by the controller on startup.
+	updateExisting bool
+	// pauseClusters pauses the restored CAPI Clusters until the restoration is completed.
+	pauseClusters bool
+	// mapNamespaces restores the objects into the namespaces according to the Restore namespace mapping.
+	mapNamespaces bool
+}
+
+// restoreStages are the ordered steps of the restoration, each of them
+// has to be completed before the next one begins.
+var restoreStages = []restoreStage{
+	{
+		name:           hmc.RestoreStageReleases,
+		resources:      staticResources("releases." + hmc.GroupVersion.Group),
+		updateExisting: true,
+	},
+	{
+		name: hmc.RestoreStageManagement,
+		resources: staticResources(
+			"providertemplates."+hmc.GroupVersion.Group,
+			"managements."+hmc.GroupVersion.Group,
+			"accessmanagements."+hmc.GroupVersion.Group,
+		),
+		ready:          managementRestored,
+		updateExisting: true,
+	},
+	{
+		name:      hmc.RestoreStageCredentials,
+		resources: credentialsResources,
+		ready:     credentialsRestored,
+	},
+	{
+		name:          hmc.RestoreStageClusters,
+		pauseClusters: true,
+	},
+}
+
+// clusterDeploymentsRestoreStages are the steps of the restoration from a ClusterDeployment scoped backup.
+// The management components are expected to be already installed, hence everything is restored at once.
+var clusterDeploymentsRestoreStages = []restoreStage{
+	{
+		name:          hmc.RestoreStageClusterDeployments,
+		pauseClusters: true,
+		mapNamespaces: true,
+	},
+}
+
+func (r *RestoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	l := ctrl.LoggerFrom(ctx)
+	l.Info("Reconciling Restore")
+
+	restore := new(hmc.Restore)
+	if err := r.Get(ctx, req.NamespacedName, restore); err != nil {
+		if apierrors.IsNotFound(err) {
+			l.Info("Restore not found, ignoring since object must be deleted")
+			return ctrl.Result{}, nil
+		}
+
+		l.Error(err, "Failed to get Restore")
+		return ctrl.Result{}, err
+	}
+
+	if restore.Status.Stage == hmc.RestoreStageCompleted {
+		return ctrl.Result{}, nil
+	}
+
+	if cond := apimeta.FindStatusCondition(restore.Status.Conditions, hmc.ReadyCondition); cond != nil && cond.Reason == hmc.FailedReason {
+		l.Info("Restore has failed, manual intervention is required")
+		return ctrl.Result{}, nil
+	}
+
+	restore.Status.ObservedGeneration = restore.Generation
+
+	veleroBackup := new(velerov1.Backup)
+	if err := r.Get(ctx, client.ObjectKey{Namespace: r.SystemNamespace, Name: restore.Spec.BackupName}, veleroBackup); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, fmt.Errorf("failed to get velero Backup %s: %w", restore.Spec.BackupName, err)
+		}
+
+		// velero Backups are synced from the backup storage location periodically
+		r.setReadyCondition(restore, metav1.ConditionFalse, hmc.ProgressingReason,
+			fmt.Sprintf("Waiting for velero Backup %s to be synced from the backup storage", restore.Spec.BackupName))
+		return ctrl.Result{RequeueAfter: DefaultRequeueInterval}, r.updateStatus(ctx, restore)
+	}
+
+	stages := restoreStages
+	if veleroBackup.Labels[hmc.BackupScopeLabel] == hmc.BackupScopeClusterDeployments {
+		stages = clusterDeploymentsRestoreStages
+	}
+
+	// already passed stages are not reconciled again
+	start := max(slices.IndexFunc(stages, func(s restoreStage) bool { return s.name == restore.Status.Stage }), 0)
+	for _, stage := range stages[start:] {
+		restore.Status.Stage = stage.name
+
+		veleroRestore, err := r.ensureVeleroRestore(ctx, restore, stage)
+		if err != nil {
+			r.setReadyCondition(restore, metav1.ConditionFalse, hmc.ProgressingReason, err.Error())
+			return ctrl.Result{}, errors.Join(err, r.updateStatus(ctx, restore))
+		}
+
+		setRestoreStageStatus(restore, stage.name, veleroRestore)
+
+		switch veleroRestore.Status.Phase {
+		case velerov1.RestorePhaseCompleted, velerov1.RestorePhasePartiallyFailed:
+			// partial failures are tolerated since the errors are reported in the stage status
+		case velerov1.RestorePhaseFailed, velerov1.RestorePhaseFailedValidation:
+			reason := veleroRestore.Status.FailureReason
+			if len(veleroRestore.Status.ValidationErrors) > 0 {
+				reason = strings.Join(veleroRestore.Status.ValidationErrors, "; ")
+			}
+			msg := fmt.Sprintf("Velero Restore %s has failed at the %s stage: %s", veleroRestore.Name, stage.name, reason)
+			r.setReadyCondition(restore, metav1.ConditionFalse, hmc.FailedReason, msg)
+			return ctrl.Result{}, r.updateStatus(ctx, restore)
+		default:
+			r.setReadyCondition(restore, metav1.ConditionFalse, hmc.ProgressingReason,
+				fmt.Sprintf("Waiting for velero Restore %s to be completed", veleroRestore.Name))
+			return ctrl.Result{RequeueAfter: DefaultRequeueInterval}, r.updateStatus(ctx, restore)
+		}
+
+		if stage.ready == nil {
+			continue
+		}
+
+		ready, msg, err := stage.ready(ctx, r.Client)
+		if err != nil {
+			return ctrl.Result{}, errors.Join(err, r.updateStatus(ctx, restore))
+		}
+		if !ready {
+			r.setReadyCondition(restore, metav1.ConditionFalse, hmc.ProgressingReason, msg)
+			return ctrl.Result{RequeueAfter: DefaultRequeueInterval}, r.updateStatus(ctx, restore)
+		}
+	}
+
+	if err := r.unpauseClusters(ctx, restore); err != nil {
+		r.setReadyCondition(restore, metav1.ConditionFalse, hmc.ProgressingReason, err.Error())
+		return ctrl.Result{}, errors.Join(err, r.updateStatus(ctx, restore))
+	}
+
+	restore.Status.Stage = hmc.RestoreStageCompleted
+	r.setReadyCondition(restore, metav1.ConditionTrue, hmc.SucceededReason, "Management cluster has been restored")
+
+	l.Info("Management cluster has been restored", "backup", restore.Spec.BackupName)
+	return ctrl.Result{}, r.updateStatus(ctx, restore)
+}
+
+// ensureVeleroRestore creates the Velero Restore for the given stage if it does not exist yet.
+func (r *RestoreReconciler) ensureVeleroRestore(ctx context.Context, restore *hmc.Restore, stage restoreStage) (*velerov1.Restore, error) {
+	l := ctrl.LoggerFrom(ctx)
+
+	veleroRestore := &velerov1.Restore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      restore.Name + "-" + strings.ToLower(string(stage.name)),
+			Namespace: r.SystemNamespace,
+		},
+	}
+
+	// velero restores are not supposed to be changed after the creation
+	err := r.Get(ctx, client.ObjectKeyFromObject(veleroRestore), veleroRestore)
+	if err == nil {
+		return veleroRestore, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get velero Restore %s: %w", client.ObjectKeyFromObject(veleroRestore), err)
+	}
+
+	veleroRestore.Spec = velerov1.RestoreSpec{
+		BackupName:              restore.Spec.BackupName,
+		IncludedNamespaces:      []string{"*"},
+		IncludeClusterResources: ptr.To(true),
+	}
+
+	if stage.resources != nil {
+		if veleroRestore.Spec.IncludedResources, err = stage.resources(ctx, r.Client); err != nil {
+			return nil, fmt.Errorf("failed to get resources to restore at the %s stage: %w", stage.name, err)
+		}
+	}
+
+	if stage.updateExisting {
+		veleroRestore.Spec.ExistingResourcePolicy = velerov1.PolicyTypeUpdate
+	}
+
+	var namespaceMapping map[string]string
+	if stage.mapNamespaces {
+		namespaceMapping = restore.Spec.NamespaceMapping
+		veleroRestore.Spec.NamespaceMapping = namespaceMapping
+	}
+
+	if stage.pauseClusters {
+		cm, err := r.ensureResourceModifiers(ctx, restore, namespaceMapping)
+		if err != nil {
+			return nil, err
+		}
+
+		veleroRestore.Spec.ResourceModifier = &corev1.TypedLocalObjectReference{
+			Kind: "ConfigMap",
+			Name: cm.Name,
+		}
+	}
+
+	if err := controllerutil.SetControllerReference(restore, veleroRestore, r.Scheme); err != nil {
+		return nil, fmt.Errorf("failed to set controller reference to velero Restore %s: %w", client.ObjectKeyFromObject(veleroRestore), err)
+	}
+
+	if err := r.Create(ctx, veleroRestore); err != nil {
+		return nil, fmt.Errorf("failed to create velero Restore %s: %w", client.ObjectKeyFromObject(veleroRestore), err)
+	}
+
+	l.Info("Created velero Restore", "velero_restore", client.ObjectKeyFromObject(veleroRestore), "stage", stage.name)
+	return veleroRestore, nil
+}
+
+// ensureResourceModifiers ensures the ConfigMap with the velero resource modifiers exists.
+// The modifiers pause the restored CAPI Clusters marking them with the Restore name and
+// point the references between the CAPI objects to the namespaces the objects are mapped to.
+func (r *RestoreReconciler) ensureResourceModifiers(ctx context.Context, restore *hmc.Restore, namespaceMapping map[string]string) (*corev1.ConfigMap, error) {
+	modifiers, err := yaml.Marshal(getResourceModifiers(restore.Name, namespaceMapping))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal resource modifiers: %w", err)
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      restore.Name + "-resource-modifiers",
+			Namespace: r.SystemNamespace,
+		},
+	}
+
+	_, err = ctrl.CreateOrUpdate(ctx, r.Client, cm, func() error {
+		// velero expects exactly one key in the ConfigMap
+		cm.Data = map[string]string{"resource-modifiers.yaml": string(modifiers)}
+		return controllerutil.SetControllerReference(restore, cm, r.Scheme)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile resource modifiers ConfigMap %s: %w", client.ObjectKeyFromObject(cm), err)
+	}
+
+	return cm, nil
+}
+
+// resourceModifiers mirrors the format of the velero resource modifiers.
+type resourceModifiers struct {
+	Version string                 `json:"version"`
+	Rules   []resourceModifierRule `json:"resourceModifierRules"`
+}
+
+type resourceModifierRule struct {
+	Conditions   resourceModifierConditions `json:"conditions"`
+	Patches      []resourceModifierPatch    `json:"patches,omitempty"`
+	MergePatches []resourceModifierPatch    `json:"mergePatches,omitempty"`
+}
+
+type resourceModifierConditions struct {
+	GroupResource string                  `json:"groupResource"`
+	Namespaces    []string                `json:"namespaces,omitempty"`
+	Matches       []resourceModifierPatch `json:"matches,omitempty"`
+}
+
+type resourceModifierPatch struct {
+	Operation string `json:"operation,omitempty"`
+	Path      string `json:"path,omitempty"`
+	Value     string `json:"value,omitempty"`
+	PatchData string `json:"patchData,omitempty"`
+}
+
+// capiNamespacedReferences are the paths of the namespaced references between the CAPI objects,
+// CAPI forbids to reference objects in other namespaces.
+var capiNamespacedReferences = map[string][]string{
+	"clusters.cluster.x-k8s.io": {"/spec/infrastructureRef/namespace", "/spec/controlPlaneRef/namespace"},
+	"machines.cluster.x-k8s.io": {"/spec/infrastructureRef/namespace", "/spec/bootstrap/configRef/namespace"},
+	"machinesets.cluster.x-k8s.io": {
+		"/spec/template/spec/infrastructureRef/namespace", "/spec/template/spec/bootstrap/configRef/namespace",
+	},
+	"machinedeployments.cluster.x-k8s.io": {
+		"/spec/template/spec/infrastructureRef/namespace", "/spec/template/spec/bootstrap/configRef/namespace",
+	},
+	"machinepools.cluster.x-k8s.io": {
+		"/spec/template/spec/infrastructureRef/namespace", "/spec/template/spec/bootstrap/configRef/namespace",
+	},
+	"*.controlplane.cluster.x-k8s.io": {"/spec/machineTemplate/infrastructureRef/namespace"},
+}
+
+// getResourceModifiers returns the velero resource modifiers pausing the restored CAPI Clusters
+// and rewriting the namespaces of the references between the CAPI objects according to the given mapping.
+func getResourceModifiers(restoreName string, namespaceMapping map[string]string) *resourceModifiers {
+	modifiers := &resourceModifiers{
+		Version: "v1",
+		Rules: []resourceModifierRule{
+			{
+				Conditions: resourceModifierConditions{GroupResource: "clusters.cluster.x-k8s.io"},
+				MergePatches: []resourceModifierPatch{{
+					PatchData: fmt.Sprintf(`{"metadata": {"annotations": {%q: %q}}, "spec": {"paused": true}}`, hmc.RestorePausedAnnotation, restoreName),
+				}},
+			},
+		},
+	}
+
+	groupResources := slices.Sorted(maps.Keys(capiNamespacedReferences))
+	for _, oldNamespace := range slices.Sorted(maps.Keys(namespaceMapping)) {
+		newNamespace := namespaceMapping[oldNamespace]
+		for _, gr := range groupResources {
+			for _, path := range capiNamespacedReferences[gr] {
+				modifiers.Rules = append(modifiers.Rules, resourceModifierRule{
+					Conditions: resourceModifierConditions{
+						GroupResource: gr,
+						Namespaces:    []string{oldNamespace},
+						Matches:       []resourceModifierPatch{{Path: path, Value: oldNamespace}},
+					},
+					Patches: []resourceModifierPatch{{Operation: "replace", Path: path, Value: newNamespace}},
+				})
+			}
+		}
+	}
+
+	return modifiers
+}
+
+// unpauseClusters unpauses the CAPI Clusters previously paused by the given Restore.
+func (r *RestoreReconciler) unpauseClusters(ctx context.Context, restore *hmc.Restore) error {
+	l := ctrl.LoggerFrom(ctx)
+
+	clusters := new(unstructured.UnstructuredList)
+	clusters.SetGroupVersionKind(schema.GroupVersionKind{Group: "cluster.x-k8s.io", Version: "v1beta1", Kind: "ClusterList"})
+	if err := r.List(ctx, clusters); err != nil {
+		if apimeta.IsNoMatchError(err) {
+			return nil
+		}
+
+		return fmt.Errorf("failed to list CAPI Clusters: %w", err)
+	}
+
+	patch := client.RawPatch(types.MergePatchType, []byte(fmt.Sprintf(`{"metadata":{"annotations":{%q:null}},"spec":{"paused":false}}`, hmc.RestorePausedAnnotation)))
+	for _, cluster := range clusters.Items {
+		if cluster.GetAnnotations()[hmc.RestorePausedAnnotation] != restore.Name {
+			continue
+		}
+
+		if err := r.Patch(ctx, &cluster, patch); err != nil {
+			return fmt.Errorf("failed to unpause CAPI Cluster %s: %w", client.ObjectKeyFromObject(&cluster), err)
+		}
+
+		l.Info("Unpaused restored CAPI Cluster", "cluster", client.ObjectKeyFromObject(&cluster))
+	}
+
+	return nil
+}
+
+func staticResources(resources ...string) func(context.Context, client.Client) ([]string, error) {
+	return func(context.Context, client.Client) ([]string, error) {
+		return resources, nil
+	}
+}
+
+// credentialsResources returns the templates, the Credentials, the Secrets and the cluster identities
+// of the providers installed during the previous stages, ClusterDeployments cannot be admitted without them.
+func credentialsResources(ctx context.Context, cl client.Client) ([]string, error) {
+	resources := []string{
+		"secrets",
+		"clustertemplates." + hmc.GroupVersion.Group,
+		"clustertemplatechains." + hmc.GroupVersion.Group,
+		"servicetemplates." + hmc.GroupVersion.Group,
+		"servicetemplatechains." + hmc.GroupVersion.Group,
+		"credentials." + hmc.GroupVersion.Group,
+	}
+
+	crds := new(metav1.PartialObjectMetadataList)
+	crds.SetGroupVersionKind(apiextensionsv1.SchemeGroupVersion.WithKind("CustomResourceDefinitionList"))
+	if err := cl.List(ctx, crds); err != nil {
+		return nil, fmt.Errorf("failed to list CustomResourceDefinitions: %w", err)
+	}
+
+	for _, crd := range crds.Items {
+		plural, group, ok := strings.Cut(crd.Name, ".")
+		if ok && group == "infrastructure.cluster.x-k8s.io" && strings.Contains(plural, "identit") {
+			resources = append(resources, crd.Name)
+		}
+	}
+
+	return resources, nil
+}
+
+// managementRestored reports whether the restored Management has been reconciled
+// and all of its components have been successfully installed.
+func managementRestored(ctx context.Context, cl client.Client) (bool, string, error) {
+	mgmt := new(hmc.Management)
+	if err := cl.Get(ctx, client.ObjectKey{Name: hmc.ManagementName}, mgmt); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, "Waiting for the Management to be restored", nil
+		}
+
+		return false, "", fmt.Errorf("failed to get Management: %w", err)
+	}
+
+	if mgmt.Status.ObservedGeneration != mgmt.Generation {
+		return false, "Waiting for the Management to be reconciled", nil
+	}
+
+	var notReady []string
+	for name, component := range mgmt.Status.Components {
+		if !component.Success {
+			notReady = append(notReady, name)
+		}
+	}
+
+	if len(notReady) > 0 {
+		slices.Sort(notReady)
+		return false, "Waiting for the Management components to be installed: " + strings.Join(notReady, ", "), nil
+	}
+
+	return true, "", nil
+}
+
+// credentialsRestored reports whether the restored templates and Credentials have been processed.
+func credentialsRestored(ctx context.Context, cl client.Client) (bool, string, error) {
+	var pending []string
+
+	clusterTemplates := new(hmc.ClusterTemplateList)
+	if err := cl.List(ctx, clusterTemplates); err != nil {
+		return false, "", fmt.Errorf("failed to list ClusterTemplates: %w", err)
+	}
+	for _, t := range clusterTemplates.Items {
+		if t.Status.ObservedGeneration != t.Generation {
+			pending = append(pending, hmc.ClusterTemplateKind+" "+t.Namespace+"/"+t.Name)
+		}
+	}
+
+	serviceTemplates := new(hmc.ServiceTemplateList)
+	if err := cl.List(ctx, serviceTemplates); err != nil {
+		return false, "", fmt.Errorf("failed to list ServiceTemplates: %w", err)
+	}
+	for _, t := range serviceTemplates.Items {
+		if t.Status.ObservedGeneration != t.Generation {
+			pending = append(pending, hmc.ServiceTemplateKind+" "+t.Namespace+"/"+t.Name)
+		}
+	}
+
+	credentials := new(hmc.CredentialList)
+	if err := cl.List(ctx, credentials); err != nil {
+		return false, "", fmt.Errorf("failed to list Credentials: %w", err)
+	}
+	for _, cred := range credentials.Items {
+		if apimeta.FindStatusCondition(cred.Status.Conditions, hmc.CredentialReadyCondition) == nil {
+			pending = append(pending, "Credential "+cred.Namespace+"/"+cred.Name)
+		}
+	}
+
+	if len(pending) > 0 {
+		return false, "Waiting for the restored objects to be processed: " + strings.Join(pending, ", "), nil
+	}
+
+	return true, "", nil
+}
+
+func setRestoreStageStatus(restore *hmc.Restore, stage hmc.RestoreStage, veleroRestore *velerov1.Restore) {
+	status := hmc.RestoreStageStatus{
+		Stage:    stage,
+		Name:     veleroRestore.Name,
+		Phase:    veleroRestore.Status.Phase,
+		Warnings: veleroRestore.Status.Warnings,
+		Errors:   veleroRestore.Status.Errors,
+	}
+
+	if i := slices.IndexFunc(restore.Status.Stages, func(s hmc.RestoreStageStatus) bool { return s.Stage == stage }); i >= 0 {
+		restore.Status.Stages[i] = status
+		return
+	}
+
+	restore.Status.Stages = append(restore.Status.Stages, status)
+}
+
+func (*RestoreReconciler) setReadyCondition(restore *hmc.Restore, status metav1.ConditionStatus, reason, msg string) {
+	apimeta.SetStatusCondition(restore.GetConditions(), metav1.Condition{
+		Type:               hmc.ReadyCondition,
+		Status:             status,
+		Reason:             reason,
+		Message:            msg,
+		ObservedGeneration: restore.Generation,
+	})
+}
+
+func (r *RestoreReconciler) updateStatus(ctx context.Context, restore *hmc.Restore) error {
+	if err := r.Status().Update(ctx, restore); err != nil {
+		return fmt.Errorf("failed to update status for Restore %s: %w", restore.Name, err)
+	}
+
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *RestoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&hmc.Restore{}).
+		Owns(&velerov1.Restore{}).
+		Complete(r)
+}
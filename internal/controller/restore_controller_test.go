@@ -0,0 +1,118 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	hmcmirantiscomv1alpha1 "github.com/K0rdent/kcm/api/v1alpha1"
+	"github.com/K0rdent/kcm/internal/utils"
+)
+
+var _ = Describe("Restore Controller", func() {
+	Context("When reconciling a resource", func() {
+		const (
+			restoreName = "test-restore"
+			backupName  = "test-backup"
+		)
+
+		ctx := context.Background()
+
+		restore := &hmcmirantiscomv1alpha1.Restore{}
+
+		BeforeEach(func() {
+			By("creating the system namespace")
+			Expect(client.IgnoreAlreadyExists(k8sClient.Create(ctx, &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.DefaultSystemNamespace,
+				},
+			}))).To(Succeed())
+
+			By("creating the Restore")
+			restore = &hmcmirantiscomv1alpha1.Restore{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: restoreName,
+				},
+				Spec: hmcmirantiscomv1alpha1.RestoreSpec{
+					BackupName: backupName,
+				},
+			}
+			Expect(k8sClient.Create(ctx, restore)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			By("Cleanup the velero Restores")
+			Expect(k8sClient.DeleteAllOf(ctx, &velerov1.Restore{}, client.InNamespace(utils.DefaultSystemNamespace))).To(Succeed())
+
+			By("Cleanup the Restore")
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, restore))).To(Succeed())
+		})
+
+		It("should restore the management cluster in stages", func() {
+			controllerReconciler := &RestoreReconciler{
+				Client:          k8sClient,
+				Scheme:          k8sClient.Scheme(),
+				SystemNamespace: utils.DefaultSystemNamespace,
+			}
+
+			By("Reconciling the created resource")
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: client.ObjectKeyFromObject(restore),
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking the velero Restore of the Releases stage has been created")
+			releasesRestore := &velerov1.Restore{}
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: restoreName + "-releases", Namespace: utils.DefaultSystemNamespace}, releasesRestore)).To(Succeed())
+			Expect(releasesRestore.Spec.BackupName).To(Equal(backupName))
+			Expect(releasesRestore.Spec.IncludedResources).To(ConsistOf("releases.hmc.mirantis.com"))
+			Expect(releasesRestore.Spec.ExistingResourcePolicy).To(Equal(velerov1.PolicyTypeUpdate))
+			Expect(metav1.IsControlledBy(releasesRestore, restore)).To(BeTrue())
+
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(restore), restore)).To(Succeed())
+			Expect(restore.Status.Stage).To(Equal(hmcmirantiscomv1alpha1.RestoreStageReleases))
+			Expect(restore.Status.Stages).To(HaveLen(1))
+			Expect(apimeta.IsStatusConditionFalse(restore.Status.Conditions, hmcmirantiscomv1alpha1.ReadyCondition)).To(BeTrue())
+
+			By("Completing the velero Restore of the Releases stage")
+			releasesRestore.Status.Phase = velerov1.RestorePhaseCompleted
+			Expect(k8sClient.Update(ctx, releasesRestore)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: client.ObjectKeyFromObject(restore),
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking the velero Restore of the Management stage has been created")
+			mgmtRestore := &velerov1.Restore{}
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: restoreName + "-management", Namespace: utils.DefaultSystemNamespace}, mgmtRestore)).To(Succeed())
+			Expect(mgmtRestore.Spec.IncludedResources).To(ContainElement("managements.hmc.mirantis.com"))
+
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(restore), restore)).To(Succeed())
+			Expect(restore.Status.Stage).To(Equal(hmcmirantiscomv1alpha1.RestoreStageManagement))
+			Expect(restore.Status.Stages).To(HaveLen(2))
+			Expect(restore.Status.Stages[0].Phase).To(Equal(velerov1.RestorePhaseCompleted))
+		})
+	})
+})
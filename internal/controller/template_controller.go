@@ -349,7 +349,18 @@ func (r *ClusterTemplateReconciler) validateCompatibilityAttrs(ctx context.Conte
 		return err
 	}
 
-	exposedProviders, requiredProviders := management.Status.AvailableProviders, template.Status.Providers
+	if err := checkClusterTemplateCompatibility(ctx, template, management.Status.AvailableProviders, management.Status.CAPIContracts); err != nil {
+		_ = r.updateStatus(ctx, template, err.Error())
+		return err
+	}
+
+	return r.updateStatus(ctx, template, "")
+}
+
+// checkClusterTemplateCompatibility checks the given ClusterTemplate against the given exposed providers
+// and their CAPI contracts, i.e. the ones of the Management.
+func checkClusterTemplateCompatibility(ctx context.Context, template *hmc.ClusterTemplate, exposedProviders []string, capiContracts map[string]hmc.CompatibilityContracts) error {
+	requiredProviders := template.Status.Providers
 
 	l := ctrl.LoggerFrom(ctx)
 	l.V(1).Info("providers to check", "exposed", exposedProviders, "required", requiredProviders)
@@ -368,9 +379,9 @@ func (r *ClusterTemplateReconciler) validateCompatibilityAttrs(ctx context.Conte
 
 	// already validated contract versions format
 	for providerName, requiredContract := range template.Status.ProviderContracts {
-		l.V(1).Info("validating contracts", "exposed_provider_capi_contracts", management.Status.CAPIContracts, "required_provider_name", providerName)
+		l.V(1).Info("validating contracts", "exposed_provider_capi_contracts", capiContracts, "required_provider_name", providerName)
 
-		providerCAPIContracts, ok := management.Status.CAPIContracts[providerName] // capi_version: provider_version(s)
+		providerCAPIContracts, ok := capiContracts[providerName] // capi_version: provider_version(s)
 		if !ok {
 			continue // both the provider and cluster templates contract versions must be set for the validation
 		}
@@ -396,12 +407,7 @@ func (r *ClusterTemplateReconciler) validateCompatibilityAttrs(ctx context.Conte
 		merr = errors.Join(merr, fmt.Errorf("one or more required provider contract versions does not satisfy deployed: %v", nonSatisfying))
 	}
 
-	if merr != nil {
-		_ = r.updateStatus(ctx, template, merr.Error())
-		return merr
-	}
-
-	return r.updateStatus(ctx, template, "")
+	return merr
 }
 
 // SetupWithManager sets up the controller with the Manager.
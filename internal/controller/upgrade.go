@@ -0,0 +1,233 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	hcv2 "github.com/fluxcd/helm-controller/api/v2"
+	fluxconditions "github.com/fluxcd/pkg/runtime/conditions"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
+	"github.com/K0rdent/kcm/internal/utils/status"
+)
+
+// startUpgrade records the upgrade of the given ClusterDeployment to another template if the staged upgrades
+// are enabled, otherwise the template is considered applied right away.
+func startUpgrade(clusterDeployment *hmc.ClusterDeployment) {
+	upgrade := clusterDeployment.Status.Upgrade
+	upgrading := upgrade != nil && upgrade.Phase == hmc.UpgradePhaseProgressing
+
+	if clusterDeployment.Spec.Upgrade == nil || clusterDeployment.Status.Template == "" ||
+		clusterDeployment.Status.Template == clusterDeployment.Spec.Template {
+		if upgrading {
+			now := metav1.Now()
+			upgrade.CompletionTime = &now
+			if clusterDeployment.Status.Template == clusterDeployment.Spec.Template {
+				upgrade.Phase = hmc.UpgradePhaseRolledBack
+				upgrade.Message = "Upgrade has been reverted"
+			} else {
+				upgrade.Phase = hmc.UpgradePhaseFailed
+				upgrade.Message = "Staged upgrades have been disabled"
+			}
+		}
+
+		clusterDeployment.Status.Template = clusterDeployment.Spec.Template
+		return
+	}
+
+	if upgrading && upgrade.ToTemplate == clusterDeployment.Spec.Template {
+		return
+	}
+
+	now := metav1.Now()
+	clusterDeployment.Status.Upgrade = &hmc.UpgradeStatus{
+		StartTime:    &now,
+		FromTemplate: clusterDeployment.Status.Template,
+		ToTemplate:   clusterDeployment.Spec.Template,
+		Phase:        hmc.UpgradePhaseProgressing,
+		Message:      "Upgrade has started",
+	}
+}
+
+// reconcileUpgrade completes the upgrade of the given ClusterDeployment once the cluster passes the health checks,
+// rolls it back to the previous template if it has not passed them within the timeout.
+// clusterHealthy reports whether the health checks of the ClusterTemplate have passed.
+func (r *ClusterDeploymentReconciler) reconcileUpgrade(ctx context.Context, clusterDeployment *hmc.ClusterDeployment, hr *hcv2.HelmRelease, clusterHealthy bool) (ctrl.Result, error) {
+	l := ctrl.LoggerFrom(ctx)
+
+	upgrade := clusterDeployment.Status.Upgrade
+	if clusterDeployment.Spec.Upgrade == nil || upgrade == nil || upgrade.Phase != hmc.UpgradePhaseProgressing {
+		return ctrl.Result{}, nil
+	}
+
+	healthy, reason, err := r.checkUpgradeHealth(ctx, clusterDeployment, hr, clusterHealthy)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	now := metav1.Now()
+	if healthy {
+		upgrade.Phase = hmc.UpgradePhaseSucceeded
+		upgrade.Message = "Cluster has been upgraded"
+		upgrade.CompletionTime = &now
+		clusterDeployment.Status.Template = clusterDeployment.Spec.Template
+		completeRevision(clusterDeployment, hmc.RevisionOutcomeDeployed, upgrade.Message)
+		l.Info("Cluster has been upgraded", "from", upgrade.FromTemplate, "to", upgrade.ToTemplate)
+		return ctrl.Result{}, nil
+	}
+
+	upgrade.Message = reason
+	if upgrade.StartTime == nil || time.Since(upgrade.StartTime.Time) < clusterDeployment.Spec.Upgrade.Timeout.Duration {
+		return ctrl.Result{RequeueAfter: DefaultRequeueInterval}, nil
+	}
+
+	if clusterDeployment.Spec.Upgrade.DisableRollback {
+		upgrade.Phase = hmc.UpgradePhaseFailed
+		upgrade.Message = "Upgrade has not completed within the timeout: " + reason
+		upgrade.CompletionTime = &now
+		clusterDeployment.Status.Template = clusterDeployment.Spec.Template
+		completeRevision(clusterDeployment, hmc.RevisionOutcomeFailed, upgrade.Message)
+		l.Info("Cluster upgrade has failed", "from", upgrade.FromTemplate, "to", upgrade.ToTemplate, "reason", reason)
+		return ctrl.Result{}, nil
+	}
+
+	// the patch overrides the status with the stored one, hence preserve the observed state
+	observedStatus := clusterDeployment.Status.DeepCopy()
+	original := clusterDeployment.DeepCopy()
+	clusterDeployment.Spec.Template = upgrade.FromTemplate
+	if err := r.Patch(ctx, clusterDeployment, client.MergeFrom(original)); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to roll back clusterDeployment %s/%s to template %s: %w", clusterDeployment.Namespace, clusterDeployment.Name, upgrade.FromTemplate, err)
+	}
+	clusterDeployment.Status = *observedStatus
+
+	upgrade = clusterDeployment.Status.Upgrade
+	upgrade.Phase = hmc.UpgradePhaseRolledBack
+	upgrade.Message = "Upgrade has not completed within the timeout, rolled back: " + reason
+	upgrade.CompletionTime = &now
+	completeRevision(clusterDeployment, hmc.RevisionOutcomeRolledBack, upgrade.Message)
+	l.Info("Rolled back the cluster upgrade", "from", upgrade.FromTemplate, "to", upgrade.ToTemplate, "reason", reason)
+
+	return ctrl.Result{Requeue: true}, nil
+}
+
+// checkUpgradeHealth checks whether the upgraded cluster has been rolled out and passed the configured health checks,
+// returns the reason otherwise.
+func (r *ClusterDeploymentReconciler) checkUpgradeHealth(ctx context.Context, clusterDeployment *hmc.ClusterDeployment, hr *hcv2.HelmRelease, clusterHealthy bool) (healthy bool, reason string, _ error) {
+	if hr.Status.ObservedGeneration != hr.Generation || !fluxconditions.IsReady(hr) {
+		return false, "HelmRelease is not ready", nil
+	}
+
+	clusters, err := r.listCAPIObjects(ctx, clusterDeployment.Namespace, "ClusterList",
+		client.MatchingLabels{hmc.FluxHelmChartNameKey: clusterDeployment.Name})
+	if err != nil {
+		return false, "", err
+	}
+
+	checks := clusterDeployment.Spec.Upgrade.Checks
+	for _, cluster := range clusters {
+		controlPlane, found, err := r.getControlPlane(ctx, &cluster)
+		if err != nil {
+			return false, "", err
+		}
+		if found {
+			if rolledOut, reason := replicasRolledOut(controlPlane); !rolledOut {
+				return false, reason, nil
+			}
+		}
+
+		selector := client.MatchingLabels{hmc.ClusterNameLabelKey: cluster.GetName()}
+		for _, listKind := range []string{"MachineDeploymentList", "MachinePoolList"} {
+			items, err := r.listCAPIObjects(ctx, cluster.GetNamespace(), listKind, selector)
+			if err != nil {
+				return false, "", err
+			}
+			for _, item := range items {
+				if rolledOut, reason := replicasRolledOut(&item); !rolledOut {
+					return false, reason, nil
+				}
+			}
+		}
+
+		if !slices.Contains(checks, hmc.UpgradeCheckNodes) {
+			continue
+		}
+
+		machines, err := r.listCAPIObjects(ctx, cluster.GetNamespace(), "MachineList", selector)
+		if err != nil {
+			return false, "", err
+		}
+		for _, machine := range machines {
+			conditions, err := status.ConditionsFromUnstructured(&machine)
+			if err != nil {
+				return false, "", err
+			}
+			if !apimeta.IsStatusConditionTrue(conditions, "NodeHealthy") {
+				return false, fmt.Sprintf("Node of Machine %s is not healthy", machine.GetName()), nil
+			}
+		}
+	}
+
+	if slices.Contains(checks, hmc.UpgradeCheckCluster) && !clusterHealthy {
+		return false, "Cluster health checks have not passed", nil
+	}
+
+	if slices.Contains(checks, hmc.UpgradeCheckServices) {
+		for _, svc := range clusterDeployment.Status.Services {
+			for _, c := range svc.Conditions {
+				if c.Status != metav1.ConditionTrue {
+					return false, fmt.Sprintf("Service %s is not deployed: %s", c.Type, c.Message), nil
+				}
+			}
+		}
+	}
+
+	return true, "", nil
+}
+
+// replicasRolledOut checks whether all of the replicas of the given CAPI object managing a set of machines
+// have been updated and are ready, returns the reason otherwise. The objects without replicas are considered rolled out.
+func replicasRolledOut(obj *unstructured.Unstructured) (rolledOut bool, reason string) {
+	replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if !found {
+		return true, ""
+	}
+
+	if observedGeneration, found, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration"); found && observedGeneration < obj.GetGeneration() {
+		return false, fmt.Sprintf("%s %s has not been observed yet", obj.GetKind(), obj.GetName())
+	}
+
+	readyReplicas, _, _ := unstructured.NestedInt64(obj.Object, "status", "readyReplicas")
+	updatedReplicas, found, _ := unstructured.NestedInt64(obj.Object, "status", "updatedReplicas")
+	if !found {
+		// e.g. MachinePools do not report the updated replicas
+		updatedReplicas = replicas
+	}
+
+	if updatedReplicas < replicas || readyReplicas < replicas {
+		return false, fmt.Sprintf("%s %s is rolling out: %d/%d replicas updated, %d/%d ready",
+			obj.GetKind(), obj.GetName(), updatedReplicas, replicas, readyReplicas, replicas)
+	}
+
+	return true, ""
+}
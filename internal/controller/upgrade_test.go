@@ -0,0 +1,82 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
+)
+
+var _ = Describe("ClusterDeployment staged upgrades", func() {
+	It("should record the upgrade to another template", func() {
+		clusterDeployment := &hmc.ClusterDeployment{
+			Spec: hmc.ClusterDeploymentSpec{
+				Template: "template-1-0-1",
+				Upgrade:  &hmc.UpgradeSpec{},
+			},
+			Status: hmc.ClusterDeploymentStatus{Template: "template-1-0-0"},
+		}
+
+		startUpgrade(clusterDeployment)
+		Expect(clusterDeployment.Status.Template).To(Equal("template-1-0-0"))
+		Expect(clusterDeployment.Status.Upgrade).NotTo(BeNil())
+		Expect(clusterDeployment.Status.Upgrade.FromTemplate).To(Equal("template-1-0-0"))
+		Expect(clusterDeployment.Status.Upgrade.ToTemplate).To(Equal("template-1-0-1"))
+		Expect(clusterDeployment.Status.Upgrade.Phase).To(Equal(hmc.UpgradePhaseProgressing))
+
+		By("reverting the template")
+		clusterDeployment.Spec.Template = "template-1-0-0"
+		startUpgrade(clusterDeployment)
+		Expect(clusterDeployment.Status.Upgrade.Phase).To(Equal(hmc.UpgradePhaseRolledBack))
+		Expect(clusterDeployment.Status.Template).To(Equal("template-1-0-0"))
+	})
+
+	It("should apply the template right away if the staged upgrades are disabled", func() {
+		clusterDeployment := &hmc.ClusterDeployment{
+			Spec:   hmc.ClusterDeploymentSpec{Template: "template-1-0-1"},
+			Status: hmc.ClusterDeploymentStatus{Template: "template-1-0-0"},
+		}
+
+		startUpgrade(clusterDeployment)
+		Expect(clusterDeployment.Status.Template).To(Equal("template-1-0-1"))
+		Expect(clusterDeployment.Status.Upgrade).To(BeNil())
+	})
+
+	It("should check the rollout of the replicas", func() {
+		md := &unstructured.Unstructured{Object: map[string]any{
+			"spec":   map[string]any{"replicas": int64(2)},
+			"status": map[string]any{"observedGeneration": int64(2), "updatedReplicas": int64(1), "readyReplicas": int64(2)},
+		}}
+		md.SetKind("MachineDeployment")
+		md.SetName("md")
+		md.SetGeneration(2)
+
+		rolledOut, reason := replicasRolledOut(md)
+		Expect(rolledOut).To(BeFalse())
+		Expect(reason).To(Equal("MachineDeployment md is rolling out: 1/2 replicas updated, 2/2 ready"))
+
+		Expect(unstructured.SetNestedField(md.Object, int64(2), "status", "updatedReplicas")).To(Succeed())
+		rolledOut, _ = replicasRolledOut(md)
+		Expect(rolledOut).To(BeTrue())
+
+		md.SetGeneration(3)
+		rolledOut, reason = replicasRolledOut(md)
+		Expect(rolledOut).To(BeFalse())
+		Expect(reason).To(Equal("MachineDeployment md has not been observed yet"))
+	})
+})
@@ -0,0 +1,104 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"fmt"
+	"sync"
+
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
+)
+
+// veleroKinds are the Velero kinds the backup related controllers depend on.
+var veleroKinds = []string{"Backup", "Restore", "Schedule", "BackupStorageLocation", "VolumeSnapshotLocation"}
+
+// VeleroControllers sets up the controllers depending on the Velero CRDs.
+// Velero is installed only once the backup is enabled in the Management,
+// so the controllers are set up as soon as its CRDs are available.
+type VeleroControllers struct {
+	Manager         ctrl.Manager
+	SystemNamespace string
+
+	// management is the Management controller watching the velero storage locations
+	management controller.Controller
+
+	mu    sync.Mutex
+	ready bool
+}
+
+// Setup sets up the Backup and Restore controllers and the velero watches of the Management controller
+// if the Velero CRDs are installed. Returns false if the CRDs are not installed yet.
+func (v *VeleroControllers) Setup() (bool, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.ready {
+		return true, nil
+	}
+
+	installed, err := veleroInstalled(v.Manager.GetRESTMapper())
+	if err != nil || !installed {
+		return false, err
+	}
+
+	if v.management != nil {
+		if err := v.management.Watch(source.Kind(v.Manager.GetCache(), &velerov1.BackupStorageLocation{},
+			handler.TypedEnqueueRequestForOwner[*velerov1.BackupStorageLocation](v.Manager.GetScheme(), v.Manager.GetRESTMapper(), &hmc.Management{}, handler.OnlyControllerOwner()),
+		)); err != nil {
+			return false, fmt.Errorf("failed to watch velero BackupStorageLocations: %w", err)
+		}
+	}
+
+	if err := (&BackupReconciler{
+		Client:          v.Manager.GetClient(),
+		Scheme:          v.Manager.GetScheme(),
+		SystemNamespace: v.SystemNamespace,
+	}).SetupWithManager(v.Manager); err != nil {
+		return false, fmt.Errorf("failed to set up Backup controller: %w", err)
+	}
+
+	if err := (&RestoreReconciler{
+		Client:          v.Manager.GetClient(),
+		Scheme:          v.Manager.GetScheme(),
+		SystemNamespace: v.SystemNamespace,
+	}).SetupWithManager(v.Manager); err != nil {
+		return false, fmt.Errorf("failed to set up Restore controller: %w", err)
+	}
+
+	v.ready = true
+	return true, nil
+}
+
+// veleroInstalled reports whether the CRDs of all of the Velero kinds the controllers depend on are installed.
+func veleroInstalled(mapper apimeta.RESTMapper) (bool, error) {
+	for _, kind := range veleroKinds {
+		_, err := mapper.RESTMapping(velerov1.SchemeGroupVersion.WithKind(kind).GroupKind(), velerov1.SchemeGroupVersion.Version)
+		if apimeta.IsNoMatchError(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to get REST mapping of velero %s: %w", kind, err)
+		}
+	}
+
+	return true, nil
+}
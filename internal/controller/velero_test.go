@@ -0,0 +1,91 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"net/http"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/config"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
+	"github.com/K0rdent/kcm/internal/utils"
+)
+
+var _ = Describe("Velero controllers", func() {
+	newManager := func(mapper apimeta.RESTMapper) ctrl.Manager {
+		opts := ctrl.Options{
+			Scheme:     scheme.Scheme,
+			Metrics:    metricsserver.Options{BindAddress: "0"},
+			Controller: config.Controller{SkipNameValidation: ptr.To(true)},
+		}
+		if mapper != nil {
+			opts.MapperProvider = func(*rest.Config, *http.Client) (apimeta.RESTMapper, error) {
+				return mapper, nil
+			}
+		}
+
+		mgr, err := ctrl.NewManager(cfg, opts)
+		Expect(err).NotTo(HaveOccurred())
+		return mgr
+	}
+
+	It("should set up the Management controller without the backup controllers if Velero is not installed", func() {
+		By("Creating the manager unaware of the velero kinds as if the backup were disabled")
+		mapper := apimeta.NewDefaultRESTMapper(nil)
+		mapper.Add(hmc.GroupVersion.WithKind(hmc.ManagementKind), apimeta.RESTScopeRoot)
+
+		veleroControllers := &VeleroControllers{
+			Manager:         newManager(mapper),
+			SystemNamespace: utils.DefaultSystemNamespace,
+		}
+		reconciler := &ManagementReconciler{
+			Client:            k8sClient,
+			Scheme:            k8sClient.Scheme(),
+			SystemNamespace:   utils.DefaultSystemNamespace,
+			VeleroControllers: veleroControllers,
+		}
+		Expect(reconciler.SetupWithManager(veleroControllers.Manager)).To(Succeed())
+		Expect(veleroControllers.management).NotTo(BeNil())
+
+		installed, err := veleroControllers.Setup()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(installed).To(BeFalse())
+		Expect(veleroControllers.ready).To(BeFalse())
+	})
+
+	It("should set up the backup controllers once Velero is installed", func() {
+		veleroControllers := &VeleroControllers{
+			Manager:         newManager(nil),
+			SystemNamespace: utils.DefaultSystemNamespace,
+		}
+
+		installed, err := veleroControllers.Setup()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(installed).To(BeTrue())
+
+		By("Not setting up the controllers again")
+		installed, err = veleroControllers.Setup()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(installed).To(BeTrue())
+	})
+})
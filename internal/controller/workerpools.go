@@ -0,0 +1,74 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
+)
+
+// setWorkerPoolsHelmValues sets the given worker pools into the values
+// at the path and in the layout declared by the worker pools mapping of the template.
+func setWorkerPoolsHelmValues(values *apiextensionsv1.JSON, workerPools []hmc.WorkerPool, mapping *hmc.WorkerPoolsMapping) (*apiextensionsv1.JSON, error) {
+	if len(workerPools) == 0 {
+		return values, nil
+	}
+	if mapping == nil || mapping.Path == "" || len(mapping.Fields) == 0 {
+		return nil, errors.New("the template does not support worker pools")
+	}
+
+	valuesJSON := make(map[string]any)
+	if err := json.Unmarshal(values.Raw, &valuesJSON); err != nil {
+		return nil, fmt.Errorf("error unmarshalling values: %w", err)
+	}
+
+	pools := make([]any, 0, len(workerPools))
+	for _, pool := range workerPools {
+		poolValues, err := pool.Values()
+		if err != nil {
+			return nil, fmt.Errorf("error converting worker pool %s to values: %w", pool.Name, err)
+		}
+
+		item := make(map[string]any)
+		for field, path := range mapping.Fields {
+			value, ok := poolValues[field]
+			if !ok {
+				continue
+			}
+			if err := unstructured.SetNestedField(item, value, strings.Split(path, ".")...); err != nil {
+				return nil, fmt.Errorf("error setting %s of worker pool %s: %w", field, pool.Name, err)
+			}
+		}
+		pools = append(pools, item)
+	}
+
+	if err := unstructured.SetNestedSlice(valuesJSON, pools, strings.Split(mapping.Path, ".")...); err != nil {
+		return nil, fmt.Errorf("error setting worker pools values: %w", err)
+	}
+
+	valuesRaw, err := json.Marshal(valuesJSON)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling values: %w", err)
+	}
+
+	return &apiextensionsv1.JSON{Raw: valuesRaw}, nil
+}
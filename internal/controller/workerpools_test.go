@@ -0,0 +1,56 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
+)
+
+var _ = Describe("ClusterDeployment worker pools", func() {
+	It("should translate the worker pools into the template values", func() {
+		mapping := &hmc.WorkerPoolsMapping{
+			Path: "workers.pools",
+			Fields: map[string]string{
+				"name":         "name",
+				"instanceType": "machine.instanceType",
+				"replicas":     "replicas",
+				"taints":       "taints",
+			},
+		}
+		workerPools := []hmc.WorkerPool{
+			{Name: "gpu", InstanceType: "g4dn.xlarge", Replicas: 2, Taints: []corev1.Taint{{Key: "gpu", Effect: corev1.TaintEffectNoSchedule}}},
+			{Name: "spare", Replicas: 0},
+		}
+
+		values, err := setWorkerPoolsHelmValues(&apiextensionsv1.JSON{Raw: []byte(`{"workersNumber":1}`)}, workerPools, mapping)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(values.Raw).To(MatchJSON(`{
+			"workersNumber": 1,
+			"workers": {"pools": [
+				{"name": "gpu", "machine": {"instanceType": "g4dn.xlarge"}, "replicas": 2, "taints": [{"key": "gpu", "effect": "NoSchedule"}]},
+				{"name": "spare", "replicas": 0}
+			]}
+		}`))
+
+		By("requiring the template to declare the mapping")
+		_, err = setWorkerPoolsHelmValues(values, workerPools, nil)
+		Expect(err).To(HaveOccurred())
+	})
+})
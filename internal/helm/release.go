@@ -41,6 +41,7 @@ type ReconcileHelmReleaseOpts struct {
 	TargetNamespace   string
 	DependsOn         []meta.NamespacedObjectReference
 	CreateNamespace   bool
+	Suspend           bool
 }
 
 func ReconcileHelmRelease(ctx context.Context,
@@ -79,6 +80,7 @@ func ReconcileHelmRelease(ctx context.Context,
 			Install: &hcv2.Install{
 				CreateNamespace: opts.CreateNamespace,
 			},
+			Suspend: opts.Suspend,
 		}
 		return nil
 	})
@@ -24,6 +24,7 @@ import (
 	libsveltosv1beta1 "github.com/projectsveltos/libsveltos/api/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -38,6 +39,12 @@ type ReconcileProfileOpts struct {
 	HelmChartOpts  []HelmChartOpts
 	Priority       int32
 	StopOnConflict bool
+	// Paused pauses the Profile by setting the CAPI paused annotation
+	// which Sveltos propagates to the corresponding ClusterSummaries.
+	Paused bool
+	// LeavePolicies leaves the deployed services in place once
+	// the Profile is deleted or the cluster stops matching it.
+	LeavePolicies bool
 }
 
 type HelmChartOpts struct {
@@ -112,6 +119,15 @@ func ReconcileProfile(
 		}
 		p.Spec = *spec
 
+		if opts.Paused {
+			if p.Annotations == nil {
+				p.Annotations = make(map[string]string)
+			}
+			p.Annotations[clusterv1.PausedAnnotation] = "true"
+		} else {
+			delete(p.Annotations, clusterv1.PausedAnnotation)
+		}
+
 		return nil
 	})
 	if err != nil {
@@ -235,6 +251,10 @@ func GetSpec(opts *ReconcileProfileOpts) (*sveltosv1beta1.Spec, error) {
 		HelmCharts:         make([]sveltosv1beta1.HelmChart, 0, len(opts.HelmChartOpts)),
 	}
 
+	if opts.LeavePolicies {
+		spec.StopMatchingBehavior = sveltosv1beta1.LeavePolicies
+	}
+
 	for _, hc := range opts.HelmChartOpts {
 		helmChart := sveltosv1beta1.HelmChart{
 			RepositoryURL:    hc.RepositoryURL,
@@ -265,6 +285,29 @@ func GetSpec(opts *ReconcileProfileOpts) (*sveltosv1beta1.Spec, error) {
 	return spec, nil
 }
 
+// HelmChartsChanged reports whether the Helm charts of the given Profile or ClusterProfile spec
+// differ from the ones defined by the given options. Only the fields set by HMC are compared.
+func HelmChartsChanged(spec *sveltosv1beta1.Spec, opts []HelmChartOpts) bool {
+	if len(spec.HelmCharts) != len(opts) {
+		return true
+	}
+
+	for i, hc := range opts {
+		current := spec.HelmCharts[i]
+		if current.RepositoryURL != hc.RepositoryURL ||
+			current.RepositoryName != hc.RepositoryName ||
+			current.ChartName != hc.ChartName ||
+			current.ChartVersion != hc.ChartVersion ||
+			current.ReleaseName != hc.ReleaseName ||
+			current.ReleaseNamespace != hc.ReleaseNamespace ||
+			current.Values != hc.Values {
+			return true
+		}
+	}
+
+	return false
+}
+
 func objectMeta(owner *metav1.OwnerReference) metav1.ObjectMeta {
 	obj := metav1.ObjectMeta{
 		Labels: map[string]string{
@@ -15,11 +15,17 @@
 package sveltos
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"testing"
 
+	sveltosv1beta1 "github.com/projectsveltos/addon-controller/api/v1beta1"
 	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func Test_priorityToTier(t *testing.T) {
@@ -44,3 +50,42 @@ func Test_priorityToTier(t *testing.T) {
 		})
 	}
 }
+
+func TestReconcileProfilePaused(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, sveltosv1beta1.AddToScheme(scheme))
+
+	var (
+		ctx = context.Background()
+		cl  = fake.NewClientBuilder().WithScheme(scheme).Build()
+		key = client.ObjectKey{Namespace: "test", Name: "test-profile"}
+	)
+
+	for _, paused := range []bool{true, false} {
+		t.Run(fmt.Sprintf("paused=%t", paused), func(t *testing.T) {
+			_, err := ReconcileProfile(ctx, cl, key.Namespace, key.Name, ReconcileProfileOpts{Priority: 100, Paused: paused})
+			require.NoError(t, err)
+
+			profile := new(sveltosv1beta1.Profile)
+			require.NoError(t, cl.Get(ctx, key, profile))
+			if paused {
+				require.Equal(t, "true", profile.Annotations[clusterv1.PausedAnnotation])
+			} else {
+				require.NotContains(t, profile.Annotations, clusterv1.PausedAnnotation)
+			}
+		})
+	}
+}
+
+func TestHelmChartsChanged(t *testing.T) {
+	opts := []HelmChartOpts{{ChartName: "ingress-nginx", ChartVersion: "4.11.0", ReleaseName: "ingress", ReleaseNamespace: "ingress"}}
+
+	spec, err := GetSpec(&ReconcileProfileOpts{Priority: 100, HelmChartOpts: opts})
+	require.NoError(t, err)
+	require.False(t, HelmChartsChanged(spec, opts))
+
+	upgraded := []HelmChartOpts{opts[0]}
+	upgraded[0].ChartVersion = "4.11.3"
+	require.True(t, HelmChartsChanged(spec, upgraded))
+	require.True(t, HelmChartsChanged(spec, nil))
+}
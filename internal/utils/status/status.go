@@ -94,6 +94,32 @@ type ResourceConditions struct {
 func GetResourceConditions(
 	ctx context.Context, namespace string, dynamicClient dynamic.Interface,
 	gvr schema.GroupVersionResource, labelSelector string,
+) (resourceConditions *ResourceConditions, err error) {
+	return getResourceConditions(ctx, namespace, dynamicClient, gvr, labelSelector, ConditionsFromUnstructured)
+}
+
+// GetResourceReadyConditions is similar to GetResourceConditions but is
+// intended for the resources reporting their readiness in the boolean
+// status.ready field instead of status.conditions, see ReadyConditionFromUnstructured.
+func GetResourceReadyConditions(
+	ctx context.Context, namespace string, dynamicClient dynamic.Interface,
+	gvr schema.GroupVersionResource, labelSelector string,
+) (resourceConditions *ResourceConditions, err error) {
+	return getResourceConditions(ctx, namespace, dynamicClient, gvr, labelSelector,
+		func(unstrObj *unstructured.Unstructured) ([]metav1.Condition, error) {
+			c, err := ReadyConditionFromUnstructured(unstrObj)
+			if err != nil {
+				return nil, err
+			}
+			return []metav1.Condition{c}, nil
+		},
+	)
+}
+
+func getResourceConditions(
+	ctx context.Context, namespace string, dynamicClient dynamic.Interface,
+	gvr schema.GroupVersionResource, labelSelector string,
+	conditionsFromUnstructured func(*unstructured.Unstructured) ([]metav1.Condition, error),
 ) (resourceConditions *ResourceConditions, err error) {
 	list, err := dynamicClient.Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{
 		LabelSelector: labelSelector,
@@ -113,7 +139,7 @@ func GetResourceConditions(
 	var conditions []metav1.Condition
 	kind, name := ObjKindName(&list.Items[0])
 	for _, item := range list.Items {
-		c, err := ConditionsFromUnstructured(&item)
+		c, err := conditionsFromUnstructured(&item)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get conditions: %w", err)
 		}
@@ -127,6 +153,39 @@ func GetResourceConditions(
 	}, nil
 }
 
+// ReadyConditionType returns the type of the condition built
+// by ReadyConditionFromUnstructured for the given kind.
+func ReadyConditionType(kind string) string {
+	return kind + "Ready"
+}
+
+// ReadyConditionFromUnstructured builds a metav1.Condition of the
+// <Kind>Ready type from the boolean status.ready field of an unstructured
+// object, e.g. a K0sControlPlane, which does not use the metav1.Condition
+// type for the status.conditions.
+func ReadyConditionFromUnstructured(unstrObj *unstructured.Unstructured) (metav1.Condition, error) {
+	objKind, objName := ObjKindName(unstrObj)
+
+	ready, _, err := unstructured.NestedBool(unstrObj.Object, "status", "ready")
+	if err != nil {
+		return metav1.Condition{}, fmt.Errorf("failed to get ready status for %s: %s: %w", objKind, objName, err)
+	}
+
+	c := metav1.Condition{
+		Type:    ReadyConditionType(objKind),
+		Status:  metav1.ConditionTrue,
+		Reason:  "Succeeded",
+		Message: objName + " is Ready",
+	}
+	if !ready {
+		c.Status = metav1.ConditionFalse
+		c.Reason = "Progressing"
+		c.Message = objName + " is not yet Ready"
+	}
+
+	return c, nil
+}
+
 func ObjKindName(unstrObj *unstructured.Unstructured) (name, kind string) {
 	return unstrObj.GetKind(), unstrObj.GetName()
 }
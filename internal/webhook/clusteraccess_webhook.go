@@ -0,0 +1,84 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/K0rdent/kcm/api/v1alpha1"
+)
+
+type ClusterAccessValidator struct {
+	client.Client
+}
+
+func (v *ClusterAccessValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	v.Client = mgr.GetClient()
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&v1alpha1.ClusterAccess{}).
+		WithValidator(v).
+		WithDefaulter(v).
+		Complete()
+}
+
+var (
+	_ webhook.CustomValidator = &ClusterAccessValidator{}
+	_ webhook.CustomDefaulter = &ClusterAccessValidator{}
+)
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
+func (v *ClusterAccessValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	access, ok := obj.(*v1alpha1.ClusterAccess)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected ClusterAccess but got a %T", obj))
+	}
+
+	accessManagement := new(v1alpha1.AccessManagement)
+	if err := v.Get(ctx, client.ObjectKey{Name: v1alpha1.AccessManagementName}, accessManagement); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to get AccessManagement: %w", err)
+		}
+		accessManagement = nil
+	}
+
+	if !accessManagement.ClusterAccessRoleAllowed(access.Spec.ClusterRole) {
+		return nil, fmt.Errorf("ClusterRole %s is not allowed to be granted by ClusterAccess, it has to be listed in the spec.clusterAccessRoles of the AccessManagement", access.Spec.ClusterRole)
+	}
+
+	return nil, nil
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+func (*ClusterAccessValidator) ValidateUpdate(context.Context, runtime.Object, runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
+func (*ClusterAccessValidator) ValidateDelete(context.Context, runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type.
+func (*ClusterAccessValidator) Default(context.Context, runtime.Object) error {
+	return nil
+}
@@ -0,0 +1,94 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/K0rdent/kcm/api/v1alpha1"
+	am "github.com/K0rdent/kcm/test/objects/accessmanagement"
+	"github.com/K0rdent/kcm/test/scheme"
+)
+
+func TestClusterAccessValidateCreate(t *testing.T) {
+	g := NewWithT(t)
+
+	ctx := context.Background()
+
+	newClusterAccess := func(clusterRole string) *v1alpha1.ClusterAccess {
+		return &v1alpha1.ClusterAccess{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: metav1.NamespaceDefault},
+			Spec: v1alpha1.ClusterAccessSpec{
+				ClusterDeployment: "test",
+				ClusterRole:       clusterRole,
+			},
+		}
+	}
+
+	tests := []struct {
+		name            string
+		access          *v1alpha1.ClusterAccess
+		existingObjects []runtime.Object
+		err             string
+	}{
+		{
+			name:   "should succeed if the default ClusterRole is requested",
+			access: newClusterAccess(v1alpha1.DefaultClusterAccessRole),
+		},
+		{
+			name:   "should fail if the ClusterRole is not the default one and the AccessManagement does not exist",
+			access: newClusterAccess("cluster-admin"),
+			err:    "ClusterRole cluster-admin is not allowed to be granted by ClusterAccess, it has to be listed in the spec.clusterAccessRoles of the AccessManagement",
+		},
+		{
+			name:   "should fail if the ClusterRole is not listed in the AccessManagement",
+			access: newClusterAccess(v1alpha1.DefaultClusterAccessRole),
+			existingObjects: []runtime.Object{
+				am.NewAccessManagement(am.WithName(v1alpha1.AccessManagementName), am.WithClusterAccessRoles("edit")),
+			},
+			err: "ClusterRole view is not allowed to be granted by ClusterAccess, it has to be listed in the spec.clusterAccessRoles of the AccessManagement",
+		},
+		{
+			name:   "should succeed if the ClusterRole is listed in the AccessManagement",
+			access: newClusterAccess("edit"),
+			existingObjects: []runtime.Object{
+				am.NewAccessManagement(am.WithName(v1alpha1.AccessManagementName), am.WithClusterAccessRoles("view", "edit")),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().
+				WithScheme(scheme.Scheme).
+				WithRuntimeObjects(tt.existingObjects...).
+				Build()
+			validator := &ClusterAccessValidator{Client: c}
+			warn, err := validator.ValidateCreate(ctx, tt.access)
+			if tt.err != "" {
+				g.Expect(err).To(MatchError(tt.err))
+			} else {
+				g.Expect(err).To(Succeed())
+			}
+			g.Expect(warn).To(BeEmpty())
+		})
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"slices"
 	"strings"
 
@@ -79,6 +80,10 @@ func (v *ClusterDeploymentValidator) ValidateCreate(ctx context.Context, obj run
 		return nil, fmt.Errorf("%s: %w", invalidClusterDeploymentMsg, err)
 	}
 
+	if err := validateWorkerPools(clusterDeployment, template); err != nil {
+		return nil, fmt.Errorf("%s: %w", invalidClusterDeploymentMsg, err)
+	}
+
 	if err := validateServices(ctx, v.Client, clusterDeployment.Namespace, clusterDeployment.Spec.Services); err != nil {
 		return nil, fmt.Errorf("%s: %w", invalidClusterDeploymentMsg, err)
 	}
@@ -105,7 +110,7 @@ func (v *ClusterDeploymentValidator) ValidateUpdate(ctx context.Context, oldObj,
 	}
 
 	if oldTemplate != newTemplate {
-		if !slices.Contains(oldClusterDeployment.Status.AvailableUpgrades, newTemplate) {
+		if !slices.Contains(oldClusterDeployment.Status.AvailableUpgrades, newTemplate) && !isUpgradeRollback(oldClusterDeployment, newTemplate) {
 			msg := fmt.Sprintf("Cluster can't be upgraded from %s to %s. This upgrade sequence is not allowed", oldTemplate, newTemplate)
 			return admission.Warnings{msg}, errClusterUpgradeForbidden
 		}
@@ -123,6 +128,10 @@ func (v *ClusterDeploymentValidator) ValidateUpdate(ctx context.Context, oldObj,
 		return nil, fmt.Errorf("%s: %w", invalidClusterDeploymentMsg, err)
 	}
 
+	if err := validateWorkerPools(newClusterDeployment, template); err != nil {
+		return nil, fmt.Errorf("%s: %w", invalidClusterDeploymentMsg, err)
+	}
+
 	if err := validateServices(ctx, v.Client, newClusterDeployment.Namespace, newClusterDeployment.Spec.Services); err != nil {
 		return nil, fmt.Errorf("%s: %w", invalidClusterDeploymentMsg, err)
 	}
@@ -130,6 +139,19 @@ func (v *ClusterDeploymentValidator) ValidateUpdate(ctx context.Context, oldObj,
 	return nil, nil
 }
 
+// isUpgradeRollback reports whether the given template is either the one of the revision the ClusterDeployment
+// is requested to be rolled back to or the one the ClusterDeployment is being upgraded from.
+func isUpgradeRollback(clusterDeployment *hmcv1alpha1.ClusterDeployment, template string) bool {
+	if revision, ok := clusterDeployment.Annotations[hmcv1alpha1.ClusterDeploymentRollbackAnnotation]; ok {
+		target, err := clusterDeployment.GetRevision(revision)
+		return err == nil && target.Template == template
+	}
+
+	upgrade := clusterDeployment.Status.Upgrade
+	return upgrade != nil && upgrade.Phase == hmcv1alpha1.UpgradePhaseProgressing &&
+		upgrade.ToTemplate == clusterDeployment.Spec.Template && upgrade.FromTemplate == template
+}
+
 func validateK8sCompatibility(ctx context.Context, cl client.Client, template *hmcv1alpha1.ClusterTemplate, mc *hmcv1alpha1.ClusterDeployment) error {
 	if len(mc.Spec.Services) == 0 || template.Status.KubernetesVersion == "" {
 		return nil // nothing to do
@@ -183,8 +205,9 @@ func (v *ClusterDeploymentValidator) Default(ctx context.Context, obj runtime.Ob
 	}
 
 	// Only apply defaults when there's no configuration provided;
-	// if template ref is empty, then nothing to default
-	if clusterDeployment.Spec.Config != nil || clusterDeployment.Spec.Template == "" {
+	// if template ref is empty, then nothing to default;
+	// the configuration of an adopted cluster is computed from the existing objects
+	if clusterDeployment.Spec.Config != nil || clusterDeployment.Spec.Template == "" || clusterDeployment.Spec.Adopt {
 		return nil
 	}
 
@@ -232,6 +255,34 @@ func isTemplateValid(status *hmcv1alpha1.TemplateStatusCommon) error {
 	return nil
 }
 
+// validateWorkerPools checks the template supports the worker pools and all of their fields.
+func validateWorkerPools(clusterDeployment *hmcv1alpha1.ClusterDeployment, template *hmcv1alpha1.ClusterTemplate) error {
+	if len(clusterDeployment.Spec.WorkerPools) == 0 {
+		return nil
+	}
+
+	// only the templates declaring the worker pools mapping render the worker pools
+	mapping := template.Status.WorkerPools
+	if mapping == nil || mapping.Path == "" || len(mapping.Fields) == 0 {
+		return fmt.Errorf("template %q does not support worker pools", template.Name)
+	}
+
+	for _, pool := range clusterDeployment.Spec.WorkerPools {
+		values, err := pool.Values()
+		if err != nil {
+			return fmt.Errorf("failed to convert worker pool %s to values: %w", pool.Name, err)
+		}
+
+		for _, field := range slices.Sorted(maps.Keys(values)) {
+			if _, ok := mapping.Fields[field]; !ok {
+				return fmt.Errorf("template %q does not support the %s of the worker pools", template.Name, field)
+			}
+		}
+	}
+
+	return nil
+}
+
 func (v *ClusterDeploymentValidator) validateCredential(ctx context.Context, clusterDeployment *hmcv1alpha1.ClusterDeployment, template *hmcv1alpha1.ClusterTemplate) error {
 	if len(template.Status.Providers) == 0 {
 		return fmt.Errorf("template %q has no providers defined", template.Name)
@@ -258,41 +309,32 @@ func (v *ClusterDeploymentValidator) validateCredential(ctx context.Context, clu
 		return errors.New("credential is not Ready")
 	}
 
-	return isCredMatchTemplate(cred, template)
+	mgmt, err := getManagement(ctx, v.Client)
+	if err != nil {
+		if !errors.Is(err, errManagementIsNotFound) {
+			return err
+		}
+		mgmt = new(hmcv1alpha1.Management)
+	}
+
+	return isCredMatchTemplate(cred, template, mgmt)
 }
 
-func isCredMatchTemplate(cred *hmcv1alpha1.Credential, template *hmcv1alpha1.ClusterTemplate) error {
+func isCredMatchTemplate(cred *hmcv1alpha1.Credential, template *hmcv1alpha1.ClusterTemplate, mgmt *hmcv1alpha1.Management) error {
 	idtyKind := cred.Spec.IdentityRef.Kind
 
-	errMsg := func(provider string) error {
-		return fmt.Errorf("wrong kind of the ClusterIdentity %q for provider %q", idtyKind, provider)
-	}
-
 	for _, provider := range template.Status.Providers {
-		switch provider {
-		case "infrastructure-aws":
-			if idtyKind != "AWSClusterStaticIdentity" &&
-				idtyKind != "AWSClusterRoleIdentity" &&
-				idtyKind != "AWSClusterControllerIdentity" {
-				return errMsg(provider)
-			}
-		case "infrastructure-azure":
-			if idtyKind != "AzureClusterIdentity" &&
-				idtyKind != "Secret" {
-				return errMsg(provider)
-			}
-		case "infrastructure-vsphere":
-			if idtyKind != "VSphereClusterIdentity" {
-				return errMsg(provider)
-			}
-		case "infrastructure-openstack", "infrastructure-internal":
-			if idtyKind != "Secret" {
-				return errMsg(provider)
-			}
-		default:
-			if strings.HasPrefix(provider, "infrastructure-") {
-				return fmt.Errorf("unsupported infrastructure provider %s", provider)
-			}
+		if !strings.HasPrefix(provider, "infrastructure-") {
+			continue
+		}
+
+		infrastructure, ok := mgmt.InfrastructureProvider(provider)
+		if !ok {
+			return fmt.Errorf("unsupported infrastructure provider %s", provider)
+		}
+
+		if len(infrastructure.ClusterIdentityKinds) > 0 && !slices.Contains(infrastructure.ClusterIdentityKinds, idtyKind) {
+			return fmt.Errorf("wrong kind of the ClusterIdentity %q for provider %q", idtyKind, provider)
 		}
 	}
 
@@ -196,6 +196,79 @@ func TestClusterDeploymentValidateCreate(t *testing.T) {
 				),
 			},
 		},
+		{
+			name: "should fail if the template does not support the worker pools",
+			ClusterDeployment: clusterdeployment.NewClusterDeployment(
+				clusterdeployment.WithClusterTemplate(testTemplateName),
+				clusterdeployment.WithCredential(testCredentialName),
+				clusterdeployment.WithWorkerPools(v1alpha1.WorkerPool{Name: "gpu", Replicas: 1}),
+			),
+			existingObjects: []runtime.Object{
+				mgmt,
+				cred,
+				template.NewClusterTemplate(
+					template.WithName(testTemplateName),
+					template.WithProvidersStatus(
+						"infrastructure-aws",
+						"control-plane-k0smotron",
+						"bootstrap-k0smotron",
+					),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+				),
+			},
+			err: fmt.Sprintf("the ClusterDeployment is invalid: template %q does not support worker pools", testTemplateName),
+		},
+		{
+			name: "should fail if the template does not support the worker pools fields",
+			ClusterDeployment: clusterdeployment.NewClusterDeployment(
+				clusterdeployment.WithClusterTemplate(testTemplateName),
+				clusterdeployment.WithCredential(testCredentialName),
+				clusterdeployment.WithWorkerPools(v1alpha1.WorkerPool{Name: "gpu", Replicas: 1, FailureDomain: "us-east-1a"}),
+			),
+			existingObjects: []runtime.Object{
+				mgmt,
+				cred,
+				template.NewClusterTemplate(
+					template.WithName(testTemplateName),
+					template.WithProvidersStatus(
+						"infrastructure-aws",
+						"control-plane-k0smotron",
+						"bootstrap-k0smotron",
+					),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+					template.WithClusterStatusWorkerPools(&v1alpha1.WorkerPoolsMapping{
+						Path:   "workerPools",
+						Fields: map[string]string{"name": "name", "replicas": "replicas"},
+					}),
+				),
+			},
+			err: fmt.Sprintf("the ClusterDeployment is invalid: template %q does not support the failureDomain of the worker pools", testTemplateName),
+		},
+		{
+			name: "should succeed with the worker pools",
+			ClusterDeployment: clusterdeployment.NewClusterDeployment(
+				clusterdeployment.WithClusterTemplate(testTemplateName),
+				clusterdeployment.WithCredential(testCredentialName),
+				clusterdeployment.WithWorkerPools(v1alpha1.WorkerPool{Name: "gpu", Replicas: 1, InstanceType: "g4dn.xlarge"}),
+			),
+			existingObjects: []runtime.Object{
+				mgmt,
+				cred,
+				template.NewClusterTemplate(
+					template.WithName(testTemplateName),
+					template.WithProvidersStatus(
+						"infrastructure-aws",
+						"control-plane-k0smotron",
+						"bootstrap-k0smotron",
+					),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+					template.WithClusterStatusWorkerPools(&v1alpha1.WorkerPoolsMapping{
+						Path:   "workerPools",
+						Fields: map[string]string{"name": "name", "replicas": "replicas", "instanceType": "machine.instanceType"},
+					}),
+				),
+			},
+		},
 		{
 			name: "cluster template k8s version does not satisfy service template constraints",
 			ClusterDeployment: clusterdeployment.NewClusterDeployment(
@@ -296,6 +369,92 @@ func TestClusterDeploymentValidateCreate(t *testing.T) {
 			},
 			err: "the ClusterDeployment is invalid: wrong kind of the ClusterIdentity \"AWSClusterStaticIdentity\" for provider \"infrastructure-azure\"",
 		},
+		{
+			name: "should fail if the infrastructure provider is unknown",
+			ClusterDeployment: clusterdeployment.NewClusterDeployment(
+				clusterdeployment.WithClusterTemplate(testTemplateName),
+				clusterdeployment.WithCredential(testCredentialName),
+			),
+			existingObjects: []runtime.Object{
+				cred,
+				management.NewManagement(
+					management.WithAvailableProviders(v1alpha1.Providers{
+						"infrastructure-docker",
+						"control-plane-k0smotron",
+						"bootstrap-k0smotron",
+					}),
+				),
+				template.NewClusterTemplate(
+					template.WithName(testTemplateName),
+					template.WithProvidersStatus(
+						"infrastructure-docker",
+						"control-plane-k0smotron",
+						"bootstrap-k0smotron",
+					),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+				),
+			},
+			err: "the ClusterDeployment is invalid: unsupported infrastructure provider infrastructure-docker",
+		},
+		{
+			name: "should fail if the credential kind is not declared by the third-party infrastructure provider",
+			ClusterDeployment: clusterdeployment.NewClusterDeployment(
+				clusterdeployment.WithClusterTemplate(testTemplateName),
+				clusterdeployment.WithCredential(testCredentialName),
+			),
+			existingObjects: []runtime.Object{
+				cred,
+				management.NewManagement(
+					management.WithAvailableProviders(v1alpha1.Providers{
+						"infrastructure-docker",
+						"control-plane-k0smotron",
+						"bootstrap-k0smotron",
+					}),
+					management.WithInfrastructureProviders(map[string]v1alpha1.InfrastructureProvider{
+						"infrastructure-docker": {ClusterIdentityKinds: []string{"Secret"}},
+					}),
+				),
+				template.NewClusterTemplate(
+					template.WithName(testTemplateName),
+					template.WithProvidersStatus(
+						"infrastructure-docker",
+						"control-plane-k0smotron",
+						"bootstrap-k0smotron",
+					),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+				),
+			},
+			err: "the ClusterDeployment is invalid: wrong kind of the ClusterIdentity \"AWSClusterStaticIdentity\" for provider \"infrastructure-docker\"",
+		},
+		{
+			name: "should succeed if the third-party infrastructure provider does not restrict the credential kind",
+			ClusterDeployment: clusterdeployment.NewClusterDeployment(
+				clusterdeployment.WithClusterTemplate(testTemplateName),
+				clusterdeployment.WithCredential(testCredentialName),
+			),
+			existingObjects: []runtime.Object{
+				cred,
+				management.NewManagement(
+					management.WithAvailableProviders(v1alpha1.Providers{
+						"infrastructure-docker",
+						"control-plane-k0smotron",
+						"bootstrap-k0smotron",
+					}),
+					management.WithInfrastructureProviders(map[string]v1alpha1.InfrastructureProvider{
+						"infrastructure-docker": {CredentialsPropagation: v1alpha1.CredentialsPropagationNone},
+					}),
+				),
+				template.NewClusterTemplate(
+					template.WithName(testTemplateName),
+					template.WithProvidersStatus(
+						"infrastructure-docker",
+						"control-plane-k0smotron",
+						"bootstrap-k0smotron",
+					),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+				),
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -392,6 +551,100 @@ func TestClusterDeploymentValidateUpdate(t *testing.T) {
 			warnings: admission.Warnings{fmt.Sprintf("Cluster can't be upgraded from %s to %s. This upgrade sequence is not allowed", testTemplateName, upgradeTargetTemplateName)},
 			err:      "cluster upgrade is forbidden",
 		},
+		{
+			name: "update spec.template: should fail if the new template does not support the worker pools",
+			oldClusterDeployment: clusterdeployment.NewClusterDeployment(
+				clusterdeployment.WithClusterTemplate(testTemplateName),
+				clusterdeployment.WithCredential(testCredentialName),
+				clusterdeployment.WithAvailableUpgrades([]string{upgradeTargetTemplateName}),
+				clusterdeployment.WithWorkerPools(v1alpha1.WorkerPool{Name: "gpu", Replicas: 1}),
+			),
+			newClusterDeployment: clusterdeployment.NewClusterDeployment(
+				clusterdeployment.WithClusterTemplate(upgradeTargetTemplateName),
+				clusterdeployment.WithCredential(testCredentialName),
+				clusterdeployment.WithWorkerPools(v1alpha1.WorkerPool{Name: "gpu", Replicas: 1}),
+			),
+			existingObjects: []runtime.Object{
+				mgmt, cred,
+				template.NewClusterTemplate(
+					template.WithName(upgradeTargetTemplateName),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+					template.WithProvidersStatus(
+						"infrastructure-aws",
+						"control-plane-k0smotron",
+						"bootstrap-k0smotron",
+					),
+				),
+			},
+			err: fmt.Sprintf("the ClusterDeployment is invalid: template %q does not support worker pools", upgradeTargetTemplateName),
+		},
+		{
+			name: "update spec.template: should succeed if the upgrade is rolled back to the previous template",
+			oldClusterDeployment: clusterdeployment.NewClusterDeployment(
+				clusterdeployment.WithClusterTemplate(upgradeTargetTemplateName),
+				clusterdeployment.WithCredential(testCredentialName),
+				clusterdeployment.WithAvailableUpgrades([]string{}),
+				clusterdeployment.WithUpgradeStatus(&v1alpha1.UpgradeStatus{
+					FromTemplate: testTemplateName,
+					ToTemplate:   upgradeTargetTemplateName,
+					Phase:        v1alpha1.UpgradePhaseProgressing,
+				}),
+			),
+			newClusterDeployment: clusterdeployment.NewClusterDeployment(
+				clusterdeployment.WithClusterTemplate(testTemplateName),
+				clusterdeployment.WithCredential(testCredentialName),
+			),
+			existingObjects: []runtime.Object{
+				mgmt, cred,
+				template.NewClusterTemplate(
+					template.WithName(testTemplateName),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+					template.WithProvidersStatus(
+						"infrastructure-aws",
+						"control-plane-k0smotron",
+						"bootstrap-k0smotron",
+					),
+				),
+				template.NewClusterTemplate(
+					template.WithName(upgradeTargetTemplateName),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+					template.WithProvidersStatus(
+						"infrastructure-aws",
+						"control-plane-k0smotron",
+						"bootstrap-k0smotron",
+					),
+				),
+			},
+		},
+		{
+			name: "update spec.template: should succeed if the template is rolled back to a revision",
+			oldClusterDeployment: clusterdeployment.NewClusterDeployment(
+				clusterdeployment.WithClusterTemplate(upgradeTargetTemplateName),
+				clusterdeployment.WithCredential(testCredentialName),
+				clusterdeployment.WithAvailableUpgrades([]string{}),
+				clusterdeployment.WithAnnotations(map[string]string{v1alpha1.ClusterDeploymentRollbackAnnotation: "1"}),
+				clusterdeployment.WithHistory(
+					v1alpha1.ClusterRevision{Revision: 1, Template: testTemplateName, Outcome: v1alpha1.RevisionOutcomeDeployed},
+					v1alpha1.ClusterRevision{Revision: 2, Template: upgradeTargetTemplateName, Outcome: v1alpha1.RevisionOutcomeDeployed},
+				),
+			),
+			newClusterDeployment: clusterdeployment.NewClusterDeployment(
+				clusterdeployment.WithClusterTemplate(testTemplateName),
+				clusterdeployment.WithCredential(testCredentialName),
+			),
+			existingObjects: []runtime.Object{
+				mgmt, cred,
+				template.NewClusterTemplate(
+					template.WithName(testTemplateName),
+					template.WithValidationStatus(v1alpha1.TemplateValidationStatus{Valid: true}),
+					template.WithProvidersStatus(
+						"infrastructure-aws",
+						"control-plane-k0smotron",
+						"bootstrap-k0smotron",
+					),
+				),
+			},
+		},
 		{
 			name: "update spec.template: should succeed if the template is in the list of available",
 			oldClusterDeployment: clusterdeployment.NewClusterDeployment(
@@ -635,6 +888,11 @@ func TestClusterDeploymentDefault(t *testing.T) {
 			input:  clusterdeployment.NewClusterDeployment(clusterdeployment.WithConfig(clusterDeploymentConfig)),
 			output: clusterdeployment.NewClusterDeployment(clusterdeployment.WithConfig(clusterDeploymentConfig)),
 		},
+		{
+			name:   "should not set defaults if the cluster is adopted",
+			input:  clusterdeployment.NewClusterDeployment(clusterdeployment.WithClusterTemplate(testTemplateName), clusterdeployment.WithAdopt(true)),
+			output: clusterdeployment.NewClusterDeployment(clusterdeployment.WithClusterTemplate(testTemplateName), clusterdeployment.WithAdopt(true)),
+		},
 		{
 			name:   "should not set defaults: template is invalid",
 			input:  clusterdeployment.NewClusterDeployment(clusterdeployment.WithClusterTemplate(testTemplateName)),
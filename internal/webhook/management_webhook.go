@@ -64,6 +64,12 @@ func (v *ManagementValidator) ValidateCreate(ctx context.Context, obj runtime.Ob
 				field.Forbidden(field.NewPath("spec", "release"), err.Error()),
 			})
 	}
+	if err := validateProviderDependencies(mgmt.Spec.Providers); err != nil {
+		return nil,
+			apierrors.NewInvalid(mgmt.GroupVersionKind().GroupKind(), mgmt.Name, field.ErrorList{
+				field.Forbidden(field.NewPath("spec", "providers"), err.Error()),
+			})
+	}
 	return nil, nil
 }
 
@@ -90,6 +96,13 @@ func (v *ManagementValidator) ValidateUpdate(ctx context.Context, oldObj, newObj
 		}
 	}
 
+	if err := validateProviderDependencies(newMgmt.Spec.Providers); err != nil {
+		return nil,
+			apierrors.NewInvalid(newMgmt.GroupVersionKind().GroupKind(), newMgmt.Name, field.ErrorList{
+				field.Forbidden(field.NewPath("spec", "providers"), err.Error()),
+			})
+	}
+
 	if err := checkComponentsRemoval(ctx, v.Client, oldMgmt, newMgmt); err != nil {
 		return admission.Warnings{"Some of the providers cannot be removed"},
 			apierrors.NewInvalid(newMgmt.GroupVersionKind().GroupKind(), newMgmt.Name, field.ErrorList{
@@ -109,6 +122,71 @@ func (v *ManagementValidator) ValidateUpdate(ctx context.Context, oldObj, newObj
 	return nil, nil
 }
 
+// validateProviderDependencies checks the providers depend only on the other existing providers
+// or the core components and the dependencies do not form a cycle.
+func validateProviderDependencies(providers []hmcv1alpha1.Provider) error {
+	dependencies := make(map[string][]string, len(providers))
+	for _, p := range providers {
+		dependencies[p.Name] = nil
+	}
+
+	var errs error
+	for _, p := range providers {
+		for _, dependency := range p.DependsOn {
+			if dependency == hmcv1alpha1.CoreHMCName || dependency == hmcv1alpha1.CoreCAPIName {
+				continue
+			}
+			if dependency == p.Name {
+				errs = errors.Join(errs, fmt.Errorf("provider %s depends on itself", p.Name))
+				continue
+			}
+			if _, ok := dependencies[dependency]; !ok {
+				errs = errors.Join(errs, fmt.Errorf("provider %s depends on the provider %s which is not in the Management", p.Name, dependency))
+				continue
+			}
+			dependencies[p.Name] = append(dependencies[p.Name], dependency)
+		}
+	}
+	if errs != nil {
+		return errs
+	}
+
+	const (
+		visiting = iota + 1
+		visited
+	)
+	states := make(map[string]int, len(providers))
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch states[name] {
+		case visiting:
+			cycle := slices.Concat(path[slices.Index(path, name):], []string{name})
+			return fmt.Errorf("providers dependencies form a cycle: %s", strings.Join(cycle, " -> "))
+		case visited:
+			return nil
+		}
+
+		states[name] = visiting
+		for _, dependency := range dependencies[name] {
+			if err := visit(dependency, append(path, name)); err != nil {
+				return err
+			}
+		}
+		states[name] = visited
+
+		return nil
+	}
+
+	for _, p := range providers {
+		if err := visit(p.Name, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func checkComponentsRemoval(ctx context.Context, cl client.Client, oldMgmt, newMgmt *hmcv1alpha1.Management) error {
 	removedComponents := []hmcv1alpha1.Provider{}
 	for _, oldComp := range oldMgmt.Spec.Providers {
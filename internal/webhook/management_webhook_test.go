@@ -58,10 +58,46 @@ func TestManagementValidateCreate(t *testing.T) {
 			},
 			err: fmt.Sprintf(`Management "%s" is invalid: spec.release: Forbidden: release "%s" status is not ready`, management.DefaultName, release.DefaultName),
 		},
+		{
+			name: "provider depends on the provider not in the Management, should fail",
+			management: management.NewManagement(
+				management.WithRelease(release.DefaultName),
+				management.WithProviders(
+					v1alpha1.Provider{Name: "ipam", DependsOn: []string{"infra"}},
+				),
+			),
+			existingObjects: []runtime.Object{
+				release.New(
+					release.WithName(release.DefaultName),
+				),
+			},
+			err: fmt.Sprintf(`Management "%s" is invalid: spec.providers: Forbidden: provider ipam depends on the provider infra which is not in the Management`, management.DefaultName),
+		},
+		{
+			name: "providers dependencies form a cycle, should fail",
+			management: management.NewManagement(
+				management.WithRelease(release.DefaultName),
+				management.WithProviders(
+					v1alpha1.Provider{Name: "bootstrap", DependsOn: []string{"ipam"}},
+					v1alpha1.Provider{Name: "infra", DependsOn: []string{"bootstrap"}},
+					v1alpha1.Provider{Name: "ipam", DependsOn: []string{"infra"}},
+				),
+			),
+			existingObjects: []runtime.Object{
+				release.New(
+					release.WithName(release.DefaultName),
+				),
+			},
+			err: fmt.Sprintf(`Management "%s" is invalid: spec.providers: Forbidden: providers dependencies form a cycle: bootstrap -> ipam -> infra -> bootstrap`, management.DefaultName),
+		},
 		{
 			name: "should succeed",
 			management: management.NewManagement(
 				management.WithRelease(release.DefaultName),
+				management.WithProviders(
+					v1alpha1.Provider{Name: "infra", DependsOn: []string{v1alpha1.CoreCAPIName}},
+					v1alpha1.Provider{Name: "ipam", DependsOn: []string{"infra"}},
+				),
 			),
 			existingObjects: []runtime.Object{
 				release.New(
@@ -37,6 +37,7 @@ const (
 	ProviderAWS     ProviderType = "infrastructure-aws"
 	ProviderAzure   ProviderType = "infrastructure-azure"
 	ProviderVSphere ProviderType = "infrastructure-vsphere"
+	ProviderDocker  ProviderType = "infrastructure-docker"
 
 	providerLabel = "cluster.x-k8s.io/provider"
 )
@@ -50,6 +51,8 @@ const (
 	TemplateAzureStandaloneCP   Template = "azure-standalone-cp"
 	TemplateVSphereStandaloneCP Template = "vsphere-standalone-cp"
 	TemplateVSphereHostedCP     Template = "vsphere-hosted-cp"
+	TemplateDockerStandaloneCP  Template = "docker-standalone-cp"
+	TemplateDockerHostedCP      Template = "docker-hosted-cp"
 )
 
 //go:embed resources/aws-standalone-cp.yaml.tpl
@@ -70,6 +73,12 @@ var vsphereStandaloneCPClusterDeploymentTemplateBytes []byte
 //go:embed resources/vsphere-hosted-cp.yaml.tpl
 var vsphereHostedCPClusterDeploymentTemplateBytes []byte
 
+//go:embed resources/docker-standalone-cp.yaml.tpl
+var dockerStandaloneCPClusterDeploymentTemplateBytes []byte
+
+//go:embed resources/docker-hosted-cp.yaml.tpl
+var dockerHostedCPClusterDeploymentTemplateBytes []byte
+
 func FilterAllProviders() []string {
 	return []string{
 		utils.HMCControllerLabel,
@@ -77,6 +86,7 @@ func FilterAllProviders() []string {
 		GetProviderLabel(ProviderAzure),
 		GetProviderLabel(ProviderCAPI),
 		GetProviderLabel(ProviderVSphere),
+		GetProviderLabel(ProviderDocker),
 	}
 }
 
@@ -134,6 +144,10 @@ func GetUnstructured(templateName Template) *unstructured.Unstructured {
 		clusterDeploymentTemplateBytes = azureHostedCPClusterDeploymentTemplateBytes
 	case TemplateAzureStandaloneCP:
 		clusterDeploymentTemplateBytes = azureStandaloneCPClusterDeploymentTemplateBytes
+	case TemplateDockerStandaloneCP:
+		clusterDeploymentTemplateBytes = dockerStandaloneCPClusterDeploymentTemplateBytes
+	case TemplateDockerHostedCP:
+		clusterDeploymentTemplateBytes = dockerHostedCPClusterDeploymentTemplateBytes
 	default:
 		Fail(fmt.Sprintf("Unsupported template: %s", templateName))
 	}
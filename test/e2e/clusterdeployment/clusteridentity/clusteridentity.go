@@ -49,6 +49,7 @@ func New(kc *kubeclient.KubeClient, provider clusterdeployment.ProviderType) *Cl
 	GinkgoHelper()
 
 	var (
+		group            = "infrastructure.cluster.x-k8s.io"
 		resource         string
 		kind             string
 		version          string
@@ -111,13 +112,22 @@ func New(kc *kubeclient.KubeClient, provider clusterdeployment.ProviderType) *Cl
 				},
 			},
 		}
+	case clusterdeployment.ProviderDocker:
+		// The Docker provider requires no credentials, so the Credential
+		// references the Secret itself.
+		group = ""
+		resource = "secrets"
+		kind = "Secret"
+		version = "v1"
+		identityName = secretName
+		namespaced = true
 	default:
 		Fail(fmt.Sprintf("Unsupported provider: %s", provider))
 	}
 
 	ci := ClusterIdentity{
 		GroupVersionResource: schema.GroupVersionResource{
-			Group:    "infrastructure.cluster.x-k8s.io",
+			Group:    group,
 			Version:  version,
 			Resource: resource,
 		},
@@ -130,6 +140,12 @@ func New(kc *kubeclient.KubeClient, provider clusterdeployment.ProviderType) *Cl
 	}
 
 	validateSecretDataPopulated(secretStringData)
+	if ci.Kind == "Secret" {
+		ci.createSecret(kc)
+		ci.createCredential(kc)
+		return &ci
+	}
+
 	ci.waitForResourceCRD(kc)
 	ci.createSecret(kc)
 	ci.createClusterIdentity(kc)
@@ -216,7 +232,7 @@ func (ci *ClusterIdentity) createCredential(kc *kubeclient.KubeClient) {
 			},
 			"spec": map[string]any{
 				"identityRef": map[string]any{
-					"apiVersion": ci.GroupVersionResource.Group + "/" + ci.GroupVersionResource.Version,
+					"apiVersion": ci.GroupVersionResource.GroupVersion().String(),
 					"kind":       ci.Kind,
 					"name":       ci.IdentityName,
 					"namespace":  kc.Namespace,
@@ -240,7 +256,7 @@ func (ci *ClusterIdentity) createClusterIdentity(kc *kubeclient.KubeClient) {
 
 	id := &unstructured.Unstructured{
 		Object: map[string]any{
-			"apiVersion": ci.GroupVersionResource.Group + "/" + ci.GroupVersionResource.Version,
+			"apiVersion": ci.GroupVersionResource.GroupVersion().String(),
 			"kind":       ci.Kind,
 			"metadata": map[string]any{
 				"name":      ci.IdentityName,
@@ -53,6 +53,9 @@ func PatchHostedClusterReady(kc *kubeclient.KubeClient, provider ProviderType, c
 		resource = "azureclusters"
 	case ProviderVSphere:
 		return
+	case ProviderDocker:
+		version = "v1beta1"
+		resource = "dockerclusters"
 	default:
 		Fail(fmt.Sprintf("unsupported provider: %s", provider))
 	}
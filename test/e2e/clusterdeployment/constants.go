@@ -47,4 +47,7 @@ const (
 	EnvVarAzureSubscription    = "AZURE_SUBSCRIPTION"
 	EnvVarAzureClusterIdentity = "AZURE_CLUSTER_IDENTITY"
 	EnvVarAzureRegion          = "AZURE_REGION"
+
+	// Docker
+	EnvVarDockerClusterIdentity = "DOCKER_CLUSTER_IDENTITY"
 )
@@ -0,0 +1,77 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
+	"github.com/K0rdent/kcm/test/e2e/kubeclient"
+)
+
+// ProviderName is the name of the Management provider installing the
+// Docker infrastructure provider.
+const ProviderName = "cluster-api-provider-docker"
+
+// EnableProvider adds the Docker infrastructure provider to the Management
+// unless it is already there. The provider is not installed by default since
+// it is intended for development and testing only. The provider is installed
+// in the airgap mode, i.e. from the components vendored into its chart by
+// `make helm-package`, so the tests do not depend on GitHub being reachable.
+func EnableProvider(ctx context.Context, kc *kubeclient.KubeClient) {
+	GinkgoHelper()
+
+	client := kc.GetDynamicClient(schema.GroupVersionResource{
+		Group:    "hmc.mirantis.com",
+		Version:  "v1alpha1",
+		Resource: "managements",
+	}, false)
+
+	Eventually(func() error {
+		mgmt, err := client.Get(ctx, hmc.ManagementName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		providers, _, err := unstructured.NestedSlice(mgmt.Object, "spec", "providers")
+		if err != nil {
+			return err
+		}
+
+		for _, p := range providers {
+			if provider, ok := p.(map[string]any); ok && provider["name"] == ProviderName {
+				return nil
+			}
+		}
+
+		providers = append(providers, map[string]any{
+			"name":   ProviderName,
+			"config": map[string]any{"airgap": true},
+		})
+		if err := unstructured.SetNestedSlice(mgmt.Object, providers, "spec", "providers"); err != nil {
+			return err
+		}
+
+		_, err = client.Update(ctx, mgmt, metav1.UpdateOptions{})
+		return err
+	}).WithTimeout(time.Minute).WithPolling(5 * time.Second).Should(Succeed())
+}
@@ -67,6 +67,9 @@ func NewProviderValidator(template Template, clusterName string, action Validati
 			resourceOrder = append(resourceOrder, "ccm")
 		case TemplateAzureStandaloneCP, TemplateVSphereStandaloneCP:
 			delete(resourcesToValidate, "csi-driver")
+		case TemplateDockerStandaloneCP, TemplateDockerHostedCP:
+			// The machines of the Docker clusters provide no storage to be claimed.
+			delete(resourcesToValidate, "csi-driver")
 		}
 	} else {
 		resourcesToValidate = map[string]resourceValidationFunc{
@@ -0,0 +1,14 @@
+apiVersion: hmc.mirantis.com/v1alpha1
+kind: ClusterDeployment
+metadata:
+  name: ${CLUSTER_DEPLOYMENT_NAME}
+spec:
+  template: docker-hosted-cp-0-0-1
+  credential: ${DOCKER_CLUSTER_IDENTITY}-cred
+  config:
+    workersNumber: ${WORKERS_NUMBER:=1}
+    worker:
+      customImage: "${DOCKER_CUSTOM_IMAGE:=kindest/node:v1.31.0}"
+    k0s:
+      version: v1.31.1+k0s.1
+      downloadURL: "${K0S_DOWNLOAD_URL}"
@@ -0,0 +1,114 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clusterdeployment
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/K0rdent/kcm/test/e2e/kubeclient"
+)
+
+// CreateTemplateUpgrade creates the ClusterTemplate with the given upgradeTemplate
+// name from the same chart as the given template, along with the ClusterTemplateChain
+// making it available as an upgrade of the template, and returns a DeleteFunc
+// to clean up both of them.
+func CreateTemplateUpgrade(ctx context.Context, kc *kubeclient.KubeClient, template, upgradeTemplate string) func() error {
+	GinkgoHelper()
+
+	templatesClient := kc.GetDynamicClient(schema.GroupVersionResource{
+		Group:    "hmc.mirantis.com",
+		Version:  "v1alpha1",
+		Resource: "clustertemplates",
+	}, true)
+
+	source, err := templatesClient.Get(ctx, template, metav1.GetOptions{})
+	Expect(err).NotTo(HaveOccurred(), "failed to get ClusterTemplate %s", template)
+
+	helmSpec, found, err := unstructured.NestedMap(source.Object, "spec", "helm")
+	Expect(err).NotTo(HaveOccurred(), "failed to get helm spec of ClusterTemplate %s", template)
+	Expect(found).To(BeTrue(), "ClusterTemplate %s has no helm spec", template)
+
+	By("creating ClusterTemplate " + upgradeTemplate)
+	kc.CreateOrUpdateUnstructuredObject(schema.GroupVersionResource{
+		Group:    "hmc.mirantis.com",
+		Version:  "v1alpha1",
+		Resource: "clustertemplates",
+	}, &unstructured.Unstructured{
+		Object: map[string]any{
+			"apiVersion": "hmc.mirantis.com/v1alpha1",
+			"kind":       "ClusterTemplate",
+			"metadata": map[string]any{
+				"name":      upgradeTemplate,
+				"namespace": kc.Namespace,
+			},
+			"spec": map[string]any{
+				"helm": helmSpec,
+			},
+		},
+	}, true)
+
+	chainsClient := kc.GetDynamicClient(schema.GroupVersionResource{
+		Group:    "hmc.mirantis.com",
+		Version:  "v1alpha1",
+		Resource: "clustertemplatechains",
+	}, true)
+
+	By("creating ClusterTemplateChain " + upgradeTemplate)
+	chain := &unstructured.Unstructured{
+		Object: map[string]any{
+			"apiVersion": "hmc.mirantis.com/v1alpha1",
+			"kind":       "ClusterTemplateChain",
+			"metadata": map[string]any{
+				"name":      upgradeTemplate,
+				"namespace": kc.Namespace,
+			},
+			"spec": map[string]any{
+				"supportedTemplates": []any{
+					map[string]any{
+						"name": template,
+						"availableUpgrades": []any{
+							map[string]any{"name": upgradeTemplate},
+						},
+					},
+					map[string]any{"name": upgradeTemplate},
+				},
+			},
+		},
+	}
+	// The spec of the chain is immutable, so it is not updated if already exists.
+	_, err = chainsClient.Create(ctx, chain, metav1.CreateOptions{})
+	if !apierrors.IsAlreadyExists(err) {
+		Expect(err).NotTo(HaveOccurred(), "failed to create ClusterTemplateChain %s", upgradeTemplate)
+	}
+
+	return func() error {
+		var errs error
+		for _, c := range []dynamic.ResourceInterface{chainsClient, templatesClient} {
+			if err := c.Delete(ctx, upgradeTemplate, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				errs = errors.Join(errs, err)
+			}
+		}
+		return errs
+	}
+}
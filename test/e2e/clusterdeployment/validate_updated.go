@@ -0,0 +1,117 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clusterdeployment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
+	"github.com/K0rdent/kcm/internal/sveltos"
+	"github.com/K0rdent/kcm/test/e2e/kubeclient"
+	"github.com/K0rdent/kcm/test/utils"
+)
+
+// ValidateClusterDeploymentTemplate validates that the ClusterDeployment with
+// the given name has been reconciled with the given template and is ready.
+// It is meant to be used in conjunction with an Eventually block.
+func ValidateClusterDeploymentTemplate(ctx context.Context, kc *kubeclient.KubeClient, name, template string) error {
+	clusterDeployment, err := getClusterDeployment(ctx, kc, name)
+	if err != nil {
+		return err
+	}
+
+	if clusterDeployment.Spec.Template != template {
+		return fmt.Errorf("ClusterDeployment %s has template %s, expected %s", name, clusterDeployment.Spec.Template, template)
+	}
+
+	if clusterDeployment.Status.ObservedGeneration != clusterDeployment.Generation {
+		return fmt.Errorf("ClusterDeployment %s has not been reconciled yet", name)
+	}
+
+	var errs error
+	for _, conditionType := range []string{hmc.HelmReleaseReadyCondition, hmc.ReadyCondition} {
+		condition := apimeta.FindStatusCondition(clusterDeployment.Status.Conditions, conditionType)
+		if condition == nil {
+			errs = errors.Join(errs, fmt.Errorf("no %s condition found", conditionType))
+			continue
+		}
+		if condition.Status != metav1.ConditionTrue {
+			errs = errors.Join(errs, errors.New(utils.ConvertConditionsToString(*condition)))
+		}
+	}
+
+	if errs != nil {
+		return fmt.Errorf("ClusterDeployment %s is not ready with conditions:\n%w", name, errs)
+	}
+
+	return nil
+}
+
+// ValidateServices validates that the given services of the ClusterDeployment
+// with the given name are deployed to the cluster.
+// It is meant to be used in conjunction with an Eventually block.
+func ValidateServices(ctx context.Context, kc *kubeclient.KubeClient, name string, services ...hmc.ServiceSpec) error {
+	clusterDeployment, err := getClusterDeployment(ctx, kc, name)
+	if err != nil {
+		return err
+	}
+
+	var conditions []metav1.Condition
+	for _, serviceStatus := range clusterDeployment.Status.Services {
+		if serviceStatus.ClusterName == name {
+			conditions = serviceStatus.Conditions
+			break
+		}
+	}
+
+	var errs error
+	for _, svc := range services {
+		if apimeta.FindStatusCondition(conditions, sveltos.HelmReleaseReadyConditionType(svc.Namespace, svc.Name)) == nil {
+			errs = errors.Join(errs, fmt.Errorf("service %s/%s has not been deployed yet", svc.Namespace, svc.Name))
+		}
+	}
+
+	for _, c := range conditions {
+		if c.Status != metav1.ConditionTrue {
+			errs = errors.Join(errs, errors.New(utils.ConvertConditionsToString(c)))
+		}
+	}
+
+	if errs != nil {
+		return fmt.Errorf("services of ClusterDeployment %s are not ready:\n%w", name, errs)
+	}
+
+	return nil
+}
+
+func getClusterDeployment(ctx context.Context, kc *kubeclient.KubeClient, name string) (*hmc.ClusterDeployment, error) {
+	obj, err := kc.GetClusterDeployment(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterDeployment := new(hmc.ClusterDeployment)
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, clusterDeployment); err != nil {
+		return nil, fmt.Errorf("failed to convert ClusterDeployment %s: %w", name, err)
+	}
+
+	return clusterDeployment, nil
+}
@@ -16,6 +16,7 @@ package kubeclient
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -28,6 +29,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -208,6 +210,42 @@ func (kc *KubeClient) CreateClusterDeployment(
 	}
 }
 
+// GetClusterDeployment returns a ClusterDeployment resource by name.
+func (kc *KubeClient) GetClusterDeployment(ctx context.Context, name string) (*unstructured.Unstructured, error) {
+	gvr := schema.GroupVersionResource{
+		Group:    "hmc.mirantis.com",
+		Version:  "v1alpha1",
+		Resource: "clusterdeployments",
+	}
+
+	client := kc.GetDynamicClient(gvr, true)
+
+	clusterDeployment, err := client.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s %s: %w", gvr.Resource, name, err)
+	}
+
+	return clusterDeployment, nil
+}
+
+// PatchClusterDeployment applies the given merge patch to the ClusterDeployment
+// with the given name.
+func (kc *KubeClient) PatchClusterDeployment(ctx context.Context, name string, patch map[string]any) {
+	GinkgoHelper()
+
+	client := kc.GetDynamicClient(schema.GroupVersionResource{
+		Group:    "hmc.mirantis.com",
+		Version:  "v1alpha1",
+		Resource: "clusterdeployments",
+	}, true)
+
+	patchBytes, err := json.Marshal(patch)
+	Expect(err).NotTo(HaveOccurred(), "failed to marshal patch bytes")
+
+	_, err = client.Patch(ctx, name, types.MergePatchType, patchBytes, metav1.PatchOptions{})
+	Expect(err).NotTo(HaveOccurred(), "failed to patch ClusterDeployment %s", name)
+}
+
 // GetCluster returns a Cluster resource by name.
 func (kc *KubeClient) GetCluster(ctx context.Context, clusterName string) (*unstructured.Unstructured, error) {
 	gvr := schema.GroupVersionResource{
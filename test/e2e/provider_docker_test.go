@@ -0,0 +1,242 @@
+// Copyright 2024
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	hmc "github.com/K0rdent/kcm/api/v1alpha1"
+	internalutils "github.com/K0rdent/kcm/internal/utils"
+	"github.com/K0rdent/kcm/test/e2e/clusterdeployment"
+	"github.com/K0rdent/kcm/test/e2e/clusterdeployment/clusteridentity"
+	"github.com/K0rdent/kcm/test/e2e/clusterdeployment/docker"
+	"github.com/K0rdent/kcm/test/e2e/kubeclient"
+)
+
+// The Docker templates are deployed to the containers of the host running
+// the kind management cluster, so the whole lifecycle of the ClusterDeployments
+// is tested with no cloud credentials and with no network access if the images
+// used are available locally and K0S_DOWNLOAD_URL points to a local k0s binary.
+var _ = Describe("Docker Templates", Label("provider:docker"), Ordered, func() {
+	const (
+		standaloneTemplate = "docker-standalone-cp-0-0-1"
+		upgradeTemplate    = "docker-standalone-cp-0-0-1-upgrade"
+	)
+
+	var (
+		kc                   *kubeclient.KubeClient
+		standaloneDeleteFunc func() error
+		hostedDeleteFunc     func() error
+		upgradeDeleteFunc    func() error
+		clusterName          string
+		hostedClusterName    string
+
+		ingressService = hmc.ServiceSpec{
+			Template:  "ingress-nginx-4-11-3",
+			Name:      "ingress-nginx",
+			Namespace: "ingress-nginx",
+			Values: `ingress-nginx:
+  controller:
+    service:
+      type: NodePort
+`,
+		}
+	)
+
+	BeforeAll(func() {
+		kc = kubeclient.NewFromLocal(internalutils.DefaultSystemNamespace)
+
+		By("enabling the Docker provider in the Management")
+		docker.EnableProvider(context.Background(), kc)
+		Eventually(func() error {
+			return validateController(kc, clusterdeployment.GetProviderLabel(clusterdeployment.ProviderDocker), string(clusterdeployment.ProviderDocker))
+		}).WithTimeout(10 * time.Minute).WithPolling(10 * time.Second).Should(Succeed())
+
+		By("providing cluster identity")
+		ci := clusteridentity.New(kc, clusterdeployment.ProviderDocker)
+		Expect(os.Setenv(clusterdeployment.EnvVarDockerClusterIdentity, ci.IdentityName)).Should(Succeed())
+	})
+
+	AfterAll(func() {
+		// If we failed collect logs from each of the affiliated controllers
+		// as well as the output of clusterctl to store as artifacts.
+		if CurrentSpecReport().Failed() {
+			By("collecting failure logs from controllers")
+			collectLogArtifacts(kc, clusterName, clusterdeployment.ProviderDocker, clusterdeployment.ProviderCAPI)
+		}
+
+		if noCleanup() {
+			return
+		}
+
+		By("deleting resources")
+		for _, deleteFunc := range []func() error{
+			hostedDeleteFunc,
+			standaloneDeleteFunc,
+		} {
+			if deleteFunc != nil {
+				Expect(deleteFunc()).To(Succeed())
+			}
+		}
+
+		// The ClusterTemplate can be deleted only once it is not used by the
+		// ClusterDeployment anymore.
+		if upgradeDeleteFunc != nil && clusterName != "" {
+			Eventually(func() error {
+				return clusterdeployment.NewProviderValidator(
+					clusterdeployment.TemplateDockerStandaloneCP,
+					clusterName,
+					clusterdeployment.ValidationActionDelete,
+				).Validate(context.Background(), kc)
+			}).WithTimeout(10 * time.Minute).WithPolling(10 * time.Second).Should(Succeed())
+			Expect(upgradeDeleteFunc()).To(Succeed())
+		}
+	})
+
+	It("should deploy standalone cluster", func() {
+		templateBy(clusterdeployment.TemplateDockerStandaloneCP, "creating a ClusterDeployment")
+		sd := clusterdeployment.GetUnstructured(clusterdeployment.TemplateDockerStandaloneCP)
+		clusterName = sd.GetName()
+
+		standaloneDeleteFunc = kc.CreateClusterDeployment(context.Background(), sd)
+
+		templateBy(clusterdeployment.TemplateDockerStandaloneCP, "waiting for infrastructure to deploy successfully")
+		deploymentValidator := clusterdeployment.NewProviderValidator(
+			clusterdeployment.TemplateDockerStandaloneCP,
+			clusterName,
+			clusterdeployment.ValidationActionDeploy,
+		)
+		Eventually(func() error {
+			return deploymentValidator.Validate(context.Background(), kc)
+		}).WithTimeout(30 * time.Minute).WithPolling(10 * time.Second).Should(Succeed())
+	})
+
+	It("should deploy services to the standalone cluster", func() {
+		templateBy(clusterdeployment.TemplateDockerStandaloneCP, "adding the services to the ClusterDeployment")
+		kc.PatchClusterDeployment(context.Background(), clusterName, map[string]any{
+			"spec": map[string]any{
+				"services": []map[string]any{{
+					"template":  ingressService.Template,
+					"name":      ingressService.Name,
+					"namespace": ingressService.Namespace,
+					"values":    ingressService.Values,
+				}},
+			},
+		})
+
+		templateBy(clusterdeployment.TemplateDockerStandaloneCP, "waiting for the services to deploy successfully")
+		Eventually(func() error {
+			return clusterdeployment.ValidateServices(context.Background(), kc, clusterName, ingressService)
+		}).WithTimeout(15 * time.Minute).WithPolling(10 * time.Second).Should(Succeed())
+	})
+
+	It("should upgrade standalone cluster", func() {
+		templateBy(clusterdeployment.TemplateDockerStandaloneCP, "making the upgrade available")
+		upgradeDeleteFunc = clusterdeployment.CreateTemplateUpgrade(context.Background(), kc, standaloneTemplate, upgradeTemplate)
+
+		Eventually(func() error {
+			cd, err := kc.GetClusterDeployment(context.Background(), clusterName)
+			if err != nil {
+				return err
+			}
+			upgrades, _, err := unstructured.NestedStringSlice(cd.Object, "status", "availableUpgrades")
+			if err != nil {
+				return err
+			}
+			if slices.Contains(upgrades, upgradeTemplate) {
+				return nil
+			}
+			return fmt.Errorf("%s is not yet available as an upgrade of %s", upgradeTemplate, clusterName)
+		}).WithTimeout(5 * time.Minute).WithPolling(10 * time.Second).Should(Succeed())
+
+		templateBy(clusterdeployment.TemplateDockerStandaloneCP, "upgrading the ClusterDeployment")
+		kc.PatchClusterDeployment(context.Background(), clusterName, map[string]any{
+			"spec": map[string]any{"template": upgradeTemplate},
+		})
+
+		templateBy(clusterdeployment.TemplateDockerStandaloneCP, "waiting for the upgrade to complete")
+		Eventually(func() error {
+			return clusterdeployment.ValidateClusterDeploymentTemplate(context.Background(), kc, clusterName, upgradeTemplate)
+		}).WithTimeout(15 * time.Minute).WithPolling(10 * time.Second).Should(Succeed())
+
+		deploymentValidator := clusterdeployment.NewProviderValidator(
+			clusterdeployment.TemplateDockerStandaloneCP,
+			clusterName,
+			clusterdeployment.ValidationActionDeploy,
+		)
+		Eventually(func() error {
+			if err := deploymentValidator.Validate(context.Background(), kc); err != nil {
+				return err
+			}
+			return clusterdeployment.ValidateServices(context.Background(), kc, clusterName, ingressService)
+		}).WithTimeout(15 * time.Minute).WithPolling(10 * time.Second).Should(Succeed())
+	})
+
+	It("should deploy and delete hosted cluster", func() {
+		templateBy(clusterdeployment.TemplateDockerHostedCP, "creating a ClusterDeployment")
+		hd := clusterdeployment.GetUnstructured(clusterdeployment.TemplateDockerHostedCP)
+		hostedClusterName = hd.GetName()
+
+		// The control plane of the hosted cluster is run by the management cluster.
+		hostedDeleteFunc = kc.CreateClusterDeployment(context.Background(), hd)
+
+		templateBy(clusterdeployment.TemplateDockerHostedCP, "Patching DockerCluster to ready")
+		clusterdeployment.PatchHostedClusterReady(kc, clusterdeployment.ProviderDocker, hostedClusterName)
+
+		templateBy(clusterdeployment.TemplateDockerHostedCP, "waiting for infrastructure to deploy successfully")
+		deploymentValidator := clusterdeployment.NewProviderValidator(
+			clusterdeployment.TemplateDockerHostedCP,
+			hostedClusterName,
+			clusterdeployment.ValidationActionDeploy,
+		)
+		Eventually(func() error {
+			return deploymentValidator.Validate(context.Background(), kc)
+		}).WithTimeout(30 * time.Minute).WithPolling(10 * time.Second).Should(Succeed())
+
+		templateBy(clusterdeployment.TemplateDockerHostedCP, "deleting the ClusterDeployment")
+		Expect(hostedDeleteFunc()).To(Succeed())
+
+		deletionValidator := clusterdeployment.NewProviderValidator(
+			clusterdeployment.TemplateDockerHostedCP,
+			hostedClusterName,
+			clusterdeployment.ValidationActionDelete,
+		)
+		Eventually(func() error {
+			return deletionValidator.Validate(context.Background(), kc)
+		}).WithTimeout(10 * time.Minute).WithPolling(10 * time.Second).Should(Succeed())
+	})
+
+	It("should delete standalone cluster", func() {
+		templateBy(clusterdeployment.TemplateDockerStandaloneCP, "deleting the ClusterDeployment")
+		Expect(standaloneDeleteFunc()).To(Succeed())
+
+		deletionValidator := clusterdeployment.NewProviderValidator(
+			clusterdeployment.TemplateDockerStandaloneCP,
+			clusterName,
+			clusterdeployment.ValidationActionDelete,
+		)
+		Eventually(func() error {
+			return deletionValidator.Validate(context.Background(), kc)
+		}).WithTimeout(10 * time.Minute).WithPolling(10 * time.Second).Should(Succeed())
+	})
+})
@@ -50,3 +50,9 @@ func WithAccessRules(accessRules []v1alpha1.AccessRule) Opt {
 		am.Spec.AccessRules = accessRules
 	}
 }
+
+func WithClusterAccessRoles(roles ...string) Opt {
+	return func(am *v1alpha1.AccessManagement) {
+		am.Spec.ClusterAccessRoles = roles
+	}
+}
@@ -60,6 +60,12 @@ func WithDryRun(dryRun bool) Opt {
 	}
 }
 
+func WithAdopt(adopt bool) Opt {
+	return func(p *v1alpha1.ClusterDeployment) {
+		p.Spec.Adopt = adopt
+	}
+}
+
 func WithClusterTemplate(templateName string) Opt {
 	return func(p *v1alpha1.ClusterDeployment) {
 		p.Spec.Template = templateName
@@ -93,3 +99,27 @@ func WithAvailableUpgrades(availableUpgrades []string) Opt {
 		p.Status.AvailableUpgrades = availableUpgrades
 	}
 }
+
+func WithUpgradeStatus(upgrade *v1alpha1.UpgradeStatus) Opt {
+	return func(p *v1alpha1.ClusterDeployment) {
+		p.Status.Upgrade = upgrade
+	}
+}
+
+func WithAnnotations(annotations map[string]string) Opt {
+	return func(p *v1alpha1.ClusterDeployment) {
+		p.Annotations = annotations
+	}
+}
+
+func WithHistory(history ...v1alpha1.ClusterRevision) Opt {
+	return func(p *v1alpha1.ClusterDeployment) {
+		p.Status.History = history
+	}
+}
+
+func WithWorkerPools(workerPools ...v1alpha1.WorkerPool) Opt {
+	return func(p *v1alpha1.ClusterDeployment) {
+		p.Spec.WorkerPools = workerPools
+	}
+}
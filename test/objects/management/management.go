@@ -78,6 +78,12 @@ func WithAvailableProviders(providers v1alpha1.Providers) Opt {
 	}
 }
 
+func WithInfrastructureProviders(infrastructureProviders map[string]v1alpha1.InfrastructureProvider) Opt {
+	return func(p *v1alpha1.Management) {
+		p.Status.InfrastructureProviders = infrastructureProviders
+	}
+}
+
 func WithComponentsStatus(components map[string]v1alpha1.ComponentStatus) Opt {
 	return func(p *v1alpha1.Management) {
 		p.Status.Components = components
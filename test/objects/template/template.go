@@ -213,3 +213,13 @@ func WithClusterStatusK8sVersion(v string) Opt {
 		ct.Status.KubernetesVersion = v
 	}
 }
+
+func WithClusterStatusWorkerPools(mapping *v1alpha1.WorkerPoolsMapping) Opt {
+	return func(template Template) {
+		ct, ok := template.(*v1alpha1.ClusterTemplate)
+		if !ok {
+			panic(fmt.Sprintf("unexpected type %T, expected ClusterTemplate", template))
+		}
+		ct.Status.WorkerPools = mapping
+	}
+}